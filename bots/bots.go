@@ -0,0 +1,186 @@
+// Package bots provides baseline agents for the tables of mahjong: a random bot, a tsumogiri bot,
+// a shanten greedy bot and a riichi and fold bot. A bot is deterministic for its seed, so self-play can be reproduced.
+package bots
+
+import (
+	"context"
+	"math/rand"
+
+	"github.com/hphphp123321/mahjong-go/mahjong"
+)
+
+// Option option of the bots
+type Option func(b *base)
+
+// WithNoise
+//
+//	@Description: choose a uniformly random valid call instead of the call of the bot with the probability noise,
+//	0 for the full strength and 1 for a random bot
+//	@param noise: probability of a random call
+//	@return Option
+func WithNoise(noise float64) Option {
+	return func(b *base) {
+		b.noise = noise
+	}
+}
+
+// base the random source and the noise of a bot
+type base struct {
+	r     *rand.Rand
+	noise float64
+}
+
+// newBase returns the base of a bot with the seed
+func newBase(seed int64, opts []Option) base {
+	b := base{r: rand.New(rand.NewSource(seed))}
+	for _, opt := range opts {
+		opt(&b)
+	}
+	return b
+}
+
+// random returns a random call when the noise hits, nil otherwise
+func (b *base) random(calls mahjong.Calls) *mahjong.Call {
+	if b.noise > 0 && b.r.Float64() < b.noise {
+		return calls[b.r.Intn(len(calls))]
+	}
+	return nil
+}
+
+// Random a bot choosing a valid call uniformly at random, the noise makes no difference
+type Random struct {
+	base
+}
+
+// NewRandom
+//
+//	@Description: create a random bot
+//	@param seed: random seed
+//	@param opts: options
+//	@return *Random
+func NewRandom(seed int64, opts ...Option) *Random {
+	return &Random{base: newBase(seed, opts)}
+}
+
+func (b *Random) Observe(mahjong.Events) error {
+	return nil
+}
+
+func (b *Random) Choose(_ context.Context, calls mahjong.Calls, _ *mahjong.BoardState) (*mahjong.Call, error) {
+	return calls[b.r.Intn(len(calls))], nil
+}
+
+// Tsumogiri a bot skipping every call and discarding the tile just drawn
+type Tsumogiri struct {
+	base
+}
+
+// NewTsumogiri
+//
+//	@Description: create a tsumogiri bot
+//	@param seed: random seed
+//	@param opts: options
+//	@return *Tsumogiri
+func NewTsumogiri(seed int64, opts ...Option) *Tsumogiri {
+	return &Tsumogiri{base: newBase(seed, opts)}
+}
+
+func (b *Tsumogiri) Observe(mahjong.Events) error {
+	return nil
+}
+
+func (b *Tsumogiri) Choose(_ context.Context, calls mahjong.Calls, state *mahjong.BoardState) (*mahjong.Call, error) {
+	if call := b.random(calls); call != nil {
+		return call, nil
+	}
+	return mahjong.DefaultCall(calls, state.DrawnTile), nil
+}
+
+// Greedy a bot improving the shanten of the hand, it wins whenever it can, declares riichi once tenpai,
+// discards the tile leaving the most unseen tiles to improve the hand and pons a yakuhai only to lower the shanten
+type Greedy struct {
+	base
+}
+
+// NewGreedy
+//
+//	@Description: create a shanten greedy bot
+//	@param seed: random seed
+//	@param opts: options
+//	@return *Greedy
+func NewGreedy(seed int64, opts ...Option) *Greedy {
+	return &Greedy{base: newBase(seed, opts)}
+}
+
+func (b *Greedy) Observe(mahjong.Events) error {
+	return nil
+}
+
+func (b *Greedy) Choose(_ context.Context, calls mahjong.Calls, state *mahjong.BoardState) (*mahjong.Call, error) {
+	if call := b.random(calls); call != nil {
+		return call, nil
+	}
+	return chooseEfficient(calls, state, nil), nil
+}
+
+// RiichiFold a Greedy bot folding after the riichi of an opponent, it discards the safest tile unless
+// its hand is tenpai and makes no call but wins
+type RiichiFold struct {
+	base
+	discards mahjong.TileClasses  // classes of the discards of the round in order
+	riichiAt map[mahjong.Wind]int // index of the riichi discard of every player
+}
+
+// NewRiichiFold
+//
+//	@Description: create a riichi and fold bot
+//	@param seed: random seed
+//	@param opts: options
+//	@return *RiichiFold
+func NewRiichiFold(seed int64, opts ...Option) *RiichiFold {
+	return &RiichiFold{base: newBase(seed, opts), riichiAt: make(map[mahjong.Wind]int)}
+}
+
+// Observe keeps the discards to know the tiles passed by the riichi players
+func (b *RiichiFold) Observe(events mahjong.Events) error {
+	for _, event := range events {
+		switch e := event.(type) {
+		case *mahjong.EventStart:
+			b.discards = b.discards[:0]
+			b.riichiAt = make(map[mahjong.Wind]int)
+		case *mahjong.EventDiscard:
+			b.discards = append(b.discards, e.Tile.Class())
+		case *mahjong.EventTsumoGiri:
+			b.discards = append(b.discards, e.Tile.Class())
+		case *mahjong.EventRiichi:
+			if e.Step == 2 {
+				b.riichiAt[e.Who] = len(b.discards) - 1
+			}
+		}
+	}
+	return nil
+}
+
+func (b *RiichiFold) Choose(_ context.Context, calls mahjong.Calls, state *mahjong.BoardState) (*mahjong.Call, error) {
+	if call := b.random(calls); call != nil {
+		return call, nil
+	}
+	var safe []map[mahjong.TileClass]bool
+	for wind, player := range state.PlayerStates {
+		if wind == state.PlayerWind || !player.IsRiichi {
+			continue
+		}
+		// the tiles discarded by the player and passed after the riichi are safe against it
+		classes := make(map[mahjong.TileClass]bool)
+		for _, tile := range player.DiscardTiles {
+			classes[tile.Class()] = true
+		}
+		if at, ok := b.riichiAt[wind]; ok {
+			for _, class := range b.discards[at:] {
+				classes[class] = true
+			}
+		}
+		safe = append(safe, classes)
+	}
+	return chooseEfficient(calls, state, safe), nil
+}
@@ -0,0 +1,200 @@
+package bots
+
+import (
+	"github.com/hphphp123321/mahjong-go/mahjong"
+)
+
+// evaluation the shanten of a hand of 13 tiles and the number of the unseen tiles improving it, compared in order
+type evaluation struct {
+	shanten int
+	ukeire  int
+	keep    int // value of the tile discarded, the lower one is discarded on a tie
+}
+
+// better returns whether the evaluation is better than other
+func (e evaluation) better(other evaluation) bool {
+	if e.shanten != other.shanten {
+		return e.shanten < other.shanten
+	}
+	if e.ukeire != other.ukeire {
+		return e.ukeire > other.ukeire
+	}
+	return e.keep < other.keep
+}
+
+// evaluate returns the evaluation of a hand of 13 tiles, the ukeire counts the unseen tiles of the accepting classes
+func evaluate(hand mahjong.Tiles, melds mahjong.Calls, remain []int) evaluation {
+	shanten, classes := mahjong.GetAcceptTileClasses(hand, melds)
+	e := evaluation{shanten: shanten}
+	for _, class := range classes {
+		e.ukeire += remain[class]
+	}
+	return e
+}
+
+// without returns the hand without the tiles
+func without(hand mahjong.Tiles, tiles ...mahjong.Tile) mahjong.Tiles {
+	rest := make(mahjong.Tiles, 0, len(hand))
+	for _, t := range hand {
+		removed := false
+		for _, tile := range tiles {
+			removed = removed || t == tile
+		}
+		if !removed {
+			rest = append(rest, t)
+		}
+	}
+	return rest
+}
+
+// melds returns the melds of the player, never nil
+func melds(state *mahjong.BoardState) mahjong.Calls {
+	calls := mahjong.Calls{}
+	if player, ok := state.PlayerStates[state.PlayerWind]; ok {
+		calls = append(calls, player.Melds...)
+	}
+	return calls
+}
+
+// isYakuhai returns whether a pon of the class is a yakuhai of the player
+func isYakuhai(class mahjong.TileClass, state *mahjong.BoardState) bool {
+	roundWind := mahjong.Ton + mahjong.TileClass(int(state.WindRound-mahjong.WindRoundEast1)/4)
+	return class >= mahjong.Haku || class == roundWind || class == mahjong.Ton+mahjong.TileClass(state.PlayerWind)
+}
+
+// keepValue returns how much the tile is worth keeping, isolated honors go first, then terminals, doras last
+func keepValue(tile mahjong.Tile, state *mahjong.BoardState) int {
+	class := tile.Class()
+	value := 4
+	switch {
+	case class >= mahjong.Ton:
+		value = 0
+		if isYakuhai(class, state) {
+			value = 1
+		}
+	case class%9 == 0 || class%9 == 8:
+		value = 2
+	case class%9 == 1 || class%9 == 7:
+		value = 3
+	}
+	for _, dora := range mahjong.IndicatorsToDora(state.DoraIndicators) {
+		if dora.Class() == class {
+			value += 2
+		}
+	}
+	return value
+}
+
+// safety returns how safe the tile is against all the riichi players, the lowest safety of them
+func safety(class mahjong.TileClass, remain []int, safe []map[mahjong.TileClass]bool) int {
+	lowest := -1
+	for _, classes := range safe {
+		s := 0
+		n := int(class % 9)
+		switch {
+		case classes[class]:
+			s = 100
+		case class >= mahjong.Ton:
+			// an honor with fewer copies left is less likely waited on
+			s = 70 - 10*remain[class]
+		case (n < 3 || classes[class-3]) && (n > 5 || classes[class+3]):
+			s = 30
+			if n == 0 || n == 8 {
+				s = 40
+			}
+		case n == 0 || n == 8:
+			s = 15
+		case n == 1 || n == 7:
+			s = 8
+		}
+		if lowest == -1 || s < lowest {
+			lowest = s
+		}
+	}
+	return lowest
+}
+
+// chooseEfficient chooses a call by the shanten and the ukeire, the player folds to the safest discard against
+// the riichi players with the safe classes if its hand is not tenpai
+func chooseEfficient(calls mahjong.Calls, state *mahjong.BoardState, safe []map[mahjong.TileClass]bool) *mahjong.Call {
+	var discards, riichis, pons mahjong.Calls
+	for _, call := range calls {
+		switch call.CallType {
+		case mahjong.Tsumo, mahjong.Ron, mahjong.ChanKan, mahjong.KyuuShuKyuuHai, mahjong.Kita, mahjong.AgariYame:
+			return call
+		case mahjong.Discard:
+			discards = append(discards, call)
+		case mahjong.Riichi:
+			riichis = append(riichis, call)
+		case mahjong.Pon:
+			pons = append(pons, call)
+		}
+	}
+	remain := state.GetRemainTileClassNums()
+	hand, meldCalls := state.HandTiles, melds(state)
+
+	if len(discards) > 0 {
+		// the discards of the same class are evaluated once
+		evaluations := make(map[mahjong.TileClass]evaluation)
+		minShanten := -1
+		for _, call := range discards {
+			class := call.CallTiles[0].Class()
+			if _, ok := evaluations[class]; !ok {
+				e := evaluate(without(hand, call.CallTiles[0]), meldCalls, remain)
+				evaluations[class] = e
+				if minShanten == -1 || e.shanten < minShanten {
+					minShanten = e.shanten
+				}
+			}
+		}
+		if len(safe) > 0 && minShanten > 0 {
+			// the safest first, the lower shanten on a tie
+			safest, _ := bestCall(discards, func(call *mahjong.Call) evaluation {
+				tile := call.CallTiles[0]
+				return evaluation{shanten: -safety(tile.Class(), remain, safe), ukeire: -evaluations[tile.Class()].shanten, keep: keepValue(tile, state)}
+			})
+			return safest
+		}
+		evaluateCall := func(call *mahjong.Call) evaluation {
+			tile := call.CallTiles[0]
+			e := evaluations[tile.Class()]
+			e.keep = keepValue(tile, state)
+			return e
+		}
+		if len(riichis) > 0 && len(safe) == 0 {
+			riichi, _ := bestCall(riichis, evaluateCall)
+			return riichi
+		}
+		best, _ := bestCall(discards, evaluateCall)
+		return best
+	}
+
+	if len(safe) == 0 {
+		before := mahjong.CalculateShantenNum(hand, meldCalls)
+		for _, pon := range pons {
+			if !isYakuhai(pon.CallTiles[0].Class(), state) {
+				continue
+			}
+			rest := without(hand, pon.CallTiles[0], pon.CallTiles[1])
+			after := append(meldCalls[:len(meldCalls):len(meldCalls)], pon)
+			for _, tile := range rest {
+				if mahjong.CalculateShantenNum(without(rest, tile), after) < before {
+					return pon
+				}
+			}
+		}
+	}
+	return mahjong.DefaultCall(calls, state.DrawnTile)
+}
+
+// bestCall returns the call of the best evaluation, the first one on a tie
+func bestCall(calls mahjong.Calls, evaluate func(call *mahjong.Call) evaluation) (*mahjong.Call, evaluation) {
+	var best *mahjong.Call
+	var bestEval evaluation
+	for _, call := range calls {
+		if e := evaluate(call); best == nil || e.better(bestEval) {
+			best, bestEval = call, e
+		}
+	}
+	return best, bestEval
+}
@@ -16,9 +16,14 @@ type BoardState struct {
 	PlayerWind     Wind                  `json:"player_wind"`
 	Position       Wind                  `json:"position"`
 	HandTiles      Tiles                 `json:"hand_tiles"`
+	KuikaeTiles    Tiles                 `json:"kuikae_tiles,omitempty"` // hand tiles restricted by kuikae right after the chi or pon
+	DrawnTile      Tile                  `json:"drawn_tile"`             // tile just drawn by the player, TileDummy once the player discards or calls
 	ValidActions   Calls                 `json:"valid_actions,omitempty"`
 	NumRemainTiles int                   `json:"remain_tiles"`
 	PlayerStates   map[Wind]*PlayerState `json:"player_states"`
+
+	riichiDeposit int          // points of one riichi stick, from the rule of the round
+	kuikaePolicy  KuikaePolicy // kuikae policy, from the rule of the round
 }
 
 type PlayerState struct {
@@ -27,6 +32,22 @@ type PlayerState struct {
 	DiscardTiles   Tiles  `json:"discards"`
 	TilesTsumoGiri []bool `json:"tsumo_giri"`
 	IsRiichi       bool   `json:"riichi"`
+	KitaTiles      Tiles  `json:"kita,omitempty"`
+}
+
+func newPlayerStates(numPlayers int) map[Wind]*PlayerState {
+	var playerStates = make(map[Wind]*PlayerState, numPlayers)
+	for i := 0; i < numPlayers; i++ {
+		playerStates[Wind(i)] = &PlayerState{
+			Points:         GetDefaultRule().StartingPoints,
+			Melds:          make(Calls, 0, 4),
+			DiscardTiles:   make(Tiles, 0, 25),
+			TilesTsumoGiri: make([]bool, 0, 25),
+			IsRiichi:       false,
+			KitaTiles:      make(Tiles, 0, 4),
+		}
+	}
+	return playerStates
 }
 
 func NewBoardState() *BoardState {
@@ -38,38 +59,10 @@ func NewBoardState() *BoardState {
 		PlayerWind:     -1,
 		Position:       -1,
 		HandTiles:      make(Tiles, 0, 14),
+		DrawnTile:      TileDummy,
 		ValidActions:   nil,
 		NumRemainTiles: -1,
-		PlayerStates: map[Wind]*PlayerState{
-			East: {
-				Points:         25000,
-				Melds:          make(Calls, 0, 4),
-				DiscardTiles:   make(Tiles, 0, 25),
-				TilesTsumoGiri: make([]bool, 0, 25),
-				IsRiichi:       false,
-			},
-			South: {
-				Points:         25000,
-				Melds:          make(Calls, 0, 4),
-				DiscardTiles:   make(Tiles, 0, 25),
-				TilesTsumoGiri: make([]bool, 0, 25),
-				IsRiichi:       false,
-			},
-			West: {
-				Points:         25000,
-				Melds:          make(Calls, 0, 4),
-				DiscardTiles:   make(Tiles, 0, 25),
-				TilesTsumoGiri: make([]bool, 0, 25),
-				IsRiichi:       false,
-			},
-			North: {
-				Points:         25000,
-				Melds:          make(Calls, 0, 4),
-				DiscardTiles:   make(Tiles, 0, 25),
-				TilesTsumoGiri: make([]bool, 0, 25),
-				IsRiichi:       false,
-			},
-		},
+		PlayerStates:   newPlayerStates(4),
 	}
 }
 
@@ -81,38 +74,11 @@ func (b *BoardState) Reset() {
 	b.PlayerWind = -1
 	b.Position = -1
 	b.HandTiles = make(Tiles, 0, 14)
+	b.KuikaeTiles = nil
+	b.DrawnTile = TileDummy
 	b.ValidActions = nil
 	b.NumRemainTiles = -1
-	b.PlayerStates = map[Wind]*PlayerState{
-		East: {
-			Points:         25000,
-			Melds:          make(Calls, 0, 4),
-			DiscardTiles:   make(Tiles, 0, 25),
-			TilesTsumoGiri: make([]bool, 0, 25),
-			IsRiichi:       false,
-		},
-		South: {
-			Points:         25000,
-			Melds:          make(Calls, 0, 4),
-			DiscardTiles:   make(Tiles, 0, 25),
-			TilesTsumoGiri: make([]bool, 0, 25),
-			IsRiichi:       false,
-		},
-		West: {
-			Points:         25000,
-			Melds:          make(Calls, 0, 4),
-			DiscardTiles:   make(Tiles, 0, 25),
-			TilesTsumoGiri: make([]bool, 0, 25),
-			IsRiichi:       false,
-		},
-		North: {
-			Points:         25000,
-			Melds:          make(Calls, 0, 4),
-			DiscardTiles:   make(Tiles, 0, 25),
-			TilesTsumoGiri: make([]bool, 0, 25),
-			IsRiichi:       false,
-		},
-	}
+	b.PlayerStates = newPlayerStates(4)
 }
 
 func (b *BoardState) UTF8() string {
@@ -122,7 +88,12 @@ func (b *BoardState) UTF8() string {
 	s.WriteString(" ______________________________________________________________________________________________________________________________________________________________________________\n")
 
 	// 顶部玩家信息
+	numPlayers := Wind(len(b.PlayerStates))
 	topPlayer := b.PlayerStates[(b.Position+2)%4]
+	if numPlayers < 4 {
+		// no opposite player in sanma
+		topPlayer = &PlayerState{}
+	}
 	s.WriteString(fmt.Sprintf("|                                                     Discards: %-72s \n", topPlayer.DiscardTiles.UTF8()))
 	s.WriteString(fmt.Sprintf("|                                                     Melds: %-72s \n", topPlayer.Melds.UTF8()))
 	s.WriteString(fmt.Sprintf("|                                                     Points: %-72d \n", topPlayer.Points))
@@ -131,8 +102,8 @@ func (b *BoardState) UTF8() string {
 	s.WriteString("|______________________________________________________________________________________________________________________________________________________________________________\n")
 
 	// 左侧玩家信息、中间信息和右侧玩家信息
-	leftPlayer := b.PlayerStates[(b.Position+3)%4]
-	rightPlayer := b.PlayerStates[(b.Position+1)%4]
+	leftPlayer := b.PlayerStates[(b.Position+numPlayers-1)%numPlayers]
+	rightPlayer := b.PlayerStates[(b.Position+1)%numPlayers]
 	leftPlayerDiscards := divideIntoLines(leftPlayer.DiscardTiles.UTF8(), 4)
 	rightPlayerDiscards := divideIntoLines(rightPlayer.DiscardTiles.UTF8(), 4)
 
@@ -177,10 +148,27 @@ func BoardStateCopy(boardState *BoardState) *BoardState {
 		PlayerWind:     boardState.PlayerWind,
 		Position:       boardState.Position,
 		HandTiles:      boardState.HandTiles.Copy(),
+		KuikaeTiles:    boardState.KuikaeTiles.Copy(),
+		DrawnTile:      boardState.DrawnTile,
 		ValidActions:   boardState.ValidActions.Copy(),
 		//RealActionIdx:  boardState.RealActionIdx,
 		NumRemainTiles: boardState.NumRemainTiles,
-		PlayerStates:   boardState.PlayerStates,
+		PlayerStates: func() map[Wind]*PlayerState {
+			playerStates := make(map[Wind]*PlayerState, len(boardState.PlayerStates))
+			for wind, ps := range boardState.PlayerStates {
+				playerStates[wind] = &PlayerState{
+					Points:         ps.Points,
+					Melds:          ps.Melds.Copy(),
+					DiscardTiles:   ps.DiscardTiles.Copy(),
+					TilesTsumoGiri: append([]bool(nil), ps.TilesTsumoGiri...),
+					IsRiichi:       ps.IsRiichi,
+					KitaTiles:      ps.KitaTiles.Copy(),
+				}
+			}
+			return playerStates
+		}(),
+		riichiDeposit: boardState.riichiDeposit,
+		kuikaePolicy:  boardState.kuikaePolicy,
 	}
 }
 
@@ -194,6 +182,8 @@ func (b *BoardState) MarshalJSON() ([]byte, error) {
 			PlayerWind     string `json:"player_wind"`
 			Position       string `json:"position"`
 			HandTiles      Tiles  `json:"hand_tiles"`
+			KuikaeTiles    Tiles  `json:"kuikae_tiles,omitempty"`
+			DrawnTile      Tile   `json:"drawn_tile"`
 			ValidActions   Calls  `json:"valid_actions,omitempty"`
 			//RealActionIdx  int         `json:"action_idx"`
 			NumRemainTiles int                   `json:"remain_tiles"`
@@ -206,6 +196,8 @@ func (b *BoardState) MarshalJSON() ([]byte, error) {
 			PlayerWind:     b.PlayerWind.String(),
 			Position:       b.Position.String(),
 			HandTiles:      b.HandTiles,
+			KuikaeTiles:    b.KuikaeTiles,
+			DrawnTile:      b.DrawnTile,
 			ValidActions:   b.ValidActions,
 			//RealActionIdx:  b.RealActionIdx,
 			NumRemainTiles: b.NumRemainTiles,
@@ -223,6 +215,8 @@ func (b *BoardState) UnmarshalJSON(data []byte) error {
 		PlayerWind     string `json:"player_wind"`
 		Position       string `json:"position"`
 		HandTiles      Tiles  `json:"hand_tiles"`
+		KuikaeTiles    Tiles  `json:"kuikae_tiles,omitempty"`
+		DrawnTile      Tile   `json:"drawn_tile"`
 		ValidActions   Calls  `json:"valid_actions,omitempty"`
 		//RealActionIdx  int         `json:"action_idx"`
 		NumRemainTiles int                   `json:"remain_tiles"`
@@ -238,6 +232,8 @@ func (b *BoardState) UnmarshalJSON(data []byte) error {
 	b.PlayerWind = MapStringToWind[tmp.PlayerWind]
 	b.Position = MapStringToWind[tmp.Position]
 	b.HandTiles = tmp.HandTiles
+	b.KuikaeTiles = tmp.KuikaeTiles
+	b.DrawnTile = tmp.DrawnTile
 	b.ValidActions = tmp.ValidActions
 	b.NumRemainTiles = tmp.NumRemainTiles
 	b.PlayerStates = tmp.PlayerStates
@@ -269,10 +265,64 @@ func (b *BoardState) DecodeEvents(events Events) {
 			b.handleEventRiichi(e)
 		case EventTypeNewIndicator:
 			b.handleEventNewIndicator(e)
+		case EventTypeKita:
+			b.handleEventKita(e)
+		case EventTypeKuikae:
+			b.handleEventKuikae(e)
 		}
 	}
 }
 
+// GetRemainTileClassNums
+//
+//	@Description: the number of the tiles of every class the player has not seen in the hand, the melds, the discards,
+//	the kita tiles and the dora indicators, the classes removed in sanma(three player states) have no tiles.
+//	It counts from the board as GetRemainTileClassNumFromPlayerPerspective counts from the game, for the agents
+//	that see only the board, and also removes the kita tiles and the sanma classes the game function keeps
+//	@receiver b
+//	@return []int: numbers by the tile class from Man1 to Chun
+func (b *BoardState) GetRemainTileClassNums() []int {
+	nums := make([]int, Chun+1)
+	for class := range nums {
+		nums[class] = 4
+	}
+	if len(b.PlayerStates) == 3 {
+		for _, class := range SanmaRemovedTileClasses {
+			nums[class] = 0
+		}
+	}
+	// a called discard is both in the discards and the meld
+	seen := make(map[Tile]bool, NumTiles)
+	see := func(tiles Tiles) {
+		for _, tile := range tiles {
+			if tile != TileDummy && !seen[tile] {
+				seen[tile] = true
+				nums[tile.Class()]--
+			}
+		}
+	}
+	see(b.HandTiles)
+	see(b.DoraIndicators)
+	for _, state := range b.PlayerStates {
+		see(state.DiscardTiles)
+		see(state.KitaTiles)
+		for _, meld := range state.Melds {
+			see(meld.CallTiles)
+		}
+	}
+	return nums
+}
+
+// GetRemainTileClassNum
+//
+//	@Description: the number of the tiles of the class the player has not seen, see GetRemainTileClassNums
+//	@receiver b
+//	@param tileClass: tile class
+//	@return int
+func (b *BoardState) GetRemainTileClassNum(tileClass TileClass) int {
+	return b.GetRemainTileClassNums()[tileClass]
+}
+
 func (b *BoardState) Equal(bs *BoardState) bool {
 	if b.WindRound != bs.WindRound {
 		return false
@@ -295,6 +345,12 @@ func (b *BoardState) Equal(bs *BoardState) bool {
 	if !common.SliceEqual(b.HandTiles, bs.HandTiles) {
 		return false
 	}
+	if !common.SliceEqual(b.KuikaeTiles, bs.KuikaeTiles) {
+		return false
+	}
+	if b.DrawnTile != bs.DrawnTile {
+		return false
+	}
 	if b.NumRemainTiles != bs.NumRemainTiles {
 		return false
 	}
@@ -311,6 +367,9 @@ func (b *BoardState) Equal(bs *BoardState) bool {
 		if !common.SliceEqual(ps.TilesTsumoGiri, bs.PlayerStates[wind].TilesTsumoGiri) {
 			return false
 		}
+		if !common.SliceEqual(ps.KitaTiles, bs.PlayerStates[wind].KitaTiles) {
+			return false
+		}
 		for i, meld := range ps.Melds {
 			if !CallEqual(meld, bs.PlayerStates[wind].Melds[i]) {
 				return false
@@ -332,15 +391,19 @@ func (b *BoardState) handleEventStart(event Event) {
 		b.HandTiles.Append(tile)
 	}
 	sort.Sort(&b.HandTiles)
+	b.PlayerStates = newPlayerStates(event.(*EventStart).Rule.NumPlayers())
 	for wind, points := range event.(*EventStart).PlayersPoints {
 		b.PlayerStates[wind].Points = points
 	}
-	b.NumRemainTiles = 70
+	b.NumRemainTiles = event.(*EventStart).Rule.NumInitRemainTiles()
+	b.riichiDeposit = event.(*EventStart).Rule.RiichiDeposit
+	b.kuikaePolicy = event.(*EventStart).Rule.KuikaePolicy
 }
 
 func (b *BoardState) handleEventGet(event Event) {
 	if event.(*EventGet).Who == b.PlayerWind {
 		b.HandTiles = append(b.HandTiles, event.(*EventGet).Tile)
+		b.DrawnTile = event.(*EventGet).Tile
 	}
 	b.NumRemainTiles--
 	b.Position = event.(*EventGet).Who
@@ -351,6 +414,8 @@ func (b *BoardState) handleEventDiscard(event Event) {
 	if who == b.PlayerWind {
 		b.HandTiles.Remove(event.(*EventDiscard).Tile)
 		sort.Sort(&b.HandTiles)
+		b.KuikaeTiles = nil
+		b.DrawnTile = TileDummy
 	}
 	b.PlayerStates[who].DiscardTiles.Append(event.(*EventDiscard).Tile)
 	b.PlayerStates[who].TilesTsumoGiri = append(b.PlayerStates[who].TilesTsumoGiri, false)
@@ -361,6 +426,9 @@ func (b *BoardState) handleEventTsumoGiri(event Event) {
 	who := event.(*EventTsumoGiri).Who
 	if who == b.PlayerWind {
 		b.HandTiles.Remove(event.(*EventTsumoGiri).Tile)
+		sort.Sort(&b.HandTiles)
+		b.KuikaeTiles = nil
+		b.DrawnTile = TileDummy
 	}
 	b.PlayerStates[who].DiscardTiles.Append(event.(*EventTsumoGiri).Tile)
 	b.PlayerStates[who].TilesTsumoGiri = append(b.PlayerStates[who].TilesTsumoGiri, true)
@@ -373,6 +441,8 @@ func (b *BoardState) handleEventChi(event Event) {
 		for i := 0; i < 2; i++ {
 			b.HandTiles.Remove(call.CallTiles[i])
 		}
+		b.setKuikaeTiles(call)
+		b.DrawnTile = TileDummy
 	}
 	b.PlayerStates[who].Melds.Append(call)
 	b.Position = who
@@ -386,11 +456,34 @@ func (b *BoardState) handleEventPon(event Event) {
 		for i := 0; i < 2; i++ {
 			b.HandTiles.Remove(call.CallTiles[i])
 		}
+		b.setKuikaeTiles(call)
+		b.DrawnTile = TileDummy
 	}
 	b.PlayerStates[who].Melds.Append(call)
 	b.Position = who
 }
 
+// setKuikaeTiles marks the hand tiles restricted by kuikae after the chi or pon
+func (b *BoardState) setKuikaeTiles(call *Call) {
+	kuikaeClasses := getKuikaeClasses(call, b.kuikaePolicy)
+	b.KuikaeTiles = nil
+	for _, tile := range b.HandTiles {
+		if common.SliceContain(kuikaeClasses, tile.Class()) {
+			b.KuikaeTiles = append(b.KuikaeTiles, tile)
+		}
+	}
+}
+
+func (b *BoardState) handleEventKuikae(event Event) {
+	who := event.(*EventKuikae).Who
+	for wind, playerState := range b.PlayerStates {
+		if wind != who {
+			playerState.Points += event.(*EventKuikae).Points
+			b.PlayerStates[who].Points -= event.(*EventKuikae).Points
+		}
+	}
+}
+
 func (b *BoardState) handleEventDaiMinKan(event Event) {
 	who := event.(*EventDaiMinKan).Who
 	call := event.(*EventDaiMinKan).Call
@@ -400,6 +493,7 @@ func (b *BoardState) handleEventDaiMinKan(event Event) {
 		for i := 0; i < 3; i++ {
 			b.HandTiles.Remove(call.CallTiles[i])
 		}
+		b.DrawnTile = TileDummy
 	}
 	b.Position = who
 }
@@ -416,6 +510,7 @@ func (b *BoardState) handleEventShouMinKan(event Event) {
 	}
 	if who == b.PlayerWind {
 		b.HandTiles.Remove(call.CallTiles[3])
+		b.DrawnTile = TileDummy
 	}
 	b.Position = who
 }
@@ -428,6 +523,7 @@ func (b *BoardState) handleEventAnKan(event Event) {
 		for i := 0; i < 4; i++ {
 			b.HandTiles.Remove(call.CallTiles[i])
 		}
+		b.DrawnTile = TileDummy
 	}
 	b.Position = who
 }
@@ -435,11 +531,23 @@ func (b *BoardState) handleEventAnKan(event Event) {
 func (b *BoardState) handleEventRiichi(event Event) {
 	who := event.(*EventRiichi).Who
 	step := event.(*EventRiichi).Step
+	b.PlayerStates[who].IsRiichi = true
 	if step == 2 {
-		b.PlayerStates[who].IsRiichi = true
+		b.PlayerStates[who].Points -= b.riichiDeposit
+		b.NumRiichi++
 	}
 }
 
 func (b *BoardState) handleEventNewIndicator(event Event) {
 	b.DoraIndicators = append(b.DoraIndicators, event.(*EventNewIndicator).Tile)
 }
+
+func (b *BoardState) handleEventKita(event Event) {
+	who := event.(*EventKita).Who
+	if who == b.PlayerWind {
+		b.HandTiles.Remove(event.(*EventKita).Tile)
+		sort.Sort(&b.HandTiles)
+		b.DrawnTile = TileDummy
+	}
+	b.PlayerStates[who].KitaTiles.Append(event.(*EventKita).Tile)
+}
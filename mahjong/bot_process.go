@@ -0,0 +1,253 @@
+package mahjong
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// DefaultBotTimeout the time a subprocess bot is given for a response by default
+const DefaultBotTimeout = 5 * time.Second
+
+// botExitTimeout the time a subprocess bot is given to exit after its stdin is closed
+const botExitTimeout = 5 * time.Second
+
+var (
+	ErrBotTimeout = errors.New("bot did not respond in time")
+	ErrBotClosed  = errors.New("bot process is closed")
+)
+
+// ProcessBotOption option of NewProcessBot
+type ProcessBotOption func(bot *ProcessBot)
+
+// WithBotTimeout
+//
+//	@Description: wait for a response of the bot at most timeout, the bot is stopped after a timeout
+//	@param timeout: time for a response
+//	@return ProcessBotOption
+func WithBotTimeout(timeout time.Duration) ProcessBotOption {
+	return func(bot *ProcessBot) {
+		bot.timeout = timeout
+	}
+}
+
+// WithBotLogger
+//
+//	@Description: log the stderr of the bot and its failures by the logger instead of log.Default()
+//	@param logger: logger
+//	@return ProcessBotOption
+func WithBotLogger(logger *log.Logger) ProcessBotOption {
+	return func(bot *ProcessBot) {
+		bot.logger = logger
+	}
+}
+
+// WithBotNames
+//
+//	@Description: names of the players sent to the bot by start_game
+//	@param names: names by the index of the player
+//	@return ProcessBotOption
+func WithBotNames(names []string) ProcessBotOption {
+	return func(bot *ProcessBot) {
+		bot.adapter.Names = names
+	}
+}
+
+// ProcessBot an Agent run as a subprocess speaking mjai in json lines over stdin and stdout.
+// The events are sent as a line of a json array of mjai messages when the bot is to choose,
+// and the bot answers each line by a line of one mjai response. A bot failing to answer in time
+// or exiting is stopped, its calls are left to DefaultCall for the rest of the game.
+type ProcessBot struct {
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	lines   chan string // lines of stdout, closed when stdout is closed
+	stderr  sync.WaitGroup
+	adapter *MjaiAdapter
+	pending []MjaiMessage // messages not sent yet
+	timeout time.Duration
+	logger  *log.Logger
+	err     error // the failure stopping the bot, nil for running
+}
+
+// NewProcessBot
+//
+//	@Description: start a bot process for a player
+//	@param seat: index of the player in the game
+//	@param name: path of the executable
+//	@param args: arguments of the executable
+//	@param opts: options
+//	@return *ProcessBot
+//	@return error
+func NewProcessBot(seat int, name string, args []string, opts ...ProcessBotOption) (*ProcessBot, error) {
+	bot := &ProcessBot{
+		cmd:     exec.Command(name, args...),
+		lines:   make(chan string, 1),
+		adapter: NewMjaiAdapter(seat, nil),
+		timeout: DefaultBotTimeout,
+		logger:  log.Default(),
+	}
+	for _, opt := range opts {
+		opt(bot)
+	}
+	var err error
+	if bot.stdin, err = bot.cmd.StdinPipe(); err != nil {
+		return nil, err
+	}
+	stdout, err := bot.cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := bot.cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err = bot.cmd.Start(); err != nil {
+		return nil, err
+	}
+	go func() {
+		defer close(bot.lines)
+		scanner := bufio.NewScanner(stdout)
+		scanner.Buffer(nil, 1<<20)
+		for scanner.Scan() {
+			bot.lines <- scanner.Text()
+		}
+	}()
+	bot.stderr.Add(1)
+	go func() {
+		defer bot.stderr.Done()
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			bot.logger.Printf("bot %d: %s", seat, scanner.Text())
+		}
+	}()
+	bot.pending = append(bot.pending, bot.adapter.StartGame())
+	return bot, nil
+}
+
+// Observe
+//
+//	@Description: keep the messages of the events to send when the bot is to choose, the messages of the game
+//	end are sent at once without waiting for a response
+//	@receiver bot
+//	@param events: new events of the player
+//	@return error: nil, a stopped bot ignores the events
+func (bot *ProcessBot) Observe(events Events) error {
+	if bot.err != nil {
+		return nil
+	}
+	bot.pending = append(bot.pending, bot.adapter.Messages(events)...)
+	if len(bot.pending) > 0 && bot.pending[len(bot.pending)-1].Type() == "end_game" {
+		if err := bot.send(); err != nil {
+			bot.stop(err)
+		}
+	}
+	return nil
+}
+
+// Choose
+//
+//	@Description: send the messages kept and translate the response of the bot to a valid call
+//	@receiver bot
+//	@param ctx: the response is not waited for after ctx is done, the bot is stopped as it would answer late
+//	@param calls: valid calls of the player
+//	@param state: not used, the bot keeps its own board from the messages
+//	@return *Call
+//	@return error: the failure of the bot, the response not valid or the error of ctx
+func (bot *ProcessBot) Choose(ctx context.Context, calls Calls, _ *BoardState) (*Call, error) {
+	if bot.err != nil {
+		return nil, bot.err
+	}
+	for {
+		if err := bot.send(); err != nil {
+			bot.stop(err)
+			return nil, err
+		}
+		line, err := bot.receive(ctx)
+		if err != nil {
+			bot.stop(err)
+			return nil, err
+		}
+		call, reply, err := bot.adapter.ToCall([]byte(line), calls)
+		if err != nil {
+			bot.logger.Printf("bot %d: %v", bot.adapter.Seat, err)
+			return nil, err
+		}
+		if reply == nil {
+			return call, nil
+		}
+		bot.pending = append(bot.pending, reply)
+	}
+}
+
+// send writes the messages kept in a line
+func (bot *ProcessBot) send() error {
+	data, err := json.Marshal(bot.pending)
+	if err != nil {
+		return err
+	}
+	bot.pending = bot.pending[:0]
+	_, err = bot.stdin.Write(append(data, '\n'))
+	return err
+}
+
+// receive reads a line of the response in time, the error of ctx if ctx is done before
+func (bot *ProcessBot) receive(ctx context.Context) (string, error) {
+	timer := time.NewTimer(bot.timeout)
+	defer timer.Stop()
+	select {
+	case line, ok := <-bot.lines:
+		if !ok {
+			return "", fmt.Errorf("%w: stdout closed", ErrBotClosed)
+		}
+		return line, nil
+	case <-timer.C:
+		return "", ErrBotTimeout
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// stop kills the bot after a failure
+func (bot *ProcessBot) stop(err error) {
+	bot.logger.Printf("bot %d is stopped: %v", bot.adapter.Seat, err)
+	bot.err = err
+	_ = bot.cmd.Process.Kill()
+}
+
+// Close
+//
+//	@Description: close stdin of the bot and wait for it to exit, the bot is killed if it does not exit in 5 seconds
+//	@receiver bot
+//	@return error: error of the exit, nil for a bot stopped by failure
+func (bot *ProcessBot) Close() error {
+	_ = bot.stdin.Close()
+	done := make(chan error, 1)
+	go func() {
+		// drain stdout so that the bot is not blocked on writing
+		for range bot.lines {
+		}
+		bot.stderr.Wait()
+		done <- bot.cmd.Wait()
+	}()
+	timer := time.NewTimer(botExitTimeout)
+	defer timer.Stop()
+	var err error
+	select {
+	case err = <-done:
+	case <-timer.C:
+		_ = bot.cmd.Process.Kill()
+		err = <-done
+	}
+	if bot.err != nil {
+		return nil
+	}
+	bot.err = ErrBotClosed
+	return err
+}
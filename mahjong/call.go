@@ -1,6 +1,9 @@
 package mahjong
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"github.com/hphphp123321/go-common"
+)
 
 type Calls []*Call
 
@@ -116,6 +119,20 @@ func (calls *Calls) Index(call *Call) int {
 	return -1
 }
 
+// indexExact returns the index of the call with the same type, tiles and from who, -1 for not found
+func (calls *Calls) indexExact(call *Call) int {
+	if call == nil {
+		return -1
+	}
+	for idx, c := range *calls {
+		if c.CallType == call.CallType && common.SliceEqual(c.CallTiles, call.CallTiles) &&
+			common.SliceEqual(c.CallTilesFromWho, call.CallTilesFromWho) {
+			return idx
+		}
+	}
+	return -1
+}
+
 func (calls *Calls) Copy() Calls {
 	callsCopy := make(Calls, len(*calls), cap(*calls))
 	copy(callsCopy, *calls)
@@ -161,3 +178,4 @@ func (calls *Calls) Contains(call *Call) bool {
 
 var SkipCall = NewCall(Skip, nil, nil)
 var NextCall = NewCall(Next, nil, nil)
+var AgariYameCall = NewCall(AgariYame, nil, nil)
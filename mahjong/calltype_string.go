@@ -22,11 +22,13 @@ func _() {
 	_ = x[KyuuShuKyuuHai-10]
 	_ = x[ChanKan-11]
 	_ = x[Next-12]
+	_ = x[Kita-13]
+	_ = x[AgariYame-14]
 }
 
-const _CallType_name = "GetSkipDiscardChiPonDaiMinKanShouMinKanAnKanRiichiRonTsumoKyuuShuKyuuHaiChanKanNext"
+const _CallType_name = "GetSkipDiscardChiPonDaiMinKanShouMinKanAnKanRiichiRonTsumoKyuuShuKyuuHaiChanKanNextKitaAgariYame"
 
-var _CallType_index = [...]uint8{0, 3, 7, 14, 17, 20, 29, 39, 44, 50, 53, 58, 72, 79, 83}
+var _CallType_index = [...]uint8{0, 3, 7, 14, 17, 20, 29, 39, 44, 50, 53, 58, 72, 79, 83, 87, 96}
 
 func (i CallType) String() string {
 	i -= -1
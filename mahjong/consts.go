@@ -70,11 +70,13 @@ const (
 	KyuuShuKyuuHai
 	ChanKan
 	Next
+	Kita
+	AgariYame
 )
 
 var MapStringToCallType = func() map[string]CallType {
 	m := make(map[string]CallType)
-	for i := Get; i <= Next; i++ {
+	for i := Get; i <= AgariYame; i++ {
 		m[i.String()] = i
 	}
 	return m
@@ -104,11 +106,16 @@ const (
 	EventTypeNagashiMangan
 	EventTypeTenpaiEnd
 	EventTypeGlobalInit
+	EventTypeKita
+	EventTypeAgariYame
+	EventTypeGameEnd
+	EventTypeKuikae
+	EventTypeChombo
 )
 
 var MapStringToEventType = func() map[string]EventType {
 	m := make(map[string]EventType)
-	for i := EventTypeGet; i <= EventTypeGlobalInit; i++ {
+	for i := EventTypeGet; i <= EventTypeChombo; i++ {
 		m[i.String()] = i
 	}
 	return m
@@ -362,6 +369,9 @@ var MapStringToTileClass = func() map[string]TileClass {
 
 var YaoKyuTileClasses = TileClasses{0, 8, 9, 17, 18, 26, 27, 28, 29, 30, 31, 32, 33}
 
+// SanmaRemovedTileClasses are the tile classes taken out of the wall in three-player mahjong
+var SanmaRemovedTileClasses = TileClasses{Man2, Man3, Man4, Man5, Man6, Man7, Man8}
+
 var TileClassMap = map[Tile]TileClass{0: 0, 1: 0, 2: 0, 3: 0, 4: 1, 5: 1, 6: 1, 7: 1, 8: 2, 9: 2, 10: 2, 11: 2, 12: 3,
 	13: 3, 14: 3, 15: 3, 16: 34, 17: 4, 18: 4, 19: 4, 20: 5, 21: 5, 22: 5, 23: 5, 24: 6, 25: 6, 26: 6, 27: 6, 28: 7,
 	29: 7, 30: 7, 31: 7, 32: 8, 33: 8, 34: 8, 35: 8, 36: 9, 37: 9, 38: 9, 39: 9, 40: 10, 41: 10, 42: 10, 43: 10,
@@ -383,6 +393,14 @@ var TileClassUTF = map[TileClass]string{
 
 var ErrGameEnd = errors.New("game is end")
 
+var (
+	ErrIllegalCall     = errors.New("call is not one of the valid calls")
+	ErrUnexpectedSeat  = errors.New("seat is not expected to respond")
+	ErrMissingResponse = errors.New("seat did not respond")
+	ErrNoHistory       = errors.New("no step in the history")
+	ErrInvalidRule     = errors.New("rule is not valid")
+)
+
 // Limit numbers are now fixed and should not be changed
 type Limit int
 
@@ -411,3 +429,147 @@ const (
 	EndTypeRound
 	EndTypeGame
 )
+
+// ExtensionPolicy decides what happens when nobody reaches the target points after the all last round
+type ExtensionPolicy int
+
+//go:generate stringer -type=ExtensionPolicy -trimprefix Extension
+const (
+	ExtensionNone        ExtensionPolicy = iota // game ends after the all last round
+	ExtensionSuddenDeath                        // play the next wind(west round for hanchan), end as soon as someone reaches the target points
+	ExtensionFixedRounds                        // play a fixed number of extra rounds, the target points are not checked during them
+)
+
+var MapStringToExtensionPolicy = func() map[string]ExtensionPolicy {
+	m := make(map[string]ExtensionPolicy)
+	for i := ExtensionNone; i <= ExtensionFixedRounds; i++ {
+		m[i.String()] = i
+	}
+	return m
+}()
+
+// AgariYamePolicy decides whether the dealer can stop the game after winning in the final round
+type AgariYamePolicy int
+
+//go:generate stringer -type=AgariYamePolicy -trimprefix AgariYame
+const (
+	AgariYameNone     AgariYamePolicy = iota // no agari yame, the dealer always continues
+	AgariYameOptional                        // the dealer chooses between AgariYame and Next
+	AgariYameAuto                            // the game ends automatically
+)
+
+var MapStringToAgariYamePolicy = func() map[string]AgariYamePolicy {
+	m := make(map[string]AgariYamePolicy)
+	for i := AgariYameNone; i <= AgariYameAuto; i++ {
+		m[i.String()] = i
+	}
+	return m
+}()
+
+// TieBreakPolicy decides the ranks of the players with the same final points
+type TieBreakPolicy int
+
+//go:generate stringer -type=TieBreakPolicy -trimprefix TieBreak
+const (
+	TieBreakSeat  TieBreakPolicy = iota // the player in the earlier starting seat ranks higher
+	TieBreakSplit                       // the tied players share the same rank and split the uma and oka of the ranks evenly
+)
+
+var MapStringToTieBreakPolicy = func() map[string]TieBreakPolicy {
+	m := make(map[string]TieBreakPolicy)
+	for i := TieBreakSeat; i <= TieBreakSplit; i++ {
+		m[i.String()] = i
+	}
+	return m
+}()
+
+// KyoutakuPolicy decides who receives the riichi sticks left on the table when the game ends
+type KyoutakuPolicy int
+
+//go:generate stringer -type=KyoutakuPolicy -trimprefix Kyoutaku
+const (
+	KyoutakuTop  KyoutakuPolicy = iota // the top player receives the sticks, the tied tops split them under TieBreakSplit
+	KyoutakuLost                       // the sticks are lost, nobody receives them
+)
+
+var MapStringToKyoutakuPolicy = func() map[string]KyoutakuPolicy {
+	m := make(map[string]KyoutakuPolicy)
+	for i := KyoutakuTop; i <= KyoutakuLost; i++ {
+		m[i.String()] = i
+	}
+	return m
+}()
+
+// RoundingPolicy decides how the final points are rounded to thousands before uma and oka are added
+type RoundingPolicy int
+
+//go:generate stringer -type=RoundingPolicy -trimprefix Rounding
+const (
+	RoundingNone          RoundingPolicy = iota // no rounding, scores keep the hundreds as decimals
+	RoundingHalfUp                              // round 500 up and 400 down
+	RoundingFiveDownSixUp                       // round 600 up and 500 down
+	RoundingFloor                               // round down towards negative infinity
+	RoundingCeil                                // round up towards positive infinity
+)
+
+var MapStringToRoundingPolicy = func() map[string]RoundingPolicy {
+	m := make(map[string]RoundingPolicy)
+	for i := RoundingNone; i <= RoundingCeil; i++ {
+		m[i.String()] = i
+	}
+	return m
+}()
+
+// TripleRonPolicy decides what happens when three players ron the same tile
+type TripleRonPolicy int
+
+//go:generate stringer -type=TripleRonPolicy -trimprefix TripleRon
+const (
+	TripleRonAbort    TripleRonPolicy = iota // abortive draw(san cha hou), the dealer keeps
+	TripleRonAllowed                         // all three players win
+	TripleRonHeadBump                        // head bump(atamahane), only the player nearest to the discarder wins
+)
+
+var MapStringToTripleRonPolicy = func() map[string]TripleRonPolicy {
+	m := make(map[string]TripleRonPolicy)
+	for i := TripleRonAbort; i <= TripleRonHeadBump; i++ {
+		m[i.String()] = i
+	}
+	return m
+}()
+
+// KuikaePolicy decides which tiles can be discarded right after a chi or pon(swap calling)
+type KuikaePolicy int
+
+//go:generate stringer -type=KuikaePolicy -trimprefix Kuikae
+const (
+	KuikaeForbidAll      KuikaePolicy = iota // the called tile(genbutsu) and the tile on the other side of the chi(suji) can't be discarded
+	KuikaeForbidGenbutsu                     // only the called tile(genbutsu) can't be discarded
+	KuikaeAllowed                            // all tiles can be discarded
+	KuikaePenalty                            // all tiles can be discarded, the swap caller pays Rule.KuikaePenalty to each other player
+)
+
+var MapStringToKuikaePolicy = func() map[string]KuikaePolicy {
+	m := make(map[string]KuikaePolicy)
+	for i := KuikaeForbidAll; i <= KuikaePenalty; i++ {
+		m[i.String()] = i
+	}
+	return m
+}()
+
+// ChomboPolicy decides the penalty of a chombo(rule violation)
+type ChomboPolicy int
+
+//go:generate stringer -type=ChomboPolicy -trimprefix Chombo
+const (
+	ChomboMangan ChomboPolicy = iota // the offender pays a mangan tsumo to the other players, without honba
+	ChomboFixed                      // the offender loses Rule.ChomboPoints
+)
+
+var MapStringToChomboPolicy = func() map[string]ChomboPolicy {
+	m := make(map[string]ChomboPolicy)
+	for i := ChomboMangan; i <= ChomboFixed; i++ {
+		m[i.String()] = i
+	}
+	return m
+}()
@@ -0,0 +1,548 @@
+package mahjong
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hphphp123321/go-common"
+	"math/rand"
+	"sort"
+)
+
+// DeterminizeOptions options of Determinize
+type DeterminizeOptions struct {
+	IsRiichiWaitSafe bool // the waits of a riichi player never include the tiles they discarded
+	MaxTries         int  // number of walls sampled before giving up, 0 for 1000
+}
+
+// useMode constrains the slot a used tile is drawn from
+type useMode int
+
+const (
+	useFromHand     useMode = iota // any tile in hand
+	useNotLastDrawn                // a tile in hand except the one just drawn (tedashi after a draw)
+	useLastDrawn                   // the tile just drawn (tsumogiri after a draw)
+	useLastInHand                  // the largest tile in hand (tsumogiri right after a call)
+)
+
+// wallOp is one step of an opponent in the sampled round: a draw into a wall slot, a use of a known tile or a riichi declaration
+type wallOp struct {
+	slot      int // wall position of the draw, -1 for not a draw
+	tile      Tile
+	mode      useMode
+	isDiscard bool
+	riichi    bool
+}
+
+// wallPlan is everything a seat knows about the wall of the round
+type wallPlan struct {
+	seat     Wind
+	numTiles int
+	fixed    map[int]Tile      // wall positions whose tiles are seen by the seat
+	ops      map[Wind][]wallOp // steps of each opponent in order
+	melds    map[Wind]Calls    // closed kans of each opponent declared before the riichi
+	unseen   Tiles             // tiles the seat hasn't seen
+}
+
+// Determinize
+//
+//	@Description: sample a complete game consistent with what one seat has seen in the current round,
+//	the hidden hands, the live wall and the dead wall are drawn at random from the unseen tiles
+//	@param events: events of the seat, from GetPosEvents, the round starts from the last EventStart
+//	@param r: random source of the sample
+//	@param options: sampling options, nil for default
+//	@return *Game: game replayed to the same point with the sampled wall
+//	@return map[Wind]Calls: valid calls of the replayed game
+//	@return error
+func Determinize(events Events, r *rand.Rand, options *DeterminizeOptions) (*Game, map[Wind]Calls, error) {
+	if options == nil {
+		options = &DeterminizeOptions{}
+	}
+	maxTries := options.MaxTries
+	if maxTries == 0 {
+		maxTries = 1000
+	}
+	start := -1
+	for i, event := range events {
+		if event.GetType() == EventTypeStart {
+			start = i
+		}
+	}
+	if start == -1 {
+		return nil, nil, errors.New("no EventStart in events")
+	}
+	events = events[start:]
+	plan, err := newWallPlan(events)
+	if err != nil {
+		return nil, nil, err
+	}
+	observed := NewBoardState()
+	observed.DecodeEvents(events)
+
+	for i := 0; i < maxTries; i++ {
+		wall, ok := plan.sample(r, options)
+		if !ok {
+			continue
+		}
+		game, ok := replayWall(events, wall, r.Int63())
+		if !ok {
+			continue
+		}
+		// the tsumogiri flags and the calls offered to the others depend on the hidden tiles, check the replay is what the seat saw
+		replayed := NewBoardState()
+		replayed.DecodeEvents(game.GetPosEvents(plan.seat, 0))
+		if !replayed.Equal(observed) {
+			continue
+		}
+		return game, game.posCalls, nil
+	}
+	return nil, nil, fmt.Errorf("no wall consistent with the events in %d tries", maxTries)
+}
+
+// newWallPlan walks the events of one round and finds the wall positions of every draw,
+// the wall pointers are simulated by dealing a wall whose position i holds the i-th tile
+func newWallPlan(events Events) (*wallPlan, error) {
+	start := events[0].(*EventStart)
+	probe := newMahjongTiles(nil, start.Rule.IsSanma)
+	probe.Reset()
+	probe.Setup(probe.wallTiles())
+	var positions = make(map[Tile]int, len(probe.tiles))
+	for i, tile := range probe.tiles {
+		positions[tile] = i
+	}
+
+	plan := &wallPlan{
+		seat:     start.InitWind,
+		numTiles: len(probe.tiles),
+		fixed:    make(map[int]Tile),
+		ops:      make(map[Wind][]wallOp),
+		melds:    make(map[Wind]Calls),
+	}
+	seen := make(map[Tile]bool)
+	see := func(tile Tile) error {
+		if seen[tile] {
+			return fmt.Errorf("tile %s is seen twice", tile)
+		}
+		seen[tile] = true
+		return nil
+	}
+	numPlayers := start.Rule.NumPlayers()
+	isLastDrawn := make(map[Wind]bool)
+	isRiichi := make(map[Wind]bool)
+	dealRinshan := make(map[Wind]bool)
+	dealKita := make(map[Wind]bool)
+	var numIndicators = 0
+	use := func(who Wind, tile Tile, mode useMode) error {
+		if who == plan.seat {
+			return nil
+		}
+		plan.ops[who] = append(plan.ops[who], wallOp{slot: -1, tile: tile, mode: mode})
+		return see(tile)
+	}
+	discard := func(who Wind, tile Tile, mode useMode) error {
+		if err := use(who, tile, mode); err != nil || who == plan.seat {
+			return err
+		}
+		plan.ops[who][len(plan.ops[who])-1].isDiscard = true
+		return nil
+	}
+
+	for wind := Wind(0); int(wind) < numPlayers; wind++ {
+		for i := 0; i < 13; i++ {
+			slot := 13*int(wind) + i
+			if wind == plan.seat {
+				plan.fixed[slot] = start.InitTiles[i]
+				if err := see(start.InitTiles[i]); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			plan.ops[wind] = append(plan.ops[wind], wallOp{slot: slot})
+		}
+	}
+	plan.fixed[plan.numTiles-6] = start.InitDoraIndicator
+	if err := see(start.InitDoraIndicator); err != nil {
+		return nil, err
+	}
+
+	var err error
+	for _, event := range events[1:] {
+		switch e := event.(type) {
+		case *EventGet:
+			var tile Tile
+			switch {
+			case dealRinshan[e.Who]:
+				tile = probe.DealTile(true)
+			case dealKita[e.Who]:
+				tile = probe.DealKitaTile()
+			default:
+				tile = probe.DealTile(false)
+			}
+			dealRinshan[e.Who] = false
+			dealKita[e.Who] = false
+			isLastDrawn[e.Who] = true
+			if e.Who == plan.seat {
+				plan.fixed[positions[tile]] = e.Tile
+				err = see(e.Tile)
+			} else {
+				plan.ops[e.Who] = append(plan.ops[e.Who], wallOp{slot: positions[tile]})
+			}
+		case *EventDiscard:
+			mode := useFromHand
+			if isLastDrawn[e.Who] {
+				mode = useNotLastDrawn
+			}
+			err = discard(e.Who, e.Tile, mode)
+			isLastDrawn[e.Who] = false
+		case *EventTsumoGiri:
+			mode := useLastInHand
+			if isLastDrawn[e.Who] {
+				mode = useLastDrawn
+			}
+			err = discard(e.Who, e.Tile, mode)
+			isLastDrawn[e.Who] = false
+		case *EventChi:
+			for _, tile := range e.Call.CallTiles[:2] {
+				if err = use(e.Who, tile, useFromHand); err != nil {
+					break
+				}
+			}
+			isLastDrawn[e.Who] = false
+		case *EventPon:
+			for _, tile := range e.Call.CallTiles[:2] {
+				if err = use(e.Who, tile, useFromHand); err != nil {
+					break
+				}
+			}
+			isLastDrawn[e.Who] = false
+		case *EventDaiMinKan:
+			for _, tile := range e.Call.CallTiles[:3] {
+				if err = use(e.Who, tile, useFromHand); err != nil {
+					break
+				}
+			}
+			dealRinshan[e.Who] = true
+		case *EventAnKan:
+			for _, tile := range e.Call.CallTiles[:4] {
+				if err = use(e.Who, tile, useFromHand); err != nil {
+					break
+				}
+			}
+			if !isRiichi[e.Who] {
+				plan.melds[e.Who] = append(plan.melds[e.Who], e.Call)
+			}
+			dealRinshan[e.Who] = true
+		case *EventShouMinKan:
+			err = use(e.Who, e.Call.CallTiles[3], useFromHand)
+			dealRinshan[e.Who] = true
+		case *EventKita:
+			err = use(e.Who, e.Tile, useFromHand)
+			dealKita[e.Who] = true
+		case *EventRiichi:
+			if e.Step == 1 && e.Who != plan.seat {
+				isRiichi[e.Who] = true
+				plan.ops[e.Who] = append(plan.ops[e.Who], wallOp{slot: -1, riichi: true})
+			}
+		case *EventNewIndicator:
+			numIndicators++
+			plan.fixed[plan.numTiles-6-2*numIndicators] = e.Tile
+			err = see(e.Tile)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, tile := range probe.wallTiles() {
+		if !seen[tile] {
+			plan.unseen = append(plan.unseen, tile)
+		}
+	}
+	return plan, nil
+}
+
+// sample draws a wall consistent with the plan, false if the random choices run into a dead end
+func (plan *wallPlan) sample(r *rand.Rand, options *DeterminizeOptions) (Tiles, bool) {
+	wall := make(Tiles, plan.numTiles)
+	for i := range wall {
+		wall[i] = TileDummy
+	}
+	for slot, tile := range plan.fixed {
+		wall[slot] = tile
+	}
+	pool := plan.unseen.Copy()
+	r.Shuffle(len(pool), func(i, j int) { pool[i], pool[j] = pool[j], pool[i] })
+	take := func(accept func(Tile) bool) (Tile, bool) {
+		for i, tile := range pool {
+			if accept(tile) {
+				pool = append(pool[:i], pool[i+1:]...)
+				return tile, true
+			}
+		}
+		return TileDummy, false
+	}
+
+	var bounds = make(map[int]Tile) // the tile of the slot must be less than the bound
+	var riichiHands = make(map[Wind][]int)
+	winds := make([]Wind, 0, len(plan.ops))
+	for wind := range plan.ops {
+		winds = append(winds, wind)
+	}
+	sort.Slice(winds, func(i, j int) bool { return winds[i] < winds[j] })
+
+	// place the tiles the opponents used in the slots they drew before
+	for _, wind := range winds {
+		var hand []int
+		var lastSlot = -1
+		var isRiichi = false
+		for _, op := range plan.ops[wind] {
+			if op.riichi {
+				isRiichi = true
+				continue
+			}
+			if op.slot != -1 {
+				hand = append(hand, op.slot)
+				lastSlot = op.slot
+				continue
+			}
+			var candidates []int
+			for i, slot := range hand {
+				if bound, ok := bounds[slot]; ok && op.tile >= bound {
+					continue
+				}
+				switch op.mode {
+				case useNotLastDrawn:
+					if slot == lastSlot {
+						continue
+					}
+				case useLastDrawn:
+					if slot != lastSlot {
+						continue
+					}
+				}
+				candidates = append(candidates, i)
+			}
+			if len(candidates) == 0 {
+				return nil, false
+			}
+			i := candidates[r.Intn(len(candidates))]
+			wall[hand[i]] = op.tile
+			hand = append(hand[:i], hand[i+1:]...)
+			if op.mode == useLastInHand {
+				for _, slot := range hand {
+					if bound, ok := bounds[slot]; !ok || op.tile < bound {
+						bounds[slot] = op.tile
+					}
+				}
+			}
+			if isRiichi {
+				// the hand right after the riichi discard
+				riichiHands[wind] = append([]int(nil), hand...)
+				isRiichi = false
+			}
+		}
+	}
+
+	// riichi hands are tenpai
+	for _, wind := range winds {
+		hand, ok := riichiHands[wind]
+		if !ok {
+			continue
+		}
+		var known Tiles
+		var unknown []int
+		for _, slot := range hand {
+			if wall[slot] == TileDummy {
+				unknown = append(unknown, slot)
+			} else {
+				known = append(known, wall[slot])
+			}
+		}
+		handTiles := known.Copy()
+		if len(unknown)%3 == 1 {
+			var counts [34]int
+			for _, tile := range pool {
+				counts[tile.Class()]++
+			}
+			classes := sampleTenpaiClasses(counts, len(unknown), r)
+			if classes == nil {
+				return nil, false
+			}
+			for i, class := range classes {
+				tile, _ := take(func(t Tile) bool { return t.Class() == class })
+				wall[unknown[i]] = tile
+				handTiles = append(handTiles, tile)
+			}
+		} else {
+			// the known tiles are not groups, leave the check to the waits
+			for _, slot := range unknown {
+				wall[slot] = pool[0]
+				handTiles = append(handTiles, pool[0])
+				pool = pool[1:]
+			}
+		}
+		waits := GetTenpaiSlice(handTiles, append(Calls{}, plan.melds[wind]...))
+		if len(waits) == 0 {
+			return nil, false
+		}
+		if options.IsRiichiWaitSafe {
+			for _, op := range plan.ops[wind] {
+				if op.isDiscard && common.SliceContain(waits, op.tile.Class()) {
+					return nil, false
+				}
+			}
+		}
+	}
+
+	// the tightest bounds first
+	var boundSlots = make([]int, 0, len(bounds))
+	for slot := range bounds {
+		if wall[slot] == TileDummy {
+			boundSlots = append(boundSlots, slot)
+		}
+	}
+	sort.Slice(boundSlots, func(i, j int) bool { return bounds[boundSlots[i]] < bounds[boundSlots[j]] })
+	for _, slot := range boundSlots {
+		tile, ok := take(func(t Tile) bool { return t < bounds[slot] })
+		if !ok {
+			return nil, false
+		}
+		wall[slot] = tile
+	}
+
+	for i := range wall {
+		if wall[i] == TileDummy {
+			wall[i] = pool[0]
+			pool = pool[1:]
+		}
+	}
+	return wall, true
+}
+
+// sampleTenpaiClasses draws the tile classes of a random tenpai hand of size tiles from the counts of each class, nil for failed
+func sampleTenpaiClasses(counts [34]int, size int, r *rand.Rand) TileClasses {
+	var classes TileClasses
+	takeClasses := func(cs ...TileClass) bool {
+		var need [34]int
+		for _, c := range cs {
+			if c < 0 || int(c) >= len(counts) {
+				return false
+			}
+			need[c]++
+			if need[c] > counts[c] {
+				return false
+			}
+		}
+		for _, c := range cs {
+			counts[c]--
+		}
+		classes = append(classes, cs...)
+		return true
+	}
+	randomSuited := func() TileClass {
+		return TileClass(9*r.Intn(3) + r.Intn(9))
+	}
+	tryTake := func(gen func() []TileClass) bool {
+		for i := 0; i < 100; i++ {
+			if takeClasses(gen()...) {
+				return true
+			}
+		}
+		return false
+	}
+	group := func() []TileClass {
+		if r.Intn(3) == 0 {
+			c := TileClass(r.Intn(34))
+			return []TileClass{c, c, c}
+		}
+		c := TileClass(9*r.Intn(3) + r.Intn(7))
+		return []TileClass{c, c + 1, c + 2}
+	}
+
+	// seven pairs waiting on the single tile
+	if size == 13 && r.Intn(10) == 0 {
+		used := map[TileClass]bool{}
+		for i := 0; len(classes) < 13; i++ {
+			if i == 1000 {
+				return nil
+			}
+			c := TileClass(r.Intn(34))
+			if used[c] {
+				continue
+			}
+			if len(classes) == 12 && takeClasses(c) || len(classes) < 12 && takeClasses(c, c) {
+				used[c] = true
+			}
+		}
+		return classes
+	}
+
+	numGroups := size / 3
+	isTanki := numGroups == 0 || r.Intn(3) == 0
+	if !isTanki {
+		numGroups--
+	}
+	for i := 0; i < numGroups; i++ {
+		if !tryTake(group) {
+			return nil
+		}
+	}
+	if isTanki {
+		if !tryTake(func() []TileClass { return []TileClass{TileClass(r.Intn(34))} }) {
+			return nil
+		}
+		return classes
+	}
+	if !tryTake(func() []TileClass {
+		c := TileClass(r.Intn(34))
+		return []TileClass{c, c}
+	}) {
+		return nil
+	}
+	if !tryTake(func() []TileClass {
+		if r.Intn(4) == 0 {
+			// shanpon
+			c := TileClass(r.Intn(34))
+			return []TileClass{c, c}
+		}
+		c := randomSuited()
+		d := c + TileClass(1+r.Intn(2))
+		if (d-c == 1 && c%9 == 8) || (d-c == 2 && c%9 >= 7) {
+			return []TileClass{TileClassDummy}
+		}
+		return []TileClass{c, d}
+	}) {
+		return nil
+	}
+	return classes
+}
+
+// replayWall replays the events of the seat with the wall, false if the events can't be followed
+func replayWall(events Events, wall Tiles, seed int64) (*Game, bool) {
+	start := events[0].(*EventStart)
+	initPoints := make(map[Wind]int, len(start.PlayersPoints))
+	for wind, points := range start.PlayersPoints {
+		initPoints[wind] = points
+	}
+	globalEvents := Events{&EventGlobalInit{
+		AllTiles:   wall,
+		WindRound:  start.WindRound,
+		Seed:       seed,
+		NumGame:    start.NumGame,
+		NumHonba:   start.NumHonba,
+		NumRiichi:  start.NumRiichi,
+		Rule:       start.Rule,
+		InitPoints: initPoints,
+	}}
+	for _, event := range events[1:] {
+		// furiten events are sent to one seat only, the global events don't have them
+		if event.GetType() != EventTypeFuriten {
+			globalEvents = append(globalEvents, event)
+		}
+	}
+	players := make([]*Player, start.Rule.NumPlayers())
+	for i := range players {
+		players[i] = NewMahjongPlayer()
+	}
+	game, err := reConstructGame(players, globalEvents)
+	return game, err == nil
+}
@@ -36,6 +36,11 @@ var eventTypes = map[string]reflect.Type{
 	EventTypeNagashiMangan.String(): reflect.TypeOf(EventNagashiMangan{}),
 	EventTypeTenpaiEnd.String():     reflect.TypeOf(EventTenpaiEnd{}),
 	EventTypeGlobalInit.String():    reflect.TypeOf(EventGlobalInit{}),
+	EventTypeKita.String():          reflect.TypeOf(EventKita{}),
+	EventTypeAgariYame.String():     reflect.TypeOf(EventAgariYame{}),
+	EventTypeGameEnd.String():       reflect.TypeOf(EventGameEnd{}),
+	EventTypeKuikae.String():        reflect.TypeOf(EventKuikae{}),
+	EventTypeChombo.String():        reflect.TypeOf(EventChombo{}),
 	// ... 添加其他事件类型
 }
 
@@ -410,6 +415,7 @@ func (event *EventRiichi) UnmarshalJSON(data []byte) error {
 type EventRon struct {
 	Who       Wind    `json:"who"`
 	FromWho   Wind    `json:"from_who"`
+	PaoWho    Wind    `json:"pao_who"` // player liable for the win, WindDummy for none
 	HandTiles Tiles   `json:"hand_tiles"`
 	WinTile   Tile    `json:"win_tile"`
 	Result    *Result `json:"result"`
@@ -423,12 +429,14 @@ func (event *EventRon) MarshalJSON() ([]byte, error) {
 	return json.Marshal(&struct {
 		Who       string  `json:"who"`
 		FromWho   string  `json:"from_who"`
+		PaoWho    string  `json:"pao_who"`
 		HandTiles Tiles   `json:"hand_tiles"`
 		WinTile   string  `json:"win_tile"`
 		Result    *Result `json:"result"`
 	}{
 		Who:       event.Who.String(),
 		FromWho:   event.FromWho.String(),
+		PaoWho:    event.PaoWho.String(),
 		HandTiles: event.HandTiles,
 		WinTile:   event.WinTile.String(),
 		Result:    event.Result,
@@ -439,15 +447,18 @@ func (event *EventRon) UnmarshalJSON(data []byte) error {
 	var tmp struct {
 		Who       string `json:"who"`
 		FromWho   string `json:"from_who"`
+		PaoWho    string `json:"pao_who"`
 		HandTiles Tiles  `json:"hand_tiles"`
 		WinTile   string `json:"win_tile"`
 		Result    *Result
 	}
+	tmp.PaoWho = WindDummy.String()
 	if err := json.Unmarshal(data, &tmp); err != nil {
 		return err
 	}
 	event.Who = MapStringToWind[tmp.Who]
 	event.FromWho = MapStringToWind[tmp.FromWho]
+	event.PaoWho = MapStringToWind[tmp.PaoWho]
 	event.HandTiles = tmp.HandTiles
 	event.WinTile = MapStringToTile[tmp.WinTile]
 	event.Result = tmp.Result
@@ -456,6 +467,7 @@ func (event *EventRon) UnmarshalJSON(data []byte) error {
 
 type EventTsumo struct {
 	Who       Wind    `json:"who"`
+	PaoWho    Wind    `json:"pao_who"` // player liable for the win, WindDummy for none
 	HandTiles Tiles   `json:"hand_tiles"`
 	WinTile   Tile    `json:"win_tile"`
 	Result    *Result `json:"result"`
@@ -468,11 +480,13 @@ func (event *EventTsumo) GetType() EventType {
 func (event *EventTsumo) MarshalJSON() ([]byte, error) {
 	return json.Marshal(&struct {
 		Who       string `json:"who"`
+		PaoWho    string `json:"pao_who"`
 		HandTiles Tiles  `json:"hand_tiles"`
 		WinTile   string `json:"win_tile"`
 		Result    *Result
 	}{
 		Who:       event.Who.String(),
+		PaoWho:    event.PaoWho.String(),
 		HandTiles: event.HandTiles,
 		WinTile:   event.WinTile.String(),
 		Result:    event.Result,
@@ -482,14 +496,17 @@ func (event *EventTsumo) MarshalJSON() ([]byte, error) {
 func (event *EventTsumo) UnmarshalJSON(data []byte) error {
 	var tmp struct {
 		Who       string  `json:"who"`
+		PaoWho    string  `json:"pao_who"`
 		HandTiles Tiles   `json:"hand_tiles"`
 		WinTile   string  `json:"win_tile"`
 		Result    *Result `json:"result"`
 	}
+	tmp.PaoWho = WindDummy.String()
 	if err := json.Unmarshal(data, &tmp); err != nil {
 		return err
 	}
 	event.Who = MapStringToWind[tmp.Who]
+	event.PaoWho = MapStringToWind[tmp.PaoWho]
 	event.HandTiles = tmp.HandTiles
 	event.WinTile = MapStringToTile[tmp.WinTile]
 	event.Result = tmp.Result
@@ -526,6 +543,7 @@ func (event *EventNewIndicator) UnmarshalJSON(data []byte) error {
 type EventChanKan struct {
 	Who       Wind    `json:"who"`
 	FromWho   Wind    `json:"from_who"`
+	PaoWho    Wind    `json:"pao_who"` // player liable for the win, WindDummy for none
 	HandTiles Tiles   `json:"hand_tiles"`
 	WinTile   Tile    `json:"win_tile"`
 	Result    *Result `json:"result"`
@@ -539,12 +557,14 @@ func (event *EventChanKan) MarshalJSON() ([]byte, error) {
 	return json.Marshal(&struct {
 		Who       string  `json:"who"`
 		FromWho   string  `json:"from_who"`
+		PaoWho    string  `json:"pao_who"`
 		HandTiles Tiles   `json:"hand_tiles"`
 		WinTile   string  `json:"win_tile"`
 		Result    *Result `json:"result"`
 	}{
 		Who:       event.Who.String(),
 		FromWho:   event.FromWho.String(),
+		PaoWho:    event.PaoWho.String(),
 		HandTiles: event.HandTiles,
 		WinTile:   event.WinTile.String(),
 		Result:    event.Result,
@@ -555,15 +575,18 @@ func (event *EventChanKan) UnmarshalJSON(data []byte) error {
 	var tmp struct {
 		Who       string  `json:"who"`
 		FromWho   string  `json:"from_who"`
+		PaoWho    string  `json:"pao_who"`
 		HandTiles Tiles   `json:"hand_tiles"`
 		WinTile   string  `json:"win_tile"`
 		Result    *Result `json:"result"`
 	}
+	tmp.PaoWho = WindDummy.String()
 	if err := json.Unmarshal(data, &tmp); err != nil {
 		return err
 	}
 	event.Who = MapStringToWind[tmp.Who]
 	event.FromWho = MapStringToWind[tmp.FromWho]
+	event.PaoWho = MapStringToWind[tmp.PaoWho]
 	event.WinTile = MapStringToTile[tmp.WinTile]
 	event.HandTiles = tmp.HandTiles
 	event.Result = tmp.Result
@@ -901,3 +924,181 @@ func (event *EventTenpaiEnd) UnmarshalJSON(data []byte) error {
 	event.TenpaiSlice = tmp.TenpaiSlice
 	return nil
 }
+
+type EventKita struct {
+	Who  Wind `json:"who"`
+	Tile Tile `json:"tile"`
+}
+
+func (event *EventKita) GetType() EventType {
+	return EventTypeKita
+}
+
+func (event *EventKita) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		Who  string `json:"who"`
+		Tile string `json:"tile"`
+	}{
+		Who:  event.Who.String(),
+		Tile: event.Tile.String(),
+	})
+}
+
+func (event *EventKita) UnmarshalJSON(data []byte) error {
+	var tmp struct {
+		Who  string `json:"who"`
+		Tile string `json:"tile"`
+	}
+	if err := json.Unmarshal(data, &tmp); err != nil {
+		return err
+	}
+	event.Who = MapStringToWind[tmp.Who]
+	event.Tile = MapStringToTile[tmp.Tile]
+	return nil
+}
+
+type EventAgariYame struct {
+	Who      Wind `json:"who"`
+	IsTenpai bool `json:"is_tenpai"` // true for tenpai yame, false for agari yame
+}
+
+func (event *EventAgariYame) GetType() EventType {
+	return EventTypeAgariYame
+}
+
+func (event *EventAgariYame) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		Who      string `json:"who"`
+		IsTenpai bool   `json:"is_tenpai"`
+	}{
+		Who:      event.Who.String(),
+		IsTenpai: event.IsTenpai,
+	})
+}
+
+func (event *EventAgariYame) UnmarshalJSON(data []byte) error {
+	var tmp struct {
+		Who      string `json:"who"`
+		IsTenpai bool   `json:"is_tenpai"`
+	}
+	if err := json.Unmarshal(data, &tmp); err != nil {
+		return err
+	}
+	event.Who = MapStringToWind[tmp.Who]
+	event.IsTenpai = tmp.IsTenpai
+	return nil
+}
+
+type EventGameEnd struct {
+	Result *FinalResult `json:"result"`
+}
+
+func (event *EventGameEnd) GetType() EventType {
+	return EventTypeGameEnd
+}
+
+func (event *EventGameEnd) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		Result *FinalResult `json:"result"`
+	}{
+		Result: event.Result,
+	})
+}
+
+func (event *EventGameEnd) UnmarshalJSON(data []byte) error {
+	var tmp struct {
+		Result *FinalResult `json:"result"`
+	}
+	if err := json.Unmarshal(data, &tmp); err != nil {
+		return err
+	}
+	event.Result = tmp.Result
+	return nil
+}
+
+type EventKuikae struct {
+	Who    Wind `json:"who"`
+	Tile   Tile `json:"tile"`   // the swap called tile discarded
+	Points int  `json:"points"` // points paid to each other player
+}
+
+func (event *EventKuikae) GetType() EventType {
+	return EventTypeKuikae
+}
+
+func (event *EventKuikae) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		Who    string `json:"who"`
+		Tile   string `json:"tile"`
+		Points int    `json:"points"`
+	}{
+		Who:    event.Who.String(),
+		Tile:   event.Tile.String(),
+		Points: event.Points,
+	})
+}
+
+func (event *EventKuikae) UnmarshalJSON(data []byte) error {
+	var tmp struct {
+		Who    string `json:"who"`
+		Tile   string `json:"tile"`
+		Points int    `json:"points"`
+	}
+	if err := json.Unmarshal(data, &tmp); err != nil {
+		return err
+	}
+	event.Who = MapStringToWind[tmp.Who]
+	event.Tile = MapStringToTile[tmp.Tile]
+	event.Points = tmp.Points
+	return nil
+}
+
+type EventChombo struct {
+	Who          Wind         `json:"who"`
+	Call         *Call        `json:"call"`          // the illegal call
+	Policy       ChomboPolicy `json:"policy"`        // penalty policy of the chombo
+	PointsChange map[Wind]int `json:"points_change"` // points changes of the penalty, riichi deposits returned not included
+}
+
+func (event *EventChombo) GetType() EventType {
+	return EventTypeChombo
+}
+
+func (event *EventChombo) MarshalJSON() ([]byte, error) {
+	pointsChange := make(map[string]int)
+	for k, v := range event.PointsChange {
+		pointsChange[k.String()] = v
+	}
+	return json.Marshal(&struct {
+		Who          string         `json:"who"`
+		Call         *Call          `json:"call"`
+		Policy       string         `json:"policy"`
+		PointsChange map[string]int `json:"points_change"`
+	}{
+		Who:          event.Who.String(),
+		Call:         event.Call,
+		Policy:       event.Policy.String(),
+		PointsChange: pointsChange,
+	})
+}
+
+func (event *EventChombo) UnmarshalJSON(data []byte) error {
+	var tmp struct {
+		Who          string         `json:"who"`
+		Call         *Call          `json:"call"`
+		Policy       string         `json:"policy"`
+		PointsChange map[string]int `json:"points_change"`
+	}
+	if err := json.Unmarshal(data, &tmp); err != nil {
+		return err
+	}
+	pointsChange := make(map[Wind]int)
+	for k, v := range tmp.PointsChange {
+		pointsChange[MapStringToWind[k]] = v
+	}
+	event.Who = MapStringToWind[tmp.Who]
+	event.Call = tmp.Call
+	event.Policy = MapStringToChomboPolicy[tmp.Policy]
+	event.PointsChange = pointsChange
+	return nil
+}
@@ -28,11 +28,16 @@ func _() {
 	_ = x[EventTypeNagashiMangan-16]
 	_ = x[EventTypeTenpaiEnd-17]
 	_ = x[EventTypeGlobalInit-18]
+	_ = x[EventTypeKita-19]
+	_ = x[EventTypeAgariYame-20]
+	_ = x[EventTypeGameEnd-21]
+	_ = x[EventTypeKuikae-22]
+	_ = x[EventTypeChombo-23]
 }
 
-const _EventType_name = "GetTsumoGiriDiscardChiPonDaiMinKanShouMinKanAnKanRiichiRonTsumoNewIndicatorChanKanRyuuKyokuStartEndFuritenNagashiManganTenpaiEndGlobalInit"
+const _EventType_name = "GetTsumoGiriDiscardChiPonDaiMinKanShouMinKanAnKanRiichiRonTsumoNewIndicatorChanKanRyuuKyokuStartEndFuritenNagashiManganTenpaiEndGlobalInitKitaAgariYameGameEndKuikaeChombo"
 
-var _EventType_index = [...]uint8{0, 3, 12, 19, 22, 25, 34, 44, 49, 55, 58, 63, 75, 82, 91, 96, 99, 106, 119, 128, 138}
+var _EventType_index = [...]uint8{0, 3, 12, 19, 22, 25, 34, 44, 49, 55, 58, 63, 75, 82, 91, 96, 99, 106, 119, 128, 138, 142, 151, 158, 164, 170}
 
 func (i EventType) String() string {
 	i -= -1
@@ -0,0 +1,203 @@
+package mahjong
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// PlayerResult is the final settlement of one player
+type PlayerResult struct {
+	Seat   Wind    `json:"seat"`   // starting seat of the player
+	Points int     `json:"points"` // final points including the leftover riichi sticks received
+	Rank   int     `json:"rank"`   // 1 for the top, tied players share the rank under TieBreakSplit
+	Uma    float64 `json:"uma"`    // uma in thousand points
+	Oka    float64 `json:"oka"`    // oka in thousand points
+	Score  float64 `json:"score"`  // final score in thousand points, rounded points difference plus uma and oka
+}
+
+func (r *PlayerResult) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		Seat   string  `json:"seat"`
+		Points int     `json:"points"`
+		Rank   int     `json:"rank"`
+		Uma    float64 `json:"uma"`
+		Oka    float64 `json:"oka"`
+		Score  float64 `json:"score"`
+	}{
+		Seat:   r.Seat.String(),
+		Points: r.Points,
+		Rank:   r.Rank,
+		Uma:    r.Uma,
+		Oka:    r.Oka,
+		Score:  r.Score,
+	})
+}
+
+func (r *PlayerResult) UnmarshalJSON(data []byte) error {
+	var tmp struct {
+		Seat   string  `json:"seat"`
+		Points int     `json:"points"`
+		Rank   int     `json:"rank"`
+		Uma    float64 `json:"uma"`
+		Oka    float64 `json:"oka"`
+		Score  float64 `json:"score"`
+	}
+	if err := json.Unmarshal(data, &tmp); err != nil {
+		return err
+	}
+	r.Seat = MapStringToWind[tmp.Seat]
+	r.Points = tmp.Points
+	r.Rank = tmp.Rank
+	r.Uma = tmp.Uma
+	r.Oka = tmp.Oka
+	r.Score = tmp.Score
+	return nil
+}
+
+// FinalResult is the settlement of a whole game
+type FinalResult struct {
+	Players   []*PlayerResult `json:"players"`    // ordered by rank
+	NumRiichi int             `json:"num_riichi"` // riichi sticks left on the table when the game ends
+}
+
+// GetPlayerResult returns the result of the player in the starting seat, nil if not found
+func (r *FinalResult) GetPlayerResult(seat Wind) *PlayerResult {
+	for _, player := range r.Players {
+		if player.Seat == seat {
+			return player
+		}
+	}
+	return nil
+}
+
+// CalculateFinalResult
+//
+//	@Description: settle the final points of a game with the leftover riichi sticks, uma and oka
+//	@param rule: game rule, nil for default rule
+//	@param points: final points of every player, keyed by the starting seat
+//	@param numRiichi: riichi sticks left on the table
+//	@return *FinalResult
+//	@return error: ErrInvalidRule if an uma table does not have one value per player
+func CalculateFinalResult(rule *Rule, points map[Wind]int, numRiichi int) (*FinalResult, error) {
+	if rule == nil {
+		rule = GetDefaultRule()
+	}
+	var result = &FinalResult{NumRiichi: numRiichi}
+	for seat := East; seat <= North; seat++ {
+		if p, ok := points[seat]; ok {
+			result.Players = append(result.Players, &PlayerResult{Seat: seat, Points: p})
+		}
+	}
+	players := result.Players
+	n := len(players)
+	if n == 0 {
+		return result, nil
+	}
+	if err := rule.validateUma(n); err != nil {
+		return nil, err
+	}
+	// players in the earlier starting seats rank higher in the ties
+	sort.SliceStable(players, func(i, j int) bool {
+		return players[i].Points > players[j].Points
+	})
+
+	// group the tied players, every group shares one rank
+	var groups [][]*PlayerResult
+	for i := 0; i < n; {
+		j := i + 1
+		for rule.TieBreakPolicy == TieBreakSplit && j < n && players[j].Points == players[i].Points {
+			j++
+		}
+		for _, player := range players[i:j] {
+			player.Rank = i + 1
+		}
+		groups = append(groups, players[i:j])
+		i = j
+	}
+
+	// leftover riichi sticks, ranks are decided before the sticks are given
+	if rule.KyoutakuPolicy == KyoutakuTop && numRiichi > 0 {
+		tops := groups[0]
+		share := numRiichi * rule.RiichiDeposit / len(tops) / 100 * 100
+		for _, player := range tops {
+			player.Points += share
+		}
+		tops[0].Points += numRiichi*rule.RiichiDeposit - share*len(tops)
+	}
+
+	uma := rule.Uma
+	if len(uma) == 0 {
+		uma = make([]int, n)
+	}
+	var numFloating = 0
+	for _, player := range players {
+		if player.Points >= rule.TargetPoints {
+			numFloating++
+		}
+	}
+	if numFloating < len(rule.FloatingUma) && len(rule.FloatingUma[numFloating]) != 0 {
+		uma = rule.FloatingUma[numFloating]
+	}
+	var oka = 0
+	var basePoints = rule.StartingPoints
+	if rule.IsOka {
+		oka = (rule.TargetPoints - rule.StartingPoints) * n
+		basePoints = rule.TargetPoints
+	}
+
+	// the top player takes the rounding difference, keeps the sum of scores unchanged
+	var diffs = make([]int, n)
+	var roundingDiff = 0
+	for i, player := range players {
+		diffs[i] = roundPoints(player.Points-basePoints, rule.RoundingPolicy)
+		roundingDiff += player.Points - basePoints - diffs[i]
+	}
+	diffs[0] += roundingDiff
+
+	var rank = 0
+	for _, group := range groups {
+		var umaSum = 0
+		var okaSum = 0
+		for i := range group {
+			umaSum += uma[rank+i]
+			if rank+i == 0 {
+				okaSum += oka
+			}
+		}
+		for i, player := range group {
+			player.Uma = float64(umaSum) / float64(len(group)) / 1000
+			player.Oka = float64(okaSum) / float64(len(group)) / 1000
+			player.Score = float64(diffs[rank+i]*len(group)+umaSum+okaSum) / float64(len(group)) / 1000
+		}
+		rank += len(group)
+	}
+	return result, nil
+}
+
+// roundPoints rounds the points to thousands with the rounding policy
+func roundPoints(points int, policy RoundingPolicy) int {
+	var sign = 1
+	var abs = points
+	if points < 0 {
+		sign = -1
+		abs = -points
+	}
+	switch policy {
+	case RoundingHalfUp:
+		return sign * ((abs + 500) / 1000 * 1000)
+	case RoundingFiveDownSixUp:
+		return sign * ((abs + 400) / 1000 * 1000)
+	case RoundingFloor:
+		if points < 0 {
+			return -((abs + 999) / 1000 * 1000)
+		}
+		return abs / 1000 * 1000
+	case RoundingCeil:
+		if points < 0 {
+			return -(abs / 1000 * 1000)
+		}
+		return (abs + 999) / 1000 * 1000
+	default:
+		return points
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/dnovikoff/tempai-core/base"
 	"github.com/dnovikoff/tempai-core/score"
+	"github.com/dnovikoff/tempai-core/tile"
 	"github.com/dnovikoff/tempai-core/yaku"
 	"github.com/hphphp123321/go-common"
 	"math/rand"
@@ -23,7 +24,8 @@ type Game struct {
 	PosPlayer map[Wind]*Player
 	posEvents map[Wind]Events
 
-	allEvents Events // all events in one game (several rounds)
+	allEvents   Events       // all events in one game (several rounds)
+	finalResult *FinalResult // final settlement, nil before the game ends
 
 	Tiles *MahjongTiles
 
@@ -38,7 +40,16 @@ type Game struct {
 
 	Position Wind
 
-	State gameState
+	State    gameState
+	posCalls map[Wind]Calls // valid calls returned by the last step
+
+	history      []*Game // snapshots before the last steps, the latest at the end
+	historyDepth int     // max number of snapshots kept, 0 for no undo
+
+	wallGenerator WallGenerator // generator of the walls, nil for shuffling with the seed
+	nextWall      Tiles         // wall generated for the round the step deals, nil for shuffling with the seed
+
+	hasWaitValues bool // the tenpai results of the events have the values of the waits, see WithWaitValues
 }
 
 // NewMahjongGame
@@ -46,11 +57,12 @@ type Game struct {
 //	@Description: create a new game
 //	@param Seed: random Seed
 //	@param Rule: game Rule, nil for default Rule
+//	@param options: game options, e.g. WithWallGenerator
 //	@return *Game
-func NewMahjongGame(seed int64, rule *Rule) *Game {
-	randP := rand.New(rand.NewSource(seed))
+func NewMahjongGame(seed int64, rule *Rule, options ...GameOption) *Game {
+	source := newSeededSource(seed)
+	randP := rand.New(source)
 	game := Game{
-		Tiles:     NewMahjongTiles(randP),
 		WindRound: WindRoundDummy,
 		Seed:      seed,
 	}
@@ -59,16 +71,38 @@ func NewMahjongGame(seed int64, rule *Rule) *Game {
 	} else {
 		game.Rule = rule
 	}
+	if game.Rule.IsSanma {
+		game.Tiles = NewSanmaTiles(randP)
+	} else {
+		game.Tiles = NewMahjongTiles(randP)
+	}
+	game.Tiles.source = source
+	for _, option := range options {
+		option(&game)
+	}
 	return &game
 }
 
 // Reset
 //
 //	@Description: reset game for new game
-//	@param playerSlice: player slice, len must be 4, and the order is East, South, West, North
+//	@param playerSlice: player slice, len must be 4(3 for sanma), and the order is East, South, West, North
 //	@param tiles: tiles for game, if nil, will use default random tiles
 //	@return map[Wind]Calls: calls for East player
 func (game *Game) Reset(playerSlice []*Player, tiles Tiles) map[Wind]Calls {
+	game.reset(playerSlice, tiles)
+	posCalls, _ := game.Step(make(map[Wind]*Call, 4))
+	return posCalls
+}
+
+// reset prepares a new game without dealing the first round
+func (game *Game) reset(playerSlice []*Player, tiles Tiles) {
+	if len(playerSlice) != game.Rule.NumPlayers() {
+		panic(fmt.Errorf("len of player slice must be %d", game.Rule.NumPlayers()))
+	}
+	if err := game.Rule.Validate(); err != nil {
+		panic(err)
+	}
 	game.NumGame = -1
 	game.WindRound = WindRoundEast1
 	game.NumRiichi = 0
@@ -78,20 +112,180 @@ func (game *Game) Reset(playerSlice []*Player, tiles Tiles) map[Wind]Calls {
 	game.posEvents = map[Wind]Events{}
 	game.honbaPlus = false
 	game.nextRound = false
+	game.allEvents = nil
+	game.finalResult = nil
+	game.posCalls = nil
+	game.history = nil
 
 	game.P0 = playerSlice[0]
 	game.P1 = playerSlice[1]
 	game.P2 = playerSlice[2]
-	game.P3 = playerSlice[3]
+	game.P3 = nil
+	if len(playerSlice) == 4 {
+		game.P3 = playerSlice[3]
+	}
 
-	game.P0.ResetForGame()
-	game.P1.ResetForGame()
-	game.P2.ResetForGame()
-	game.P3.ResetForGame()
-	game.PosPlayer = map[Wind]*Player{0: playerSlice[0], 1: playerSlice[1], 2: playerSlice[2], 3: playerSlice[3]}
+	for _, player := range game.getPlayers() {
+		player.ResetForGame(game.Rule.StartingPoints)
+	}
+	game.PosPlayer = make(map[Wind]*Player, len(playerSlice))
+	game.seatPlayers()
+}
 
-	posCalls, _ := game.Step(make(map[Wind]*Call, 4))
-	return posCalls
+// Clone
+//
+//	@Description: copy the game, stepping the clone never changes the original. The rule and the recorded events are shared since they are never modified,
+//	the random source created by NewMahjongGame and the wall generator are copied
+//	@receiver game
+//	@return *Game
+func (game *Game) Clone() *Game {
+	g := *game
+	g.history = nil
+	players := make(map[*Player]*Player, 4)
+	clonePlayer := func(player *Player) *Player {
+		if player == nil {
+			return nil
+		}
+		if _, ok := players[player]; !ok {
+			players[player] = player.Copy()
+		}
+		return players[player]
+	}
+	g.P0 = clonePlayer(game.P0)
+	g.P1 = clonePlayer(game.P1)
+	g.P2 = clonePlayer(game.P2)
+	g.P3 = clonePlayer(game.P3)
+	g.PosPlayer = make(map[Wind]*Player, len(game.PosPlayer))
+	for wind, player := range game.PosPlayer {
+		g.PosPlayer[wind] = clonePlayer(player)
+	}
+	g.posEvents = make(map[Wind]Events, len(game.posEvents))
+	for wind, events := range game.posEvents {
+		g.posEvents[wind] = append(Events(nil), events...)
+	}
+	g.allEvents = append(Events(nil), game.allEvents...)
+	if game.Tiles != nil {
+		g.Tiles = game.Tiles.Clone()
+	}
+	if game.State != nil {
+		g.State = game.State.clone(&g)
+	}
+	if game.wallGenerator != nil {
+		g.wallGenerator = game.wallGenerator.Clone()
+	}
+	if game.posCalls != nil {
+		g.posCalls = make(map[Wind]Calls, len(game.posCalls))
+		for wind, calls := range game.posCalls {
+			g.posCalls[wind] = calls.Copy()
+		}
+	}
+	return &g
+}
+
+// SetHistoryDepth
+//
+//	@Description: set the number of steps can be undone, a snapshot of the game is kept before every step
+//	@receiver game
+//	@param depth: max number of steps kept, 0 for no undo
+func (game *Game) SetHistoryDepth(depth int) {
+	game.historyDepth = depth
+	if len(game.history) > depth {
+		game.history = append([]*Game(nil), game.history[len(game.history)-depth:]...)
+	}
+}
+
+// Undo
+//
+//	@Description: return to the state before the last step
+//	@receiver game
+//	@return map[Wind]Calls: player valid actions before the last step
+//	@return error: ErrNoHistory if no step can be undone
+func (game *Game) Undo() (map[Wind]Calls, error) {
+	if len(game.history) == 0 {
+		return nil, ErrNoHistory
+	}
+	snapshot := game.history[len(game.history)-1]
+	game.history = game.history[:len(game.history)-1]
+	game.restore(snapshot)
+	return game.posCalls, nil
+}
+
+// RewindTo
+//
+//	@Description: return to the latest state with at most eventIndex global events, see GetAllGlobalEvents
+//	@receiver game
+//	@param eventIndex: number of global events kept
+//	@return map[Wind]Calls: player valid actions of the state
+//	@return error: ErrNoHistory if the state is beyond the history
+func (game *Game) RewindTo(eventIndex int) (map[Wind]Calls, error) {
+	if game.numGlobalEvents() <= eventIndex {
+		return game.posCalls, nil
+	}
+	for i := len(game.history) - 1; i >= 0; i-- {
+		if game.history[i].numGlobalEvents() <= eventIndex {
+			snapshot := game.history[i]
+			game.history = game.history[:i]
+			game.restore(snapshot)
+			return game.posCalls, nil
+		}
+	}
+	return nil, ErrNoHistory
+}
+
+// snapshot returns a copy of the game for the history, nil if no step can be undone
+func (game *Game) snapshot() *Game {
+	if game.historyDepth == 0 {
+		return nil
+	}
+	return game.Clone()
+}
+
+// pushHistory keeps the snapshot of the game before a step
+func (game *Game) pushHistory(snapshot *Game) {
+	if snapshot == nil {
+		return
+	}
+	if len(game.history) == game.historyDepth {
+		game.history = append(game.history[:0], game.history[1:]...)
+	}
+	game.history = append(game.history, snapshot)
+}
+
+// restore sets the game to the snapshot, the player and tiles objects of the game are kept
+func (game *Game) restore(snapshot *Game) {
+	history := game.history
+	players := game.getPlayers()
+	tiles := game.Tiles
+	var targets = make(map[*Player]*Player, len(players))
+	for i, player := range snapshot.getPlayers() {
+		*players[i] = *player
+		targets[player] = players[i]
+	}
+
+	*game = *snapshot
+	game.history = history
+	game.P0 = players[0]
+	game.P1 = players[1]
+	game.P2 = players[2]
+	game.P3 = nil
+	if len(players) == 4 {
+		game.P3 = players[3]
+	}
+	game.PosPlayer = make(map[Wind]*Player, len(snapshot.PosPlayer))
+	for wind, player := range snapshot.PosPlayer {
+		game.PosPlayer[wind] = targets[player]
+	}
+	*tiles = *snapshot.Tiles
+	game.Tiles = tiles
+	game.State = snapshot.State.clone(game)
+}
+
+// numGlobalEvents returns the number of global events of the whole game
+func (game *Game) numGlobalEvents() int {
+	if len(game.posEvents[East]) == 0 {
+		return len(game.allEvents)
+	}
+	return len(game.GetAllGlobalEvents())
 }
 
 // Step
@@ -101,28 +295,103 @@ func (game *Game) Reset(playerSlice []*Player, tiles Tiles) map[Wind]Calls {
 //	@param map[Wind]*Call: player action, if len(posCall) == 0, game will auto call
 //	@return map[Wind]Calls: player valid actions
 //	@return EndType: game end type, EndTypeNone for not end, EndTypeRound for round end, EndTypeGame for game end
+//	panics if the wall generator fails, see StepE
 func (game *Game) Step(posCall map[Wind]*Call) (map[Wind]Calls, EndType) {
+	posCalls, endType, err := game.step(posCall)
+	if err != nil {
+		panic(err)
+	}
+	return posCalls, endType
+}
+
+// StepE
+//
+//	@Description: game step with the actions validated against the last valid calls, the game is not changed if an error is returned
+//	@receiver game
+//	@param map[Wind]*Call: player action, exactly one call for each seat with valid calls
+//	@return map[Wind]Calls: player valid actions
+//	@return EndType: game end type, EndTypeNone for not end, EndTypeRound for round end, EndTypeGame for game end
+//	@return error: ErrUnexpectedSeat, ErrMissingResponse, ErrIllegalCall or the error of the wall generator
+func (game *Game) StepE(posCall map[Wind]*Call) (map[Wind]Calls, EndType, error) {
+	if game.finalResult == nil {
+		if err := game.validateCalls(posCall); err != nil {
+			return game.posCalls, EndTypeNone, err
+		}
+	}
+	return game.step(posCall)
+}
+
+// validateCalls checks that exactly the expected seats respond with one of their valid calls,
+// the illegal calls penalized by chombo mode are allowed
+func (game *Game) validateCalls(posCall map[Wind]*Call) error {
+	for _, wind := range common.SortMapByKey(posCall) {
+		if _, ok := game.posCalls[wind]; !ok {
+			return fmt.Errorf("%w: %s", ErrUnexpectedSeat, wind)
+		}
+	}
+	for _, wind := range common.SortMapByKey(game.posCalls) {
+		call, ok := posCall[wind]
+		if !ok || call == nil {
+			return fmt.Errorf("%w: %s", ErrMissingResponse, wind)
+		}
+		calls := game.posCalls[wind]
+		if calls.indexExact(call) == -1 && !(game.Rule.IsChombo && game.judgeWellFormed(game.PosPlayer[wind], call)) {
+			return fmt.Errorf("%w: %s %s %s", ErrIllegalCall, wind, call.CallType, call.CallTiles)
+		}
+	}
+	return nil
+}
+
+// step runs the state machine until some players need to act, the malformed calls and the wall of a new round
+// are checked before any change, the other errors of the states come from the calls validateCalls rejects,
+// so StepE never changes the game on error
+func (game *Game) step(posCall map[Wind]*Call) (map[Wind]Calls, EndType, error) {
 	var posCalls = make(map[Wind]Calls, 4)
+	if game.finalResult != nil {
+		return posCalls, EndTypeGame, nil
+	}
+	if wind, call := game.judgeMalformed(posCall); call != nil {
+		return game.posCalls, EndTypeNone, fmt.Errorf("%w: %s %s %s", ErrIllegalCall, wind, call.CallType, call.CallTiles)
+	}
+	chomboWind, chomboCall := game.judgeChombo(posCall)
+	snapshot := game.snapshot()
+	if chomboCall == nil && game.dealsRound(posCall) {
+		wall, err := game.generateWall()
+		if err != nil {
+			return game.posCalls, EndTypeNone, err
+		}
+		game.nextWall = wall
+	}
+	game.pushHistory(snapshot)
 	if len(posCall) == 0 {
 		posCalls = game.State.step()
+	} else if chomboCall != nil {
+		// the illegal call ends the hand
+		game.State = &EndState{
+			g:          game,
+			chomboWind: chomboWind,
+			chomboCall: chomboCall,
+		}
+		posCalls = game.State.step()
 	}
 	for len(posCalls) == 0 {
 		if err := game.State.next(posCall); err != nil {
 			if errors.Is(err, ErrGameEnd) {
-				return posCalls, EndTypeGame
+				game.processGameEnd()
+				game.posCalls = posCalls
+				return posCalls, EndTypeGame, nil
 			} else {
-				panic(err)
+				return posCalls, EndTypeNone, err
 			}
 		}
 		posCall = make(map[Wind]*Call, 4)
 		posCalls = game.State.step()
 	}
-	if len(posCalls) == 4 { // round end
-		var globalEvents = game.GetGlobalEvents()
-		game.allEvents = append(game.allEvents, globalEvents...)
-		return posCalls, EndTypeRound
+	game.posCalls = posCalls
+	if _, ok := game.State.(*EndState); ok { // round end
+		return posCalls, EndTypeRound, nil
 	}
-	return posCalls, EndTypeNone
+	return posCalls, EndTypeNone, nil
 }
 
 // GetPosEvents
@@ -151,6 +420,7 @@ func (game *Game) GetPosBoardState(pos Wind, validActions Calls) (r *BoardState)
 			DiscardTiles:   p.DiscardTiles,
 			TilesTsumoGiri: p.TilesTsumoGiri,
 			IsRiichi:       p.IsRiichi,
+			KitaTiles:      p.KitaTiles,
 		}
 	}
 	r = &BoardState{
@@ -161,6 +431,8 @@ func (game *Game) GetPosBoardState(pos Wind, validActions Calls) (r *BoardState)
 		PlayerWind:     pos,
 		Position:       game.Position,
 		HandTiles:      game.PosPlayer[pos].HandTiles,
+		KuikaeTiles:    game.getKuikaeTiles(game.PosPlayer[pos]),
+		DrawnTile:      game.drawnTile(pos),
 		ValidActions:   validActions,
 		NumRemainTiles: game.Tiles.NumRemainTiles,
 		PlayerStates:   playerStates,
@@ -168,6 +440,42 @@ func (game *Game) GetPosBoardState(pos Wind, validActions Calls) (r *BoardState)
 	return r
 }
 
+// drawnTile returns the tile just drawn by the player, TileDummy once the player discards or calls
+func (game *Game) drawnTile(pos Wind) Tile {
+	events := game.posEvents[pos]
+	for i := len(events) - 1; i >= 0; i-- {
+		who := WindDummy
+		switch e := events[i].(type) {
+		case *EventStart:
+			return TileDummy
+		case *EventGet:
+			if e.Who == pos {
+				return e.Tile
+			}
+		case *EventDiscard:
+			who = e.Who
+		case *EventTsumoGiri:
+			who = e.Who
+		case *EventChi:
+			who = e.Who
+		case *EventPon:
+			who = e.Who
+		case *EventDaiMinKan:
+			who = e.Who
+		case *EventShouMinKan:
+			who = e.Who
+		case *EventAnKan:
+			who = e.Who
+		case *EventKita:
+			who = e.Who
+		}
+		if who == pos {
+			return TileDummy
+		}
+	}
+	return TileDummy
+}
+
 func (game *Game) addPosEvent(posEvent map[Wind]Event) {
 	for pos, event := range posEvent {
 		if event == nil {
@@ -177,27 +485,105 @@ func (game *Game) addPosEvent(posEvent map[Wind]Event) {
 	}
 }
 
-func (game *Game) newGameRound() {
-	game.NumGame += 1
-	if game.nextRound {
-		game.WindRound++
-		game.nextRound = false
+// nextRoundInfo returns the metadata of the round dealt next
+func (game *Game) nextRoundInfo() WallInfo {
+	info := WallInfo{
+		Seed:      game.Seed,
+		NumGame:   game.NumGame + 1,
+		WindRound: game.WindRound,
+		NumHonba:  game.NumHonba,
+		IsSanma:   game.Rule.IsSanma,
 	}
 	if game.honbaPlus {
-		game.NumHonba++
-		game.honbaPlus = false
+		info.NumHonba++
+	} else if game.nextRound {
+		// honba is cleared after a non-dealer win
+		info.NumHonba = 0
+	}
+	if game.nextRound {
+		info.WindRound = game.getNextWindRound(game.WindRound)
 	}
-	game.Tiles.Reset()
+	return info
+}
+
+func (game *Game) newGameRound() {
+	info := game.nextRoundInfo()
+	game.NumGame = info.NumGame
+	game.WindRound = info.WindRound
+	game.NumHonba = info.NumHonba
+	game.honbaPlus = false
+	game.nextRound = false
+	game.resetWall()
 	game.posEvents = map[Wind]Events{}
 	game.Position = East
-	game.PosPlayer[Wind((16-game.WindRound+WindRoundEast1)%4)] = game.P0
-	game.PosPlayer[Wind((17-game.WindRound+WindRoundEast1)%4)] = game.P1
-	game.PosPlayer[Wind((18-game.WindRound+WindRoundEast1)%4)] = game.P2
-	game.PosPlayer[Wind((19-game.WindRound+WindRoundEast1)%4)] = game.P3
-	game.P0.ResetForRound()
-	game.P1.ResetForRound()
-	game.P2.ResetForRound()
-	game.P3.ResetForRound()
+	game.seatPlayers()
+	for _, player := range game.getPlayers() {
+		player.ResetForRound()
+	}
+}
+
+// dealsRound judges the step deals a new round
+func (game *Game) dealsRound(posCall map[Wind]*Call) bool {
+	switch game.State.(type) {
+	case *InitState:
+		return len(posCall) == 0
+	case *EndState:
+		call, ok := posCall[East]
+		return len(posCall) != 0 && !(ok && call.CallType == AgariYame)
+	}
+	return false
+}
+
+// generateWall generates the wall of the round dealt next, nil for shuffling with the seed
+func (game *Game) generateWall() (Tiles, error) {
+	if game.wallGenerator == nil {
+		return nil, nil
+	}
+	wall, err := game.wallGenerator.Wall(game.nextRoundInfo(), game.Tiles.wallTiles())
+	if err != nil {
+		return nil, err
+	}
+	if err = game.Tiles.checkWall(wall); err != nil {
+		return nil, err
+	}
+	return wall, nil
+}
+
+// resetWall prepares the wall of the new round, the wall from the generator is checked by step
+func (game *Game) resetWall() {
+	if game.nextWall == nil {
+		game.Tiles.Reset()
+		return
+	}
+	game.Tiles.setWall(game.nextWall)
+	game.nextWall = nil
+}
+
+// seatPlayers maps players to the winds of the current wind round, the dealer moves one seat every wind round
+func (game *Game) seatPlayers() {
+	numPlayers := game.Rule.NumPlayers()
+	dealer := int(game.WindRound-WindRoundEast1) % 4
+	for i, player := range game.getPlayers() {
+		game.PosPlayer[Wind((i-dealer+numPlayers)%numPlayers)] = player
+	}
+}
+
+// getNextWindRound returns the wind round after windRound, sanma skips the fourth round of every wind
+func (game *Game) getNextWindRound(windRound WindRound) WindRound {
+	windRound++
+	if game.Rule.IsSanma && (windRound-WindRoundEast1)%4 == 3 {
+		windRound++
+	}
+	return windRound
+}
+
+// getLastWindRound returns the all last wind round of the game length
+func (game *Game) getLastWindRound() WindRound {
+	lastWindRound := WindRound(game.Rule.GameLength)
+	if game.Rule.IsSanma && (lastWindRound-WindRoundEast1)%4 == 3 {
+		lastWindRound--
+	}
+	return lastWindRound
 }
 
 func (game *Game) getTileProcess(pMain *Player, tileID Tile) {
@@ -220,17 +606,21 @@ func (game *Game) dealTile(dealRinshan bool) Tile {
 	return game.Tiles.DealTile(dealRinshan)
 }
 
-func (game *Game) discardTileProcess(pMain *Player, tileID Tile) {
+// discardTileProcess discards the tile, hasDrawn is false for the discard after chi or pon
+// whose hand has no drawn tile
+func (game *Game) discardTileProcess(pMain *Player, tileID Tile, hasDrawn bool) {
 	if !game.Tiles.allTiles[tileID].discardable {
 		panic("Illegal Discard ID")
 	}
-	if tileID == pMain.HandTiles[len(pMain.HandTiles)-1] {
+	if hasDrawn && tileID == pMain.HandTiles[len(pMain.HandTiles)-1] {
 		pMain.TilesTsumoGiri = append(pMain.TilesTsumoGiri, true)
 	} else {
 		pMain.TilesTsumoGiri = append(pMain.TilesTsumoGiri, false)
 	}
 	pMain.HandTiles.Remove(tileID)
 	pMain.DiscardTiles.Append(tileID)
+	pMain.RinshanPaoWind = WindDummy
+	pMain.KuikaeClasses = nil
 	pMain.BoardTiles.Append(tileID)
 	sort.Sort(&pMain.HandTiles)
 	pMain.IppatsuStatus = false
@@ -252,6 +642,11 @@ func (game *Game) discardTileProcess(pMain *Player, tileID Tile) {
 	} else {
 		pMain.TenpaiSlice = []TileClass{}
 	}
+	game.judgeFuritenStatus(tileID)
+}
+
+// judgeFuritenStatus marks the other players who are waiting on tileID, they become furiten if they don't ron it
+func (game *Game) judgeFuritenStatus(tileID Tile) {
 	otherWinds := game.getOtherWinds()
 	for _, wind := range otherWinds {
 		if common.SliceContain(game.PosPlayer[wind].TenpaiSlice, tileID.Class()) {
@@ -262,6 +657,37 @@ func (game *Game) discardTileProcess(pMain *Player, tileID Tile) {
 	}
 }
 
+// processSkipFuriten sets the furiten of the players who skip a tile they are waiting on
+func (game *Game) processSkipFuriten(posCalls map[Wind]*Call) {
+	for _, wind := range game.getOtherWinds() {
+		player := game.PosPlayer[wind]
+		if !player.FuritenStatus {
+			continue
+		}
+		if _, ok := posCalls[wind]; !ok {
+			continue
+		}
+		if posCalls[wind].CallType == Ron {
+			continue
+		}
+		var furitenReason FuritenReason
+		if player.IsRiichi {
+			player.RiichiFuriten = true
+			furitenReason = FuritenRiichi
+		} else {
+			player.JunFuriten = true
+			furitenReason = FuritenJun
+		}
+		furitenEvent := &EventFuriten{
+			Who:           wind,
+			FuritenReason: furitenReason,
+		}
+		game.addPosEvent(map[Wind]Event{
+			wind: furitenEvent,
+		})
+	}
+}
+
 func (game *Game) getDiscardableSlice(handTiles Tiles) Tiles {
 	tiles := Tiles{}
 	for _, tile := range handTiles {
@@ -295,11 +721,15 @@ func (game *Game) JudgeSelfCalls(pMain *Player) Calls {
 	riichi := game.judgeRiichi(pMain)
 	shouMinKan := game.judgeShouMinKan(pMain)
 	anKan := game.judgeAnKan(pMain)
+	kita := game.judgeKita(pMain)
+	kyuuShuKyuuHai := game.judgeKyuShuKyuHai(pMain)
 	discard := game.JudgeDiscardCall(pMain)
 	validCalls = append(validCalls, tsumo...)
 	validCalls = append(validCalls, riichi...)
 	validCalls = append(validCalls, shouMinKan...)
 	validCalls = append(validCalls, anKan...)
+	validCalls = append(validCalls, kita...)
+	validCalls = append(validCalls, kyuuShuKyuuHai...)
 	validCalls = append(validCalls, discard...)
 	if len(validCalls) == 0 {
 		panic("no valid action")
@@ -367,7 +797,7 @@ func (game *Game) processTsumo(pMain *Player, call *Call) *Result {
 
 func (game *Game) processRon(pMain *Player, call *Call) *Result {
 	winTile := call.CallTiles[0]
-	if game.Tiles.allTiles[winTile].isLast {
+	if game.GetNumRemainTiles() == 0 {
 		pMain.IsHoutei = true
 	}
 	if pMain.IsRiichi && pMain.IppatsuStatus {
@@ -388,13 +818,13 @@ func (game *Game) processRiichiStep1(pMain *Player, call *Call) {
 	pMain.RiichiStep = 1
 	pMain.IsRiichi = true
 	riichiTile := call.CallTiles[0]
-	game.discardTileProcess(pMain, riichiTile)
+	game.discardTileProcess(pMain, riichiTile, true)
 	pMain.IppatsuStatus = true
 }
 
 func (game *Game) processRiichiStep2(pMain *Player) {
 	pMain.RiichiStep = 2
-	pMain.Points -= 1000
+	pMain.Points -= game.Rule.RiichiDeposit
 	game.NumRiichi++
 }
 
@@ -405,13 +835,32 @@ func (game *Game) processChi(pMain *Player, call *Call) {
 	subWind := call.CallTilesFromWho[2]
 	game.PosPlayer[subWind].BoardTiles.Remove(tileID)
 	pMain.Melds = append(pMain.Melds, call)
+	game.processKuikae(pMain, call)
+	pMain.JunNum++
+}
 
-	// 食替
-	tileClass := tileID.Class()
+func (game *Game) processPon(pMain *Player, call *Call) {
+	pMain.HandTiles.Remove(call.CallTiles[0])
+	pMain.HandTiles.Remove(call.CallTiles[1])
+	tileID := call.CallTiles[2]
+	subWind := call.CallTilesFromWho[2]
+	game.PosPlayer[subWind].BoardTiles.Remove(tileID)
+	pMain.Melds = append(pMain.Melds, call)
+	game.judgePao(pMain, call, subWind)
+	game.processKuikae(pMain, call)
+	pMain.JunNum++
+}
+
+// getKuikaeClasses returns the tile classes the caller can't discard right after the chi or pon under the policy
+func getKuikaeClasses(call *Call, policy KuikaePolicy) TileClasses {
+	posClass := make(TileClasses, 0, 4)
+	if policy == KuikaeAllowed {
+		return posClass
+	}
+	tileClass := call.CallTiles[2].Class()
 	tile1Class := call.CallTiles[0].Class()
 	tile2Class := call.CallTiles[1].Class()
-	posClass := make(TileClasses, 0, 4)
-	if tile1Class-tile2Class == 1 || tile2Class-tile1Class == 1 {
+	if call.CallType == Chi && policy != KuikaeForbidGenbutsu && (tile1Class-tile2Class == 1 || tile2Class-tile1Class == 1) {
 		if !common.SliceContain(TileClasses{0, 9, 18, 8, 17, 26}, tile1Class) &&
 			!common.SliceContain(TileClasses{0, 9, 18, 8, 17, 26}, tile2Class) {
 			posClass = TileClasses{tile1Class - 1, tile1Class + 1, tile2Class - 1, tile2Class + 1}
@@ -421,33 +870,72 @@ func (game *Game) processChi(pMain *Player, call *Call) {
 		}
 	}
 	posClass.Append(tileClass)
+	return posClass
+}
+
+// processKuikae restricts the discards after the chi or pon, under KuikaePenalty the restricted tiles are still discardable
+func (game *Game) processKuikae(pMain *Player, call *Call) {
+	pMain.KuikaeClasses = getKuikaeClasses(call, game.Rule.KuikaePolicy)
 	for _, tile := range pMain.HandTiles {
-		if common.SliceContain(posClass, tile.Class()) {
+		if game.Rule.KuikaePolicy != KuikaePenalty && common.SliceContain(pMain.KuikaeClasses, tile.Class()) {
 			game.Tiles.allTiles[tile].discardable = false
 		} else {
 			game.Tiles.allTiles[tile].discardable = true
 		}
 	}
-	pMain.JunNum++
 }
 
-func (game *Game) processPon(pMain *Player, call *Call) {
-	pMain.HandTiles.Remove(call.CallTiles[0])
-	pMain.HandTiles.Remove(call.CallTiles[1])
-	tileID := call.CallTiles[2]
-	subWind := call.CallTilesFromWho[2]
-	game.PosPlayer[subWind].BoardTiles.Remove(tileID)
-	pMain.Melds = append(pMain.Melds, call)
-	// 食替
-	tileClass := tileID / 4
+// processKuikaePenalty the swap caller pays Rule.KuikaePenalty to each other player if the discard is restricted by kuikae
+func (game *Game) processKuikaePenalty(pMain *Player, tileID Tile) bool {
+	if game.Rule.KuikaePolicy != KuikaePenalty || !common.SliceContain(pMain.KuikaeClasses, tileID.Class()) {
+		return false
+	}
+	for _, wind := range game.getWinds() {
+		if wind != pMain.Wind {
+			game.PosPlayer[wind].Points += game.Rule.KuikaePenalty
+			pMain.Points -= game.Rule.KuikaePenalty
+		}
+	}
+	return true
+}
+
+// getKuikaeTiles returns the hand tiles of the player restricted by kuikae
+func (game *Game) getKuikaeTiles(pMain *Player) Tiles {
+	var tiles Tiles
 	for _, tile := range pMain.HandTiles {
-		if tile/4 == tileClass {
-			game.Tiles.allTiles[tile].discardable = false
-		} else {
-			game.Tiles.allTiles[tile].discardable = true
+		if common.SliceContain(pMain.KuikaeClasses, tile.Class()) {
+			tiles = append(tiles, tile)
 		}
 	}
-	pMain.JunNum++
+	return tiles
+}
+
+// filterKuikaeCalls removes the chi or pon calls which would leave no legal discard
+func (game *Game) filterKuikaeCalls(pMain *Player, posCalls Calls) Calls {
+	if game.Rule.KuikaePolicy == KuikaeAllowed || game.Rule.KuikaePolicy == KuikaePenalty {
+		return posCalls
+	}
+	delIdxSlice := make([]int, 0, len(posCalls))
+	for i, call := range posCalls {
+		handTilesCopy := pMain.HandTiles.Copy()
+		handTilesCopy.Remove(call.CallTiles[0])
+		handTilesCopy.Remove(call.CallTiles[1])
+		posClass := getKuikaeClasses(call, game.Rule.KuikaePolicy)
+		flag := true
+		for _, handTilesID := range handTilesCopy {
+			if !common.SliceContain(posClass, handTilesID.Class()) {
+				flag = false
+				break
+			}
+		}
+		if flag {
+			delIdxSlice = append(delIdxSlice, i)
+		}
+	}
+	if len(delIdxSlice) > 0 {
+		posCalls = common.RemoveIndex(posCalls, delIdxSlice...)
+	}
+	return posCalls
 }
 
 func (game *Game) processDaiMinKan(pMain *Player, call *Call) {
@@ -459,6 +947,57 @@ func (game *Game) processDaiMinKan(pMain *Player, call *Call) {
 	game.PosPlayer[subWind].BoardTiles.Remove(tileID)
 
 	pMain.Melds.Append(call)
+	game.judgePao(pMain, call, subWind)
+	pMain.RinshanPaoWind = subWind
+}
+
+// judgePao judge the player who fed the pon or daiminkan is liable for daisangen, daisuushi or suukantsu,
+// only the first liability of a player is kept
+func (game *Game) judgePao(pMain *Player, call *Call, subWind Wind) {
+	if pMain.PaoWind != WindDummy {
+		return
+	}
+	tileClass := call.CallTiles[0].Class()
+	var numDragons, numWinds, numKans = 0, 0, 0
+	for _, meld := range pMain.Melds {
+		if meld.CallType == Chi {
+			continue
+		}
+		switch meldClass := meld.CallTiles[0].Class(); {
+		case meldClass >= Haku && meldClass <= Chun:
+			numDragons++
+		case meldClass >= Ton && meldClass <= Pei:
+			numWinds++
+		}
+		if meld.CallType != Pon {
+			numKans++
+		}
+	}
+	switch {
+	case tileClass >= Haku && tileClass <= Chun && numDragons == 3:
+		pMain.PaoYakuman = YakumanDaisangen
+	case tileClass >= Ton && tileClass <= Pei && numWinds == 4:
+		pMain.PaoYakuman = YakumanDaisuushi
+	case game.Rule.IsSuuKantsuPao && call.CallType == DaiMinKan && numKans == 4:
+		pMain.PaoYakuman = YakumanSuukantsu
+	default:
+		return
+	}
+	pMain.PaoWind = subWind
+}
+
+// getPaoWind returns the player liable for the win of wind, WindDummy for no liability
+func (game *Game) getPaoWind(wind Wind, result *Result) Wind {
+	pMain := game.PosPlayer[wind]
+	if pMain.PaoWind != WindDummy && result.YakuResult != nil &&
+		common.SliceContain(result.YakuResult.Yakumans, pMain.PaoYakuman) {
+		return pMain.PaoWind
+	}
+	if game.Rule.IsRinshanPao && pMain.RinshanPaoWind != WindDummy && pMain.IsRinshan &&
+		result.RonCall != nil && result.RonCall.CallType == Tsumo {
+		return pMain.RinshanPaoWind
+	}
+	return WindDummy
 }
 
 func (game *Game) processAnKan(pMain *Player, call *Call) {
@@ -485,6 +1024,15 @@ func (game *Game) processShouMinKan(pMain *Player, call *Call) {
 	panic("ShouMinKan not success!")
 }
 
+func (game *Game) processKita(pMain *Player, call *Call) {
+	tileID := call.CallTiles[0]
+	pMain.HandTiles.Remove(tileID)
+	sort.Sort(&pMain.HandTiles)
+	pMain.KitaTiles.Append(tileID)
+	game.Tiles.allTiles[tileID].discardWind = pMain.Wind
+	game.judgeFuritenStatus(tileID)
+}
+
 func (game *Game) processKyuuShuKyuuHai() *Result {
 	return &Result{
 		RyuuKyokuReason: RyuuKyokuKyuuShuKyuuHai,
@@ -495,7 +1043,7 @@ func (game *Game) judgeRon(pMain *Player, tileID Tile) Calls {
 	if pMain.IsFuriten() || !common.SliceContain(pMain.TenpaiSlice, tileID.Class()) {
 		return make(Calls, 0)
 	}
-	if game.Tiles.allTiles[tileID].isLast {
+	if game.GetNumRemainTiles() == 0 {
 		pMain.IsHoutei = true
 	}
 	result := game.getRonResult(pMain, tileID)
@@ -569,7 +1117,7 @@ func (game *Game) judgeTsumo(pMain *Player) Calls {
 }
 
 func (game *Game) judgeRiichi(pMain *Player) Calls {
-	if pMain.IsRiichi || (pMain.ShantenNum > 1 && pMain.JunNum > 1) || pMain.Points < 1000 || game.GetNumRemainTiles() < 4 {
+	if pMain.IsRiichi || (pMain.ShantenNum > 1 && pMain.JunNum > 1) || pMain.Points < game.Rule.RiichiDeposit || game.GetNumRemainTiles() < 4 {
 		return make(Calls, 0)
 	}
 	if len(pMain.HandTiles) != 14 {
@@ -594,7 +1142,8 @@ func (game *Game) judgeRiichi(pMain *Player) Calls {
 func (game *Game) judgeChi(pMain *Player, tileID Tile) Calls {
 	discardWind := game.Tiles.allTiles[tileID].discardWind
 	chiClass := tileID.Class()
-	if pMain.IsRiichi ||
+	if game.Rule.IsSanma ||
+		pMain.IsRiichi ||
 		(pMain.Wind-discardWind+4)%4 != 1 ||
 		chiClass >= 27 ||
 		game.Tiles.allTiles[tileID].isLast ||
@@ -666,46 +1215,7 @@ func (game *Game) judgeChi(pMain *Player, tileID Tile) Calls {
 		}
 	}
 	// 食替
-	if len(pMain.HandTiles) > 7 {
-		return posCalls
-	}
-	delIdxSlice := make([]int, 0, len(posCalls))
-	for i, call := range posCalls {
-		tile1ID := call.CallTiles[0]
-		tile2ID := call.CallTiles[1]
-		tile3ID := call.CallTiles[2]
-		handTilesCopy := pMain.HandTiles.Copy()
-		handTilesCopy.Remove(tile1ID)
-		handTilesCopy.Remove(tile2ID)
-		tileClass := tile3ID.Class()
-		tile1Class := tile1ID.Class()
-		tile2Class := tile2ID.Class()
-		posClass := make(TileClasses, 0, 4)
-		if tile1Class-tile2Class == 1 || tile2Class-tile1Class == 1 {
-			if !common.SliceContain(TileClasses{0, 9, 18, 8, 17, 26}, tile1Class) &&
-				!common.SliceContain(TileClasses{0, 9, 18, 8, 17, 26}, tile2Class) {
-				posClass = TileClasses{tile1Class - 1, tile1Class + 1, tile2Class - 1, tile2Class + 1}
-				posClass.Remove(tileClass)
-				posClass.Remove(tile1Class)
-				posClass.Remove(tile2Class)
-			}
-		}
-		posClass.Append(tileClass)
-		flag := true
-		for _, handTilesID := range handTilesCopy {
-			if !common.SliceContain(posClass, handTilesID.Class()) {
-				flag = false
-				break
-			}
-		}
-		if flag {
-			delIdxSlice = append(delIdxSlice, i)
-		}
-	}
-	if len(delIdxSlice) > 0 {
-		posCalls = common.RemoveIndex(posCalls, delIdxSlice...)
-	}
-	return posCalls
+	return game.filterKuikaeCalls(pMain, posCalls)
 }
 
 func (game *Game) judgePon(pMain *Player, tileID Tile) Calls {
@@ -759,12 +1269,13 @@ func (game *Game) judgePon(pMain *Player, tileID Tile) Calls {
 			posCalls.Append(posCall)
 		}
 	}
-	return posCalls
+	// 食替
+	return game.filterKuikaeCalls(pMain, posCalls)
 }
 
 func (game *Game) judgeDaiMinKan(pMain *Player, tileID Tile) Calls {
 	discardWind := game.Tiles.allTiles[tileID].discardWind
-	if pMain.IsRiichi || game.Tiles.allTiles[tileID].isLast || game.GetNumRemainTiles() == 0 {
+	if pMain.IsRiichi || game.Tiles.allTiles[tileID].isLast || game.GetNumRemainTiles() == 0 || game.Tiles.kanNum == 4 {
 		return make(Calls, 0)
 	}
 	kanClass := tileID.Class()
@@ -794,7 +1305,7 @@ func (game *Game) judgeDaiMinKan(pMain *Player, tileID Tile) Calls {
 }
 
 func (game *Game) judgeAnKan(pMain *Player) Calls {
-	if len(pMain.HandTiles) == 2 || game.GetNumRemainTiles() == 0 {
+	if len(pMain.HandTiles) == 2 || game.GetNumRemainTiles() == 0 || game.Tiles.kanNum == 4 {
 		return make(Calls, 0)
 	}
 	tilesClass := pMain.GetHandTilesClass()
@@ -841,7 +1352,7 @@ func (game *Game) judgeAnKan(pMain *Player) Calls {
 }
 
 func (game *Game) judgeShouMinKan(pMain *Player) Calls {
-	if len(pMain.Melds) == 0 || game.GetNumRemainTiles() == 0 {
+	if len(pMain.Melds) == 0 || game.GetNumRemainTiles() == 0 || game.Tiles.kanNum == 4 {
 		return make(Calls, 0)
 	}
 	var posCalls Calls
@@ -863,8 +1374,34 @@ func (game *Game) judgeShouMinKan(pMain *Player) Calls {
 	return posCalls
 }
 
+// judgeKita judge kita(north extraction) in sanma, a riichi player can only extract the north tile just drawn
+func (game *Game) judgeKita(pMain *Player) Calls {
+	if !game.Rule.IsSanma || game.GetNumRemainTiles() == 0 {
+		return make(Calls, 0)
+	}
+	var kitaTile = TileDummy
+	if lastTile := pMain.HandTiles[len(pMain.HandTiles)-1]; lastTile.Class() == Pei {
+		kitaTile = lastTile
+	} else if !pMain.IsRiichi {
+		for _, tileID := range pMain.HandTiles {
+			if tileID.Class() == Pei {
+				kitaTile = tileID
+				break
+			}
+		}
+	}
+	if kitaTile == TileDummy {
+		return make(Calls, 0)
+	}
+	return Calls{&Call{
+		CallType:         Kita,
+		CallTiles:        Tiles{kitaTile, TileDummy, TileDummy, TileDummy},
+		CallTilesFromWho: []Wind{pMain.Wind, WindDummy, WindDummy, WindDummy},
+	}}
+}
+
 func (game *Game) judgeKyuShuKyuHai(pMain *Player) Calls {
-	if pMain.JunNum > 1 || !pMain.RyuukyokuStatus {
+	if !game.Rule.IsKyuuShuKyuuHai || pMain.JunNum > 1 || !pMain.RyuukyokuStatus {
 		return make(Calls, 0)
 	}
 	kyuHai := make(map[TileClass]struct{})
@@ -901,9 +1438,9 @@ func (game *Game) getRonResult(pMain *Player, winTile Tile) (r *Result) {
 	ctx := &yaku.Context{
 		Tile:        IntToInstance(int(winTile)),
 		SelfWind:    base.Wind(pMain.Wind),
-		RoundWind:   base.Wind(game.WindRound / 4),
-		DoraTiles:   IntsToTiles(IndicatorsToDora(game.Tiles.DoraIndicators())),
-		UraTiles:    IntsToTiles(IndicatorsToDora(game.Tiles.UraDoraIndicators())),
+		RoundWind:   base.Wind((game.WindRound - WindRoundEast1) / 4),
+		DoraTiles:   IntsToTiles(game.indicatorsToDora(game.Tiles.DoraIndicators())),
+		UraTiles:    IntsToTiles(game.indicatorsToDora(game.Tiles.UraDoraIndicators())),
 		Rules:       game.Rule.YakuRule(),
 		IsTsumo:     pMain.IsTsumo,
 		IsRiichi:    pMain.IsRiichi,
@@ -918,44 +1455,248 @@ func (game *Game) getRonResult(pMain *Player, winTile Tile) (r *Result) {
 	if yakuResult == nil {
 		return nil
 	}
+	addNukiDora(yakuResult, ctx, len(pMain.KitaTiles))
 	scoreResult := score.GetScoreByResult(game.Rule.ScoreRule(), yakuResult, score.Honba(game.NumHonba))
 	return GenerateRonResult(yakuResult, &scoreResult)
 }
 
+// indicatorsToDora converts dora indicators to dora tiles, in sanma 1m indicates 9m
+func (game *Game) indicatorsToDora(indicators Tiles) Tiles {
+	doraTiles := IndicatorsToDora(indicators)
+	if game.Rule.IsSanma {
+		for i, indicator := range indicators {
+			if indicator.Class() == Man1 {
+				doraTiles[i] = indicator + 32
+			}
+		}
+	}
+	return doraTiles
+}
+
+// addNukiDora adds the han of the extracted north tiles, every north counts as one dora,
+// and once more for each dora(ura dora if riichi) indicating north
+func addNukiDora(yakuResult *yaku.Result, ctx *yaku.Context, numKita int) {
+	if numKita == 0 || len(yakuResult.Yakumans) > 0 {
+		return
+	}
+	han := 1
+	for _, t := range ctx.DoraTiles {
+		if t == tile.North {
+			han++
+		}
+	}
+	if ctx.IsRiichi && ctx.Rules.Ura() {
+		for _, t := range ctx.UraTiles {
+			if t == tile.North {
+				han++
+			}
+		}
+	}
+	if yakuResult.Bonuses == nil {
+		yakuResult.Bonuses = make(yaku.YakuSet)
+	}
+	yakuResult.Bonuses[yaku.Yaku(YakuNukiDora)] = yaku.HanPoints(han * numKita)
+}
+
+// getWaitValues returns the values of the tiles of the class not in the hand of the player, the player has 13 tiles,
+// a red five is valued apart from the other copies
+func (game *Game) getWaitValues(player *Player, tileClass TileClass) []*WaitValue {
+	isClosed := true
+	for _, meld := range player.Melds {
+		isClosed = isClosed && meld.CallType == AnKan
+	}
+	var uraProbs []float64
+	if isClosed {
+		uraProbs = game.getUraProbs(player, tileClass)
+	}
+	var waits []*WaitValue
+	values := make(map[bool]*WaitValue)
+	for _, tile := range tileClass.To4Tiles() {
+		if player.HandTiles.Index(tile, 0) != -1 {
+			continue
+		}
+		isAka := game.Rule.HasAkaDora && tile%4 == 0 && (tileClass == Man5 || tileClass == Pin5 || tileClass == Sou5)
+		value, ok := values[isAka]
+		if !ok {
+			value = &WaitValue{
+				Ron:   int(game.getWaitPoints(player, tile, false, nil)),
+				Tsumo: int(game.getWaitPoints(player, tile, true, nil)),
+			}
+			if isClosed {
+				value.RiichiRon = game.getWaitPoints(player, tile, false, uraProbs)
+				value.RiichiTsumo = game.getWaitPoints(player, tile, true, uraProbs)
+			}
+			values[isAka] = value
+		}
+		wait := *value
+		wait.Tile = tile
+		waits = append(waits, &wait)
+	}
+	return waits
+}
+
+// getWaitPoints returns the points of the win of the player on the tile, uraProbs nil for no riichi,
+// the points with riichi are expected over the probabilities of the han of the ura dora
+func (game *Game) getWaitPoints(player *Player, winTile Tile, isTsumo bool, uraProbs []float64) (points float64) {
+	isRiichi := uraProbs != nil
+	ctx := &yaku.Context{
+		Tile:      IntToInstance(int(winTile)),
+		SelfWind:  base.Wind(player.Wind),
+		RoundWind: base.Wind((game.WindRound - WindRoundEast1) / 4),
+		DoraTiles: IntsToTiles(game.indicatorsToDora(game.Tiles.DoraIndicators())),
+		Rules:     game.Rule.YakuRule(),
+		IsTsumo:   isTsumo,
+		IsRiichi:  isRiichi,
+		IsDaburi:  isRiichi && player.IsDaburuRiichi,
+	}
+	yakuResult := GetYakuResult(player.HandTiles, player.Melds, ctx)
+	if yakuResult == nil {
+		return 0
+	}
+	addNukiDora(yakuResult, ctx, len(player.KitaTiles))
+	if !isRiichi || len(yakuResult.Yakumans) > 0 {
+		return float64(game.getWinPoints(player.Wind, yakuResult, isTsumo))
+	}
+	for han, prob := range uraProbs {
+		if prob == 0 {
+			continue
+		}
+		uraResult := *yakuResult
+		uraResult.Bonuses = make(yaku.YakuSet, len(yakuResult.Bonuses)+1)
+		for y, v := range yakuResult.Bonuses {
+			uraResult.Bonuses[y] = v
+		}
+		if han > 0 {
+			uraResult.Bonuses[yaku.YakuUraDora] += yaku.HanPoints(han)
+		}
+		points += prob * float64(game.getWinPoints(player.Wind, &uraResult, isTsumo))
+	}
+	return points
+}
+
+// getWinPoints returns the points the winner gets from the others, with the honba and without the riichi deposits
+func (game *Game) getWinPoints(wind Wind, yakuResult *yaku.Result, isTsumo bool) int {
+	scoreResult := NewScoreResult(score.GetScoreByResult(game.Rule.ScoreRule(), yakuResult, score.Honba(game.NumHonba)))
+	if !isTsumo {
+		if wind == East {
+			return scoreResult.PayRonDealer
+		}
+		return scoreResult.PayRon
+	}
+	points := 0
+	for _, payment := range game.getTsumoPayments(wind, scoreResult) {
+		points += payment
+	}
+	return points
+}
+
+// getUraProbs returns the probabilities of the han of the ura dora of the win of the player on the tile class by
+// the han, every ura indicator is drawn independently from the tiles the player has not seen
+func (game *Game) getUraProbs(player *Player, tileClass TileClass) []float64 {
+	probs := []float64{1}
+	numIndicators := len(game.Tiles.DoraIndicators())
+	if !game.Rule.IsUra || numIndicators == 0 {
+		return probs
+	}
+	// the tiles of the winning hand by the class, every kita tile counts as a north
+	counts := make([]int, Chun+1)
+	for _, tile := range player.HandTiles {
+		counts[tile.Class()]++
+	}
+	for _, meld := range player.Melds {
+		for _, tile := range meld.CallTiles {
+			if tile != TileDummy {
+				counts[tile.Class()]++
+			}
+		}
+	}
+	counts[tileClass]++
+	counts[Pei] += len(player.KitaTiles)
+
+	remain := make([]int, Chun+1)
+	for class := range remain {
+		remain[class] = GetRemainTileClassNumFromPlayerPerspective(game, player, TileClass(class))
+	}
+	if game.Rule.IsSanma {
+		for _, class := range SanmaRemovedTileClasses {
+			remain[class] = 0
+		}
+	}
+	for _, p := range game.PosPlayer {
+		remain[Pei] -= len(p.KitaTiles)
+	}
+	remain[tileClass]--
+	total := 0
+	for class := range remain {
+		if remain[class] < 0 {
+			remain[class] = 0
+		}
+		total += remain[class]
+	}
+	if total == 0 {
+		return probs
+	}
+
+	// the han of one indicator, at most 4 tiles of the dora class
+	indicatorProbs := make([]float64, 5)
+	for class, num := range remain {
+		if num == 0 {
+			continue
+		}
+		dora := game.indicatorsToDora(Tiles{TileClass(class).To4Tiles()[1]})[0].Class()
+		indicatorProbs[counts[dora]] += float64(num) / float64(total)
+	}
+	for i := 0; i < numIndicators; i++ {
+		next := make([]float64, len(probs)+len(indicatorProbs)-1)
+		for han, prob := range probs {
+			for indicatorHan, indicatorProb := range indicatorProbs {
+				next[han+indicatorHan] += prob * indicatorProb
+			}
+		}
+		probs = next
+	}
+	return probs
+}
+
+// judgeSuuFonRenDa judge all four players discard the same wind tile in the first turn without any call
 func (game *Game) judgeSuuFonRenDa() bool {
-	var tileClass = game.PosPlayer[East].BoardTiles[len(game.PosPlayer[South].BoardTiles)-1].Class()
-	if tileClass != 27 && tileClass != 28 && tileClass != 29 && tileClass != 30 {
+	if !game.Rule.IsSuuFonRenda || len(game.PosPlayer) != 4 {
 		return false
 	}
+	var tileClass = TileClassDummy
 	for _, player := range game.PosPlayer {
-		if player.JunNum > 1 || player.JunNum == 0 {
+		if !player.RyuukyokuStatus || len(player.BoardTiles) != 1 {
 			return false
 		}
-		if !player.IppatsuStatus {
+		class := player.BoardTiles[0].Class()
+		if class < Ton || class > Pei {
 			return false
 		}
-		if player.BoardTiles[len(player.BoardTiles)-1].Class() != tileClass {
+		if tileClass == TileClassDummy {
+			tileClass = class
+		} else if class != tileClass {
 			return false
 		}
 	}
 	return true
 }
 
+// judgeSuuChaRiichi judge all four players declare riichi
 func (game *Game) judgeSuuChaRiichi() bool {
-	var riiChiNum int
+	if !game.Rule.IsSuuChaRiichi || len(game.PosPlayer) != 4 {
+		return false
+	}
 	for _, player := range game.PosPlayer {
-		if player.IsRiichi {
-			riiChiNum++
+		if !player.IsRiichi {
+			return false
 		}
 	}
-	if riiChiNum < 4 {
-		return false
-	}
 	return true
 }
 
+// judgeSuuKaiKan judge four kans are declared by more than one player
 func (game *Game) judgeSuuKaiKan() bool {
-	if game.Tiles.kanNum < 4 {
+	if !game.Rule.IsSuuKaiKan || game.Tiles.kanNum < 4 {
 		return false
 	}
 	for _, player := range game.PosPlayer {
@@ -971,7 +1712,7 @@ func (game *Game) GetNumRemainTiles() int {
 }
 
 func (game *Game) getOtherWinds() []Wind {
-	otherWinds := []Wind{0, 1, 2, 3}
+	otherWinds := game.getWinds()
 	for i, v := range otherWinds {
 		if v == game.Position {
 			otherWinds = append(otherWinds[:i], otherWinds[i+1:]...)
@@ -981,6 +1722,25 @@ func (game *Game) getOtherWinds() []Wind {
 	return otherWinds
 }
 
+// getWinds returns the winds of all seats in turn order
+func (game *Game) getWinds() []Wind {
+	winds := make([]Wind, 0, 4)
+	for i := 0; i < game.Rule.NumPlayers(); i++ {
+		winds = append(winds, Wind(i))
+	}
+	return winds
+}
+
+// getNextWind returns the wind of the next seat in turn order
+func (game *Game) getNextWind(wind Wind) Wind {
+	return (wind + 1) % Wind(game.Rule.NumPlayers())
+}
+
+// getPlayers returns the players in seat order of the first round
+func (game *Game) getPlayers() []*Player {
+	return []*Player{game.P0, game.P1, game.P2, game.P3}[:game.Rule.NumPlayers()]
+}
+
 func (game *Game) breakIppatsu() {
 	for wind, player := range game.PosPlayer {
 		if wind == game.Position {
@@ -1022,94 +1782,256 @@ func (game *Game) judgeNagashiMangan() []Wind {
 	return retSlice
 }
 
+// CheckGameEnd
+//
+//	@Description: check if the game ends after the round settlement
+//	@return bool: true for game end
 func (game *Game) CheckGameEnd() bool {
-	for _, player := range game.PosPlayer {
-		if player.Points < 0 {
-			// player is bankrupt
-			return true
+	return game.checkGameEnd(game.nextRound)
+}
+
+// checkGameEnd check if the game ends, nextRound for whether the dealer seat moves
+func (game *Game) checkGameEnd(nextRound bool) bool {
+	if game.Rule.IsTobi {
+		for _, player := range game.PosPlayer {
+			if player.Points < 0 || (game.Rule.IsTobiAtZero && player.Points == 0) {
+				// player is bankrupt
+				return true
+			}
 		}
 	}
-	if game.WindRound < WindRound(game.Rule.GameLength) {
+	nextWindRound := game.WindRound
+	if nextRound {
+		nextWindRound = game.getNextWindRound(game.WindRound)
+	}
+	lastWindRound := game.getLastWindRound()
+	if nextWindRound <= lastWindRound {
 		return false
-	} else if game.WindRound > WindRound(game.Rule.GameLength+4) || game.WindRound > WindRoundNorth4 {
-		return true
-	} else {
+	}
+	switch game.Rule.ExtensionPolicy {
+	case ExtensionSuddenDeath:
 		for _, player := range game.PosPlayer {
-			// the first player's points over 30000
-			if player.Points >= 30000 {
+			if player.Points >= game.Rule.TargetPoints {
 				return true
 			}
 		}
+		// extension lasts until the end of the next wind
+		return nextWindRound > game.getLastExtensionWindRound(lastWindRound)
+	case ExtensionFixedRounds:
+		if game.WindRound == lastWindRound {
+			// extension only if nobody reaches the target points after the all last
+			for _, player := range game.PosPlayer {
+				if player.Points >= game.Rule.TargetPoints {
+					return true
+				}
+			}
+		}
+		extensionWindRound := lastWindRound
+		for i := 0; i < game.Rule.ExtraRounds; i++ {
+			extensionWindRound = game.getNextWindRound(extensionWindRound)
+		}
+		return nextWindRound > extensionWindRound || nextWindRound > WindRoundNorth4
+	default:
+		return true
 	}
-	return false
 }
 
-func (game *Game) processNagashiMangan(winds []Wind) {
-	otherWinds := []Wind{0, 1, 2, 3}
-	for _, wind := range winds {
-		for i, v := range otherWinds {
-			if v == wind {
-				otherWinds = append(otherWinds[:i], otherWinds[i+1:]...)
-			}
+// judgeAgariYame judge the dealer who keeps the seat can stop the game,
+// it's the final round if the seat moves and the dealer is the top, a tie for the top counts as the top
+// under TieBreakSplit as the tied players share the first rank
+func (game *Game) judgeAgariYame() bool {
+	if game.Rule.AgariYamePolicy == AgariYameNone || game.nextRound || !game.checkGameEnd(true) {
+		return false
+	}
+	dealer := game.PosPlayer[East]
+	beforeDealer := true
+	for _, player := range game.getPlayers() {
+		if player == dealer {
+			beforeDealer = false
+			continue
+		}
+		// players in the earlier starting seats win the ties under TieBreakSeat
+		isTieLost := game.Rule.TieBreakPolicy == TieBreakSeat && beforeDealer
+		if player.Points > dealer.Points || (isTieLost && player.Points == dealer.Points) {
+			return false
 		}
 	}
-	if len(winds) == 1 {
-		// only one player nagashi mangan
-		wind := winds[0]
-		if wind == East {
-			game.PosPlayer[East].Points += 12000 + game.NumHonba*300 + game.NumRiichi*1000
-			for _, w := range otherWinds {
-				game.PosPlayer[w].Points -= 4000 + game.NumHonba*100
-			}
-		} else {
-			game.PosPlayer[wind].Points += 8000 + game.NumHonba*300 + game.NumRiichi*1000
-			for _, w := range otherWinds {
-				if w == East {
-					game.PosPlayer[w].Points -= 4000 + game.NumHonba*100
-				} else {
-					game.PosPlayer[w].Points -= 2000 + game.NumHonba*100
-				}
-			}
+	return true
+}
+
+// processAgariYame the dealer stops the game
+func (game *Game) processAgariYame(isTenpai bool) {
+	var posEvent = make(map[Wind]Event)
+	for wind := range game.PosPlayer {
+		posEvent[wind] = &EventAgariYame{
+			Who:      East,
+			IsTenpai: isTenpai,
 		}
-	} else if len(winds) == 2 {
-		// two players nagashi mangan
-		wind0 := winds[0]
-		wind1 := winds[1]
-		if wind0 == 0 {
-			game.PosPlayer[wind0].Points += 12000 - 4000 + game.NumHonba*300 + game.NumRiichi*1000
-			game.PosPlayer[wind1].Points += 8000 - 4000 + game.NumHonba*300
-			for _, w := range otherWinds {
-				game.PosPlayer[w].Points -= 4000 + 2000 + game.NumHonba*300
-			}
-		} else {
-			game.PosPlayer[wind0].Points += 8000 - 2000 + game.NumHonba*300 + game.NumRiichi*1000
-			game.PosPlayer[wind1].Points += 8000 - 2000 + game.NumHonba*300
-			for _, w := range otherWinds {
-				if w == East {
-					game.PosPlayer[w].Points -= 4000 + 4000 + game.NumHonba*300
-				} else {
-					game.PosPlayer[w].Points -= 2000 + 2000 + game.NumHonba*300
-				}
-			}
+	}
+	game.addPosEvent(posEvent)
+}
+
+// judgeChombo returns the first player whose call is well-formed but not valid in chombo mode, nil call for none
+func (game *Game) judgeChombo(posCall map[Wind]*Call) (Wind, *Call) {
+	if !game.Rule.IsChombo {
+		return WindDummy, nil
+	}
+	for _, wind := range common.SortMapByKey(posCall) {
+		call := posCall[wind]
+		calls, ok := game.posCalls[wind]
+		if !ok || calls.indexExact(call) != -1 || !game.judgeWellFormed(game.PosPlayer[wind], call) {
+			continue
 		}
-	} else {
-		// three players nagashi mangan
-		wind0 := winds[0]
-		wind1 := winds[1]
-		wind2 := winds[2]
-		otherWind := otherWinds[0]
-		if wind0 == 0 {
-			game.PosPlayer[wind0].Points += 12000 - 4000 - 4000 + game.NumHonba*300 + game.NumRiichi*1000
-			game.PosPlayer[wind1].Points += 8000 - 4000 - 2000 + game.NumHonba*300
-			game.PosPlayer[wind2].Points += 8000 - 4000 - 2000 + game.NumHonba*300
-			game.PosPlayer[otherWind].Points -= 4000 + 2000 + 2000 + game.NumHonba*900
-		} else {
-			game.PosPlayer[wind0].Points += 8000 - 2000 - 2000 + game.NumHonba*300 + game.NumRiichi*1000
-			game.PosPlayer[wind1].Points += 8000 - 2000 - 2000 + game.NumHonba*300
-			game.PosPlayer[wind2].Points += 8000 - 2000 - 2000 + game.NumHonba*300
-			game.PosPlayer[otherWind].Points -= 4000 + 4000 + 4000 + game.NumHonba*900
+		return wind, call
+	}
+	return WindDummy, nil
+}
+
+// judgeMalformed returns the first player whose call is neither valid nor well-formed in chombo mode, nil call for none,
+// such a call can not be penalized, e.g. the discard of a tile not in the hand
+func (game *Game) judgeMalformed(posCall map[Wind]*Call) (Wind, *Call) {
+	if !game.Rule.IsChombo {
+		return WindDummy, nil
+	}
+	for _, wind := range common.SortMapByKey(posCall) {
+		call := posCall[wind]
+		calls, ok := game.posCalls[wind]
+		if !ok || calls.indexExact(call) != -1 || game.judgeWellFormed(game.PosPlayer[wind], call) {
+			continue
+		}
+		return wind, call
+	}
+	return WindDummy, nil
+}
+
+// judgeWellFormed judges the call is an action the player can declare, with the tiles from the player's hand
+func (game *Game) judgeWellFormed(pMain *Player, call *Call) bool {
+	if pMain == nil || call == nil || len(call.CallTiles) != 4 || len(call.CallTilesFromWho) != 4 {
+		return false
+	}
+	switch call.CallType {
+	case Discard, Chi, Pon, DaiMinKan, ShouMinKan, AnKan, Riichi, Ron, Tsumo, KyuuShuKyuuHai, ChanKan, Kita:
+	default:
+		return false
+	}
+	for i, tile := range call.CallTiles {
+		if tile == TileDummy || call.CallTilesFromWho[i] != pMain.Wind {
+			continue
+		}
+		if pMain.HandTiles.Index(tile, 0) == -1 {
+			return false
+		}
+	}
+	return true
+}
+
+// processChombo the offender pays the penalty, the riichi deposits of the hand are returned
+func (game *Game) processChombo(wind Wind) map[Wind]int {
+	var pointsChange = make(map[Wind]int)
+	switch game.Rule.ChomboPolicy {
+	case ChomboMangan:
+		manganResult := NewScoreResult(score.GetScore(game.Rule.ScoreRule(), 5, 30, 0))
+		for w, payment := range game.getTsumoPayments(wind, manganResult) {
+			pointsChange[w] += payment
+			pointsChange[wind] -= payment
+		}
+	case ChomboFixed:
+		pointsChange[wind] -= game.Rule.ChomboPoints
+	}
+	for w, points := range pointsChange {
+		game.PosPlayer[w].Points += points
+	}
+	for _, player := range game.PosPlayer {
+		if player.IsRiichi && player.RiichiStep == 2 {
+			player.Points += game.Rule.RiichiDeposit
+			game.NumRiichi--
+		}
+	}
+	return pointsChange
+}
+
+// processGameEnd settles the whole game, the players are keyed by their starting seats
+func (game *Game) processGameEnd() {
+	var points = make(map[Wind]int)
+	for i, player := range game.getPlayers() {
+		points[Wind(i)] = player.Points
+	}
+	finalResult, err := CalculateFinalResult(game.Rule, points, game.NumRiichi)
+	if err != nil {
+		// the rule is validated when the game is reset
+		panic(err)
+	}
+	game.finalResult = finalResult
+
+	var posEvent = make(map[Wind]Event)
+	for wind := range game.PosPlayer {
+		posEvent[wind] = &EventGameEnd{
+			Result: game.finalResult,
 		}
 	}
+	game.addPosEvent(posEvent)
+}
+
+// GetFinalResult
+//
+//	@Description: get the final settlement of the game
+//	@return *FinalResult: nil if the game is not end
+func (game *Game) GetFinalResult() *FinalResult {
+	return game.finalResult
+}
+
+// getLastExtensionWindRound returns the last wind round of sudden death, the last round of the next wind
+func (game *Game) getLastExtensionWindRound(lastWindRound WindRound) WindRound {
+	extensionWindRound := ((lastWindRound-WindRoundEast1)/4 + 2) * 4
+	if game.Rule.IsSanma {
+		extensionWindRound--
+	}
+	if extensionWindRound > WindRoundNorth4 {
+		extensionWindRound = WindRoundNorth4
+	}
+	return extensionWindRound
+}
+
+// processNagashiMangan settles every nagashi mangan player as a tsumo of Rule.NagashiManganLimit, the first one gets riichi sticks
+func (game *Game) processNagashiMangan(winds []Wind) {
+	nagashiResult := NewScoreResult(game.getLimitScore(game.Rule.NagashiManganLimit))
+	for i, wind := range winds {
+		for w, payment := range game.getTsumoPayments(wind, nagashiResult) {
+			game.PosPlayer[w].Points -= payment
+			game.PosPlayer[wind].Points += payment
+		}
+		if i == 0 {
+			game.PosPlayer[wind].Points += game.NumRiichi * game.Rule.RiichiDeposit
+		}
+	}
+	game.NumRiichi = 0 // Clear Riichi Sticks
+}
+
+// getLimitScore returns the score of a hand of the limit with the current honba, LimitNone is counted as mangan
+func (game *Game) getLimitScore(limit Limit) score.Score {
+	scoreRule := game.Rule.ScoreRule()
+	honba := score.Honba(game.NumHonba)
+	switch limit {
+	case LimitHaneman:
+		return score.GetScore(scoreRule, 6, 30, honba)
+	case LimitBaiman:
+		return score.GetScore(scoreRule, 8, 30, honba)
+	case LimitSanbaiman:
+		return score.GetScore(scoreRule, 11, 30, honba)
+	case LimitYakuman:
+		return score.GetYakumanScore(scoreRule, 1, honba)
+	default:
+		return score.GetScore(scoreRule, 5, 30, honba)
+	}
+}
+
+// getRyuuKyokuResults returns the same ryuu kyoku result for all players
+func (game *Game) getRyuuKyokuResults(reason RyuuKyokuReason) map[Wind]*Result {
+	var results = make(map[Wind]*Result)
+	for wind := range game.PosPlayer {
+		results[wind] = &Result{RyuuKyokuReason: reason}
+	}
+	return results
 }
 
 // judgeTenpaiWinds returns the winds of players who have ten hai in the ryuukyoku situation.
@@ -1126,86 +2048,79 @@ func (game *Game) judgeTenpaiWinds() []Wind {
 	return retSlice
 }
 
+// processNormalRyuuKyoku the noten players pay the tenpai players, Rule.NotenPenalty points in total
 func (game *Game) processNormalRyuuKyoku(winds []Wind) {
-	if len(winds) == 0 {
-		// no player Tenpai
+	numPlayers := game.Rule.NumPlayers()
+	if len(winds) == 0 || len(winds) == numPlayers {
+		// no player or all players Tenpai
 		return
 	}
-	otherWinds := []Wind{0, 1, 2, 3}
-	for _, wind := range winds {
-		for i, v := range otherWinds {
-			if v == wind {
-				otherWinds = append(otherWinds[:i], otherWinds[i+1:]...)
-			}
-		}
-	}
-	if len(winds) == 1 {
-		// one player Tenpai
-		wind := winds[0]
-		game.PosPlayer[wind].Points += 3000
-		for _, w := range otherWinds {
-			game.PosPlayer[w].Points -= 1000
-		}
-	} else if len(winds) == 2 {
-		// two players Tenpai
-		wind0 := winds[0]
-		wind1 := winds[1]
-		game.PosPlayer[wind0].Points += 1500
-		game.PosPlayer[wind1].Points += 1500
-		for _, w := range otherWinds {
-			game.PosPlayer[w].Points -= 1500
+	notenPoints := game.Rule.NotenPenalty
+	for _, wind := range game.getWinds() {
+		if common.SliceContain(winds, wind) {
+			game.PosPlayer[wind].Points += notenPoints / len(winds)
+		} else {
+			game.PosPlayer[wind].Points -= notenPoints / (numPlayers - len(winds))
 		}
-	} else {
-		// three players Tenpai
-		wind0 := winds[0]
-		wind1 := winds[1]
-		wind2 := winds[2]
-		otherWind := otherWinds[0]
-		game.PosPlayer[wind0].Points += 1000
-		game.PosPlayer[wind1].Points += 1000
-		game.PosPlayer[wind2].Points += 1000
-		game.PosPlayer[otherWind].Points -= 3000
 	}
 }
 
-// processRonResult processes the result of ron.
+// processRonResult processes the result of ron, the winner nearest to the discarder gets the riichi sticks and the honba.
 func (game *Game) processRonResult(results map[Wind]*Result) {
 	var totalPoints = 0
-	// sort results
-	var winds []Wind
-	for wind := range results {
-		winds = append(winds, wind)
-	}
-	sort.Slice(winds, func(i, j int) bool {
-		return winds[i] < winds[j]
-	})
-	for i, wind := range winds {
+	for i, wind := range game.sortWindsFromDiscarder(results) {
 		result := results[wind]
 		var pointsChange ScoreChanges
+		var honbaPoints = 0
 		if i == 0 {
 			// the first player get riichi bonus
-			pointsChange = result.ScoreResult.GetChanges(wind, game.Position, game.NumRiichi)
+			pointsChange = result.ScoreResult.GetChanges(wind, game.Position, 0)
+			game.PosPlayer[wind].Points += game.NumRiichi * game.Rule.RiichiDeposit
+			honbaPoints = 3 * game.NumHonba * game.Rule.HonbaValue
 		} else {
+			// copy the score result, it may be shared by the other winners
+			scoreResult := *result.ScoreResult
+			scoreResult.removeHonba(game.NumHonba, game.Rule.HonbaValue)
+			result.ScoreResult = &scoreResult
 			pointsChange = result.ScoreResult.GetChanges(wind, game.Position, 0)
 		}
 		game.PosPlayer[wind].Points += pointsChange.TotalWin()
-		totalPoints += pointsChange.TotalPayed()
+		payed := pointsChange.TotalPayed()
+		if paoWind := game.getPaoWind(wind, result); paoWind != WindDummy && paoWind != game.Position {
+			// the pao player pays half of the ron without honba
+			paoPay := (payed - honbaPoints) / 2
+			game.PosPlayer[paoWind].Points -= paoPay
+			payed -= paoPay
+		}
+		totalPoints += payed
 	}
 	game.PosPlayer[game.Position].Points -= totalPoints
 	game.NumRiichi = 0 // Clear Riichi Sticks
 }
 
-func (game *Game) addRonEvents(results map[Wind]*Result) {
-	if len(results) > 1 {
-		fmt.Println(1)
+// sortWindsFromDiscarder returns the winds of results in turn order from the discarder
+func (game *Game) sortWindsFromDiscarder(results map[Wind]*Result) []Wind {
+	var winds []Wind
+	for wind := range results {
+		winds = append(winds, wind)
 	}
+	numPlayers := Wind(game.Rule.NumPlayers())
+	sort.Slice(winds, func(i, j int) bool {
+		return (winds[i]-game.Position+numPlayers)%numPlayers < (winds[j]-game.Position+numPlayers)%numPlayers
+	})
+	return winds
+}
+
+func (game *Game) addRonEvents(results map[Wind]*Result) {
 	var posEvent = make(map[Wind]Event)
-	for wind, result := range results {
+	for _, wind := range game.sortWindsFromDiscarder(results) {
+		result := results[wind]
 		if result.RonCall.CallType == Ron {
 			for w := range game.PosPlayer {
 				posEvent[w] = &EventRon{
 					Who:       wind,
 					FromWho:   result.RonCall.CallTilesFromWho[0],
+					PaoWho:    game.getPaoWind(wind, result),
 					HandTiles: game.PosPlayer[wind].HandTiles,
 					WinTile:   result.RonCall.CallTiles[0],
 					Result:    result,
@@ -1217,6 +2132,7 @@ func (game *Game) addRonEvents(results map[Wind]*Result) {
 				posEvent[w] = &EventChanKan{
 					Who:       wind,
 					FromWho:   result.RonCall.CallTilesFromWho[0],
+					PaoWho:    game.getPaoWind(wind, result),
 					HandTiles: game.PosPlayer[wind].HandTiles,
 					WinTile:   result.RonCall.CallTiles[0],
 					Result:    result,
@@ -1229,29 +2145,48 @@ func (game *Game) addRonEvents(results map[Wind]*Result) {
 }
 
 func (game *Game) processTsumoResult(wind Wind, result *Result) {
-	otherWinds := []Wind{0, 1, 2, 3}
-	for i, v := range otherWinds {
-		if v == wind {
-			otherWinds = append(otherWinds[:i], otherWinds[i+1:]...)
+	payments := game.getTsumoPayments(wind, result.ScoreResult)
+	if paoWind := game.getPaoWind(wind, result); paoWind != WindDummy {
+		// the pao player pays all
+		var total = 0
+		for _, payment := range payments {
+			total += payment
+		}
+		payments = map[Wind]int{paoWind: total}
+	}
+	for w, payment := range payments {
+		game.PosPlayer[w].Points -= payment
+		game.PosPlayer[wind].Points += payment
+	}
+	game.PosPlayer[wind].Points += game.NumRiichi * game.Rule.RiichiDeposit
+	game.NumRiichi = 0 // Clear Riichi Sticks
+}
+
+// getTsumoPayments returns the points every other player pays for the tsumo of wind,
+// in sanma without tsumo loss the share of the absent player is split between the payers
+func (game *Game) getTsumoPayments(wind Wind, scoreResult *ScoreResult) map[Wind]int {
+	var payments = make(map[Wind]int)
+	for _, w := range game.getWinds() {
+		if w == wind {
+			continue
+		}
+		if wind == East || w == East {
+			payments[w] = scoreResult.PayTsumoDealer
+		} else {
+			payments[w] = scoreResult.PayTsumo
 		}
 	}
-	pointsChange := result.ScoreResult.GetChanges(wind, wind, game.NumRiichi)
-	game.PosPlayer[wind].Points += int(pointsChange.TotalWin())
-	if wind == East {
-		// dealer tsumo
-		for _, w := range otherWinds {
-			game.PosPlayer[w].Points -= int(result.ScoreResult.PayTsumoDealer)
+	if game.Rule.IsSanma && !game.Rule.IsSanmaTsumoLoss {
+		absentPay := scoreResult.PayTsumo
+		if wind == East {
+			absentPay = scoreResult.PayTsumoDealer
 		}
-	} else {
-		for _, w := range otherWinds {
-			if w == East {
-				game.PosPlayer[w].Points -= int(result.ScoreResult.PayTsumoDealer)
-			} else {
-				game.PosPlayer[w].Points -= int(result.ScoreResult.PayTsumo)
-			}
+		absentPay -= game.NumHonba * game.Rule.HonbaValue
+		for w := range payments {
+			payments[w] += (absentPay/2 + 99) / 100 * 100
 		}
 	}
-	game.NumRiichi = 0 // Clear Riichi Sticks
+	return payments
 }
 
 func (game *Game) GetGlobalEvents() Events {
@@ -1267,90 +2202,73 @@ func (game *Game) GetGlobalEvents() Events {
 		Rule:      game.Rule,
 	})
 	// add all players event
-	windIndex := map[Wind]int{
-		East:  0,
-		South: 0,
-		West:  0,
-		North: 0,
-	}
-	winds := []Wind{East, South, West, North}
-	esEast := game.posEvents[East]
-	esSouth := game.posEvents[South]
-	esWest := game.posEvents[West]
-	esNorth := game.posEvents[North]
-
-	for windIndex[East] < len(esEast) &&
-		windIndex[South] < len(esSouth) &&
-		windIndex[West] < len(esWest) &&
-		windIndex[North] < len(esNorth) {
-
-		eastIndex := windIndex[East]
-		southIndex := windIndex[South]
-		westIndex := windIndex[West]
-		northIndex := windIndex[North]
-
-		eastEvent := esEast[eastIndex]
-		southEvent := esSouth[southIndex]
-		westEvent := esWest[westIndex]
-		northEvent := esNorth[northIndex]
+	winds := game.getWinds()
+	windIndex := make(map[Wind]int, len(winds))
+	for {
+		var seatEvents = make([]Event, 0, len(winds))
+		for _, wind := range winds {
+			if windIndex[wind] >= len(game.posEvents[wind]) {
+				break
+			}
+			seatEvents = append(seatEvents, game.posEvents[wind][windIndex[wind]])
+		}
+		if len(seatEvents) < len(winds) {
+			break
+		}
 
+		// at most one seat receives an event that the others don't
+		majorType := seatEvents[0].GetType()
+		if majorType != seatEvents[1].GetType() {
+			majorType = seatEvents[2].GetType()
+		}
 		var uniqueWind = WindDummy
-
-		if eastEvent.GetType() == southEvent.GetType() {
-			if eastEvent.GetType() == westEvent.GetType() {
-				if eastEvent.GetType() != northEvent.GetType() {
-					uniqueWind = North
-				}
-			} else {
-				uniqueWind = West
-			}
-		} else {
-			if eastEvent.GetType() == westEvent.GetType() {
-				uniqueWind = South
-			} else {
-				uniqueWind = East
+		for i, e := range seatEvents {
+			if e.GetType() != majorType {
+				uniqueWind = winds[i]
+				break
 			}
 		}
 
-		if esEast[eastIndex].GetType() == EventTypeStart {
-			windIndex[East]++
-			windIndex[South]++
-			windIndex[West]++
-			windIndex[North]++
+		if seatEvents[East].GetType() == EventTypeStart {
+			for _, wind := range winds {
+				windIndex[wind]++
+			}
 			continue
 		}
 
 		if uniqueWind != WindDummy {
 			windIndex[uniqueWind]++
 		} else {
-			if eastEvent.GetType() == EventTypeGet {
-				for _, e := range []Event{eastEvent, southEvent, westEvent, northEvent} {
+			switch majorType {
+			case EventTypeGet:
+				for _, e := range seatEvents {
 					if e.(*EventGet).Tile != TileDummy {
 						events = append(events, e)
 						break
 					}
 				}
-			} else {
-				events = append(events, eastEvent)
+			case EventTypeChi:
+				// the caller's event with the tenpai infos
+				events = append(events, seatEvents[seatEvents[East].(*EventChi).Who])
+			case EventTypePon:
+				events = append(events, seatEvents[seatEvents[East].(*EventPon).Who])
+			default:
+				events = append(events, seatEvents[East])
+			}
+			for _, wind := range winds {
+				windIndex[wind]++
 			}
-			windIndex[East]++
-			windIndex[South]++
-			windIndex[West]++
-			windIndex[North]++
 		}
 	}
 
+	// points and riichi sticks at the start of the round, the sticks of the game may be taken or added since
+	start := game.posEvents[East][0].(*EventStart)
 	var initPoints = make(map[Wind]int)
-	if events[len(events)-1].GetType() == EventTypeEnd {
-		for _, wind := range winds {
-			initPoints[wind] = game.PosPlayer[wind].Points - events[len(events)-1].(*EventEnd).PointsChange[wind]
-		}
-	} else {
-		for _, wind := range winds {
-			initPoints[wind] = game.PosPlayer[wind].Points
-		}
+	for wind, points := range start.PlayersPoints {
+		initPoints[wind] = points
 	}
 	events[0].(*EventGlobalInit).InitPoints = initPoints
+	events[0].(*EventGlobalInit).NumRiichi = start.NumRiichi
 	return events
 }
 
@@ -0,0 +1,638 @@
+package mahjong
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// game modes of the Mahjong Soul record header
+const (
+	majsoulModeEast       = 1  // 4 players, east rounds
+	majsoulModeSouth      = 2  // 4 players, east and south rounds
+	majsoulModeSanmaEast  = 11 // 3 players, east rounds
+	majsoulModeSanmaSouth = 12 // 3 players, east and south rounds
+)
+
+// majsoulRyuuKyokuReasons reasons of the abortive draws of RecordLiuJu by its type
+var majsoulRyuuKyokuReasons = map[int]RyuuKyokuReason{
+	1: RyuuKyokuKyuuShuKyuuHai,
+	2: RyuuKyokuSuufonRenda,
+	3: RyuuKyokuSuuKaiKan,
+	4: RyuuKyokuSuuChaRiichi,
+	5: RyuuKyokuSanChaHou,
+}
+
+// MajsoulRecord a game imported from Mahjong Soul
+type MajsoulRecord struct {
+	UUID   string
+	Rule   *Rule
+	Names  []string // names of the players by the seat of Mahjong Soul, seat 0 is the first dealer
+	Rounds []Events // global events of every round, each starts with an EventGlobalInit
+}
+
+// majsoulFile the game record exported as json, the head and the records in order
+type majsoulFile struct {
+	Head    majsoulHead     `json:"head"`
+	Records []majsoulRecord `json:"records"`
+}
+
+type majsoulHead struct {
+	UUID   string `json:"uuid"`
+	Config struct {
+		Mode struct {
+			Mode       int                `json:"mode"`
+			DetailRule *majsoulDetailRule `json:"detail_rule"`
+		} `json:"mode"`
+	} `json:"config"`
+	Accounts []struct {
+		Seat     int    `json:"seat"`
+		Nickname string `json:"nickname"`
+	} `json:"accounts"`
+}
+
+// majsoulDetailRule the rule details of the header, zero values are omitted by the export
+type majsoulDetailRule struct {
+	DoraCount   int   `json:"dora_count"`
+	Shiduan     int   `json:"shiduan"` // 1 for open tanyao
+	InitPoint   int   `json:"init_point"`
+	Fandian     int   `json:"fandian"` // target points
+	HaveZimosun *bool `json:"have_zimosun"`
+}
+
+// majsoulRecord a record of the game, name is the message type like .lq.RecordNewRound
+type majsoulRecord struct {
+	Name string          `json:"name"`
+	Data json.RawMessage `json:"data"`
+}
+
+type majsoulNewRound struct {
+	Chang    int      `json:"chang"`
+	Ju       int      `json:"ju"`
+	Ben      int      `json:"ben"`
+	Liqibang int      `json:"liqibang"`
+	Scores   []int    `json:"scores"`
+	Dora     string   `json:"dora"`
+	Doras    []string `json:"doras"`
+	Tiles0   []string `json:"tiles0"`
+	Tiles1   []string `json:"tiles1"`
+	Tiles2   []string `json:"tiles2"`
+	Tiles3   []string `json:"tiles3"`
+}
+
+type majsoulDealTile struct {
+	Seat  int      `json:"seat"`
+	Tile  string   `json:"tile"`
+	Doras []string `json:"doras"`
+}
+
+type majsoulDiscardTile struct {
+	Seat    int      `json:"seat"`
+	Tile    string   `json:"tile"`
+	IsLiqi  bool     `json:"is_liqi"`
+	IsWliqi bool     `json:"is_wliqi"`
+	Moqie   bool     `json:"moqie"`
+	Doras   []string `json:"doras"`
+}
+
+type majsoulChiPengGang struct {
+	Seat  int      `json:"seat"`
+	Type  int      `json:"type"` // 0 for chi, 1 for pon, 2 for daiminkan
+	Tiles []string `json:"tiles"`
+	Froms []int    `json:"froms"`
+}
+
+type majsoulAnGangAddGang struct {
+	Seat  int      `json:"seat"`
+	Type  int      `json:"type"` // 2 for shouminkan, 3 for ankan
+	Tiles string   `json:"tiles"`
+	Doras []string `json:"doras"`
+}
+
+type majsoulBaBei struct {
+	Seat  int      `json:"seat"`
+	Doras []string `json:"doras"`
+}
+
+type majsoulHule struct {
+	Hules []struct {
+		Seat    int      `json:"seat"`
+		Zimo    bool     `json:"zimo"`
+		LiDoras []string `json:"li_doras"`
+	} `json:"hules"`
+	DeltaScores []int `json:"delta_scores"`
+}
+
+type majsoulNoTile struct {
+	Liujumanguan bool `json:"liujumanguan"`
+	Scores       []struct {
+		DeltaScores []int `json:"delta_scores"`
+	} `json:"scores"`
+}
+
+type majsoulLiuJu struct {
+	Type int `json:"type"`
+}
+
+// ImportMajsoul
+//
+//	@Description: import a game record of Mahjong Soul exported as json, a head and the records like
+//	{"head": {...}, "records": [{"name": ".lq.RecordNewRound", "data": {...}}, ...]}, every round can be rebuilt by
+//	ReConstructGame, tiles of the same kind get the ids in order and the wall positions never revealed are filled
+//	with the unseen tiles
+//	@param data: the json record
+//	@return *MajsoulRecord
+//	@return error
+func ImportMajsoul(data []byte) (*MajsoulRecord, error) {
+	var file majsoulFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	rule, err := file.Head.rule()
+	if err != nil {
+		return nil, err
+	}
+	result := &MajsoulRecord{UUID: file.Head.UUID, Rule: rule, Names: make([]string, rule.NumPlayers())}
+	for _, account := range file.Head.Accounts {
+		if account.Seat < 0 || account.Seat >= rule.NumPlayers() {
+			return nil, fmt.Errorf("no seat %d", account.Seat)
+		}
+		result.Names[account.Seat] = account.Nickname
+	}
+
+	var round *majsoulRound
+	for i, record := range file.Records {
+		name := strings.TrimPrefix(record.Name, ".lq.")
+		if name == "RecordNewRound" {
+			if round != nil {
+				result.Rounds = append(result.Rounds, round.finish())
+			}
+			round, err = newMajsoulRound(rule, len(result.Rounds), record.Data)
+		} else if round == nil {
+			err = errors.New("no RecordNewRound before")
+		} else {
+			err = round.record(name, record.Data)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("majsoul record %d %s: %w", i, name, err)
+		}
+	}
+	if round != nil {
+		result.Rounds = append(result.Rounds, round.finish())
+	}
+	return result, nil
+}
+
+// rule returns the rule of the game mode, the details override the rule of the ranked lobby
+func (h *majsoulHead) rule() (*Rule, error) {
+	mode := h.Config.Mode
+	rule := GetDefaultRule()
+	switch mode.Mode {
+	case majsoulModeEast, majsoulModeSouth:
+		rule.Uma = []int{15000, 5000, -5000, -15000}
+	case majsoulModeSanmaEast, majsoulModeSanmaSouth:
+		rule = GetDefaultSanmaRule()
+		rule.IsSanmaTsumoLoss = false
+	default:
+		return nil, fmt.Errorf("game mode %d not supported", mode.Mode)
+	}
+	if mode.Mode == majsoulModeEast || mode.Mode == majsoulModeSanmaEast {
+		rule.GameLength = 4
+	}
+	if detail := mode.DetailRule; detail != nil {
+		rule.HasAkaDora = detail.DoraCount > 0
+		rule.IsOpenTanyao = detail.Shiduan == 1
+		if detail.InitPoint > 0 {
+			rule.StartingPoints = detail.InitPoint
+		}
+		if detail.Fandian > 0 {
+			rule.TargetPoints = detail.Fandian
+		}
+		if detail.HaveZimosun != nil && rule.IsSanma {
+			rule.IsSanmaTsumoLoss = *detail.HaveZimosun
+		}
+	}
+	return rule, nil
+}
+
+// majsoulCode returns the tenhou.net/6 code of a tile of Mahjong Soul like 1m, 0p for the red five and 7z for chun
+func majsoulCode(s string) (int, error) {
+	if len(s) != 2 || s[0] < '0' || s[0] > '9' {
+		return 0, fmt.Errorf("invalid tile %q", s)
+	}
+	n := int(s[0] - '0')
+	suit := strings.IndexByte("mpsz", s[1]) + 1
+	switch {
+	case suit == 0 || (suit == 4 && (n == 0 || n > 7)):
+		return 0, fmt.Errorf("invalid tile %q", s)
+	case n == 0:
+		return 50 + suit, nil
+	}
+	return 10*suit + n, nil
+}
+
+// majsoulCodes returns the tenhou.net/6 codes of the tiles of Mahjong Soul
+func majsoulCodes(tiles []string) ([]int, error) {
+	codes := make([]int, 0, len(tiles))
+	for _, s := range tiles {
+		code, err := majsoulCode(s)
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+// majsoulRound replays the records of a round, tiles of the same code get the ids in order as they appear
+type majsoulRound struct {
+	*tenhou6Round
+	lastFrom Wind // the player of the last tile discarded, added to a kan or pulled for kita
+}
+
+// newMajsoulRound starts a round by RecordNewRound, the 14th tile of the dealer is the first draw
+func newMajsoulRound(rule *Rule, numGame int, data []byte) (*majsoulRound, error) {
+	var record majsoulNewRound
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, err
+	}
+	numPlayers := rule.NumPlayers()
+	if len(record.Scores) < numPlayers {
+		return nil, fmt.Errorf("scores %v must have %d values", record.Scores, numPlayers)
+	}
+	if record.Ju < 0 || record.Ju >= numPlayers {
+		return nil, fmt.Errorf("no dealer %d", record.Ju)
+	}
+	doras := record.Doras
+	if len(doras) == 0 {
+		doras = []string{record.Dora}
+	}
+	doraCodes, err := majsoulCodes(doras[:1])
+	if err != nil {
+		return nil, err
+	}
+
+	r := &majsoulRound{
+		tenhou6Round: &tenhou6Round{
+			tenhouRound: &tenhouRound{rule: rule},
+			used:        make(map[Tile]bool),
+			hands:       make(map[Wind]Tiles),
+			lastTile:    TileDummy,
+			riichiBy:    WindDummy,
+		},
+		lastFrom: WindDummy,
+	}
+	var hands []Tiles
+	var firstDraw Tile
+	for seat, tiles := range [][]string{record.Tiles0, record.Tiles1, record.Tiles2, record.Tiles3}[:numPlayers] {
+		n := 13
+		if seat == record.Ju {
+			n = 14
+		}
+		if len(tiles) != n {
+			return nil, fmt.Errorf("seat %d is dealt %d tiles", seat, len(tiles))
+		}
+		codes, err := majsoulCodes(tiles)
+		if err != nil {
+			return nil, err
+		}
+		hand, err := r.alloc(codes...)
+		if err != nil {
+			return nil, err
+		}
+		if seat == record.Ju {
+			firstDraw, hand = hand[13], hand[:13]
+		}
+		hands = append(hands, hand)
+	}
+	doraIndicator, err := r.alloc(doraCodes...)
+	if err != nil {
+		return nil, err
+	}
+	used := r.used
+	round := 4*record.Chang + record.Ju
+	if r.tenhouRound, err = newTenhouRound(rule, numGame, round, record.Ben, record.Liqibang, doraIndicator[0], record.Ju, record.Scores, hands); err != nil {
+		return nil, err
+	}
+	r.used = used
+	for seat, hand := range hands {
+		r.hands[r.wind(seat)] = hand
+	}
+	dealer := r.wind(record.Ju)
+	r.hands[dealer] = append(r.hands[dealer], firstDraw)
+	return r, r.draw(dealer, firstDraw)
+}
+
+// seat returns the wind of the seat of a record
+func (r *majsoulRound) seat(seat int) (Wind, error) {
+	if seat < 0 || seat >= r.rule.NumPlayers() {
+		return WindDummy, fmt.Errorf("no seat %d", seat)
+	}
+	return r.wind(seat), nil
+}
+
+// record replays a record after RecordNewRound
+func (r *majsoulRound) record(name string, data []byte) error {
+	if name != "RecordHule" {
+		// no one wins on the riichi discard
+		r.passRiichi()
+	}
+	switch name {
+	case "RecordDealTile":
+		var record majsoulDealTile
+		if err := json.Unmarshal(data, &record); err != nil {
+			return err
+		}
+		return r.dealTile(record)
+	case "RecordDiscardTile":
+		var record majsoulDiscardTile
+		if err := json.Unmarshal(data, &record); err != nil {
+			return err
+		}
+		return r.discardTile(record)
+	case "RecordChiPengGang":
+		var record majsoulChiPengGang
+		if err := json.Unmarshal(data, &record); err != nil {
+			return err
+		}
+		return r.chiPengGang(record)
+	case "RecordAnGangAddGang":
+		var record majsoulAnGangAddGang
+		if err := json.Unmarshal(data, &record); err != nil {
+			return err
+		}
+		return r.anGangAddGang(record)
+	case "RecordBaBei":
+		var record majsoulBaBei
+		if err := json.Unmarshal(data, &record); err != nil {
+			return err
+		}
+		return r.baBei(record)
+	case "RecordHule":
+		var record majsoulHule
+		if err := json.Unmarshal(data, &record); err != nil {
+			return err
+		}
+		return r.hule(record)
+	case "RecordNoTile":
+		var record majsoulNoTile
+		if err := json.Unmarshal(data, &record); err != nil {
+			return err
+		}
+		return r.noTile(record)
+	case "RecordLiuJu":
+		var record majsoulLiuJu
+		if err := json.Unmarshal(data, &record); err != nil {
+			return err
+		}
+		reason, ok := majsoulRyuuKyokuReasons[record.Type]
+		if !ok {
+			return fmt.Errorf("unknown ryuu kyoku type %d", record.Type)
+		}
+		hands := make(map[Wind]Tiles)
+		if reason == RyuuKyokuKyuuShuKyuuHai {
+			hand := r.hands[r.lastDrawer]
+			hands[r.lastDrawer] = hand.Copy()
+		}
+		return r.ryuuKyoku(reason, false, hands, nil)
+	}
+	return fmt.Errorf("record %s not supported", name)
+}
+
+// doras reveals the dora indicators of the list not revealed yet, the list includes the first indicator
+func (r *majsoulRound) doras(doras []string) error {
+	codes, err := majsoulCodes(doras)
+	if err != nil {
+		return err
+	}
+	for len(codes) > r.numIndicators+1 {
+		tiles, err := r.alloc(codes[r.numIndicators+1])
+		if err != nil {
+			return err
+		}
+		if err = r.dora(tiles[0]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dealTile draws a tile, the dora indicator revealed by a kan comes before the rinshan tile
+func (r *majsoulRound) dealTile(record majsoulDealTile) error {
+	who, err := r.seat(record.Seat)
+	if err != nil {
+		return err
+	}
+	if err = r.doras(record.Doras); err != nil {
+		return err
+	}
+	code, err := majsoulCode(record.Tile)
+	if err != nil {
+		return err
+	}
+	tiles, err := r.alloc(code)
+	if err != nil {
+		return err
+	}
+	r.hands[who] = append(r.hands[who], tiles[0])
+	return r.draw(who, tiles[0])
+}
+
+// discardTile discards a tile, moqie tells the tile just drawn
+func (r *majsoulRound) discardTile(record majsoulDiscardTile) error {
+	who, err := r.seat(record.Seat)
+	if err != nil {
+		return err
+	}
+	code, err := majsoulCode(record.Tile)
+	if err != nil {
+		return err
+	}
+	if record.IsLiqi || record.IsWliqi {
+		if err = r.riichi(who, 1); err != nil {
+			return err
+		}
+		r.riichiBy = who
+	}
+	if _, ok := r.lastDrawn[who]; record.Moqie && !ok {
+		return fmt.Errorf("%s discards the tile drawn without a draw", who)
+	}
+	tile, err := r.take(who, code, record.Moqie)
+	if err != nil {
+		return err
+	}
+	r.lastTile, r.lastFrom = tile, who
+	if err = r.discard(who, tile); err != nil {
+		return err
+	}
+	return r.doras(record.Doras)
+}
+
+// chiPengGang calls the last discard, the tile from another seat is the one called
+func (r *majsoulRound) chiPengGang(record majsoulChiPengGang) error {
+	who, err := r.seat(record.Seat)
+	if err != nil {
+		return err
+	}
+	callTypes := []CallType{Chi, Pon, DaiMinKan}
+	if record.Type < 0 || record.Type >= len(callTypes) || len(record.Froms) != len(record.Tiles) {
+		return fmt.Errorf("invalid call of type %d, tiles %v from %v", record.Type, record.Tiles, record.Froms)
+	}
+	codes, err := majsoulCodes(record.Tiles)
+	if err != nil {
+		return err
+	}
+	var tiles Tiles
+	called := false
+	for i, code := range codes {
+		if record.Froms[i] != record.Seat {
+			if called || code != tenhou6Code(r.lastTile, r.rule.HasAkaDora) {
+				return fmt.Errorf("tile %s is not the last discard", record.Tiles[i])
+			}
+			called = true
+			continue
+		}
+		tile, err := r.take(who, code, false)
+		if err != nil {
+			return err
+		}
+		tiles = append(tiles, tile)
+	}
+	if !called {
+		return errors.New("no tile called")
+	}
+	sort.Sort(&tiles)
+	return r.claim(who, callTypes[record.Type], tiles, r.lastTile)
+}
+
+// anGangAddGang adds an ankan of all the tiles of the class or a shouminkan of the tile
+func (r *majsoulRound) anGangAddGang(record majsoulAnGangAddGang) error {
+	who, err := r.seat(record.Seat)
+	if err != nil {
+		return err
+	}
+	code, err := majsoulCode(record.Tiles)
+	if err != nil {
+		return err
+	}
+	switch record.Type {
+	case 3:
+		class, _, err := tenhou6Class(code)
+		if err != nil {
+			return err
+		}
+		if err = r.ankan(who, class); err != nil {
+			return err
+		}
+	case 2:
+		tile, err := r.take(who, code, false)
+		if err != nil {
+			return err
+		}
+		r.lastTile, r.lastFrom = tile, who
+		if err = r.addedKan(who, tile); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("kan of type %d", record.Type)
+	}
+	return r.doras(record.Doras)
+}
+
+// ankan adds an ankan of all the tiles of the class in the hand
+func (r *majsoulRound) ankan(who Wind, class TileClass) error {
+	var tiles Tiles
+	for i := 0; i < len(r.hands[who]); i++ {
+		if r.hands[who][i].Class() == class {
+			tiles = append(tiles, r.remove(who, i))
+			i--
+		}
+	}
+	if len(tiles) != 4 {
+		return fmt.Errorf("%s has %d tiles of %s for an ankan", who, len(tiles), class)
+	}
+	sort.Sort(&tiles)
+	r.lastTile, r.lastFrom = tiles[len(tiles)-1], who
+	return r.closedKan(who, tiles)
+}
+
+// baBei pulls a north for kita, the engine takes the north just drawn
+func (r *majsoulRound) baBei(record majsoulBaBei) error {
+	who, err := r.seat(record.Seat)
+	if err != nil {
+		return err
+	}
+	tile, err := r.take(who, 44, true)
+	if err != nil {
+		return err
+	}
+	r.lastTile, r.lastFrom = tile, who
+	if err = r.kita(who, tile); err != nil {
+		return err
+	}
+	return r.doras(record.Doras)
+}
+
+// hule ends the round by the wins, the points changes are given once for all the winners
+func (r *majsoulRound) hule(record majsoulHule) error {
+	if len(record.Hules) == 0 {
+		return errors.New("no winner")
+	}
+	pointsChange := make(map[Wind]int)
+	for seat := 0; seat < len(record.DeltaScores) && seat < r.rule.NumPlayers(); seat++ {
+		pointsChange[r.wind(seat)] = record.DeltaScores[seat]
+	}
+	// the winners in riichi share the ura dora indicators
+	hasUra := false
+	for _, hule := range record.Hules {
+		who, err := r.seat(hule.Seat)
+		if err != nil {
+			return err
+		}
+		if len(hule.LiDoras) > 0 && !hasUra {
+			codes, err := majsoulCodes(hule.LiDoras)
+			if err != nil {
+				return err
+			}
+			uraIndicators, err := r.alloc(codes...)
+			if err != nil {
+				return err
+			}
+			if err = r.indicators(nil, uraIndicators); err != nil {
+				return err
+			}
+			hasUra = true
+		}
+		hand, winTile, from := r.hands[who], r.lastTile, r.lastFrom
+		hand = hand.Copy()
+		if hule.Zimo {
+			winTile, from = r.lastDrawn[who], who
+		} else {
+			hand = append(hand, winTile)
+		}
+		if err = r.win(who, from, WindDummy, hand, winTile, pointsChange); err != nil {
+			return err
+		}
+		pointsChange = nil
+	}
+	return nil
+}
+
+// noTile ends the round by the exhaustive draw, the hands in tenpai are revealed
+func (r *majsoulRound) noTile(record majsoulNoTile) error {
+	pointsChange := make(map[Wind]int)
+	for _, score := range record.Scores {
+		for seat := 0; seat < len(score.DeltaScores) && seat < r.rule.NumPlayers(); seat++ {
+			pointsChange[r.wind(seat)] += score.DeltaScores[seat]
+		}
+	}
+	hands := make(map[Wind]Tiles)
+	for wind, hand := range r.hands {
+		if len(GetTenpaiSlice(hand, append(Calls{}, r.melds[wind]...))) > 0 {
+			hands[wind] = hand.Copy()
+		}
+	}
+	return r.ryuuKyoku(RyuuKyokuNormal, record.Liujumanguan, hands, pointsChange)
+}
@@ -0,0 +1,439 @@
+package mahjong
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// MjaiMessage a json message of the mjai protocol, keyed by the fields of mjai
+type MjaiMessage map[string]interface{}
+
+// Type returns the type of the message
+func (m MjaiMessage) Type() string {
+	t, _ := m["type"].(string)
+	return t
+}
+
+// mjaiUnknown the tile hidden from the player
+const mjaiUnknown = "?"
+
+var mjaiHonors = [...]string{"E", "S", "W", "N", "P", "F", "C"}
+
+var mjaiRyuuKyokuReasons = map[RyuuKyokuReason]string{
+	RyuuKyokuNormal:         "fanpai",
+	RyuuKyokuKyuuShuKyuuHai: "kyushukyuhai",
+	RyuuKyokuSuuChaRiichi:   "suchareach",
+	RyuuKyokuSuuKaiKan:      "sukaikan",
+	RyuuKyokuSuufonRenda:    "sufonrenta",
+	RyuuKyokuSanChaHou:      "sanchaho",
+}
+
+// MjaiTile
+//
+//	@Description: the mjai name of a tile, 1m to 9m, 1p to 9p, 1s to 9s, E S W N P F C for the honors,
+//	5mr 5pr 5sr for the red fives and ? for TileDummy
+//	@param tile: tile
+//	@param hasAkaDora: whether the copy 0 of the fives is red
+//	@return string
+func MjaiTile(tile Tile, hasAkaDora bool) string {
+	if tile == TileDummy {
+		return mjaiUnknown
+	}
+	class := tile.Class()
+	if class >= Ton {
+		return mjaiHonors[class-Ton]
+	}
+	name := fmt.Sprintf("%d%c", class%9+1, "mps"[class/9])
+	if hasAkaDora && class%9 == 4 && tile%4 == 0 {
+		name += "r"
+	}
+	return name
+}
+
+// mjaiResponse the fields of the responses of mjai bots
+type mjaiResponse struct {
+	Type      string   `json:"type"`
+	Actor     int      `json:"actor"`
+	Target    int      `json:"target"`
+	Pai       string   `json:"pai"`
+	Consumed  []string `json:"consumed"`
+	Tsumogiri bool     `json:"tsumogiri"`
+}
+
+// MjaiAdapter translates the events of a player to mjai messages and the mjai responses of the player to calls,
+// seats of mjai are the indexes of the players in the game, 0 for the dealer of the first round
+type MjaiAdapter struct {
+	Seat  int
+	Names []string
+
+	rule       *Rule
+	wind       Wind         // wind of the player in the round
+	dealer     int          // seat of the dealer of the round
+	drawn      Tile         // tile just drawn by the player, TileDummy after a discard or call
+	reaching   bool         // the bot declared riichi and is asked for the discard
+	reachSent  bool         // the reach of the bot is sent already, the first step of the riichi event is skipped
+	tenpais    map[int]bool // seats tenpai at the end of the round
+	isNagashi  bool         // the round ends with nagashi mangan
+	kanWho     Wind         // the player of the last added or closed kan, robbed by chankan
+	numPlayers int
+}
+
+// NewMjaiAdapter
+//
+//	@Description: create an mjai adapter of a player
+//	@param seat: index of the player in the game
+//	@param names: names of the players by seat, sent by start_game
+//	@return *MjaiAdapter
+func NewMjaiAdapter(seat int, names []string) *MjaiAdapter {
+	return &MjaiAdapter{
+		Seat:       seat,
+		Names:      names,
+		wind:       WindDummy,
+		drawn:      TileDummy,
+		numPlayers: 4,
+	}
+}
+
+// StartGame
+//
+//	@Description: the start_game message of the player
+//	@receiver a
+//	@return MjaiMessage
+func (a *MjaiAdapter) StartGame() MjaiMessage {
+	return MjaiMessage{"type": "start_game", "id": a.Seat, "names": a.Names}
+}
+
+// seat returns the mjai seat of a wind in the round
+func (a *MjaiAdapter) seat(wind Wind) int {
+	return (int(wind) + a.dealer) % a.numPlayers
+}
+
+// tile returns the mjai name of a tile
+func (a *MjaiAdapter) tile(tile Tile) string {
+	return MjaiTile(tile, a.rule != nil && a.rule.HasAkaDora)
+}
+
+// tiles returns the mjai names of tiles
+func (a *MjaiAdapter) tiles(tiles Tiles) []string {
+	names := make([]string, 0, len(tiles))
+	for _, tile := range tiles {
+		if tile != TileDummy {
+			names = append(names, a.tile(tile))
+		}
+	}
+	return names
+}
+
+// meld returns the tile called from other players, its owner and the tiles of the player
+func (a *MjaiAdapter) meld(who Wind, call *Call) (Tile, Wind, Tiles) {
+	called, from := TileDummy, WindDummy
+	var consumed Tiles
+	for i, tile := range call.CallTiles {
+		switch {
+		case tile == TileDummy:
+		case call.CallTilesFromWho[i] != who && called == TileDummy:
+			called, from = tile, call.CallTilesFromWho[i]
+		default:
+			consumed = append(consumed, tile)
+		}
+	}
+	return called, from, consumed
+}
+
+// Messages
+//
+//	@Description: translate the events of the player to mjai messages, the tiles of other players are "?",
+//	events without mjai messages like furiten are left out
+//	@receiver a
+//	@param events: events of the player from GetPosEvents, in order
+//	@return []MjaiMessage
+func (a *MjaiAdapter) Messages(events Events) []MjaiMessage {
+	var messages []MjaiMessage
+	for _, event := range events {
+		if m := a.message(event); m != nil {
+			messages = append(messages, m)
+		}
+	}
+	return messages
+}
+
+// message returns the mjai message of an event, nil for none
+func (a *MjaiAdapter) message(event Event) MjaiMessage {
+	switch e := event.(type) {
+	case *EventStart:
+		a.rule = e.Rule
+		a.numPlayers = e.Rule.NumPlayers()
+		a.dealer = int(e.WindRound-WindRoundEast1) % 4
+		a.wind = Wind((a.Seat - a.dealer + a.numPlayers) % a.numPlayers)
+		a.drawn, a.reaching, a.reachSent = TileDummy, false, false
+		a.tenpais, a.isNagashi = make(map[int]bool), false
+		scores := make([]int, a.numPlayers)
+		tehais := make([][]string, a.numPlayers)
+		for wind, points := range e.PlayersPoints {
+			scores[a.seat(wind)] = points
+		}
+		for seat := range tehais {
+			if seat == a.Seat {
+				tehais[seat] = a.tiles(e.InitTiles)
+				continue
+			}
+			for i := 0; i < len(e.InitTiles); i++ {
+				tehais[seat] = append(tehais[seat], mjaiUnknown)
+			}
+		}
+		return MjaiMessage{
+			"type":        "start_kyoku",
+			"bakaze":      mjaiHonors[int(e.WindRound-WindRoundEast1)/4],
+			"kyoku":       a.dealer + 1,
+			"honba":       e.NumHonba,
+			"kyotaku":     e.NumRiichi,
+			"oya":         a.dealer,
+			"dora_marker": a.tile(e.InitDoraIndicator),
+			"scores":      scores,
+			"tehais":      tehais,
+		}
+	case *EventGet:
+		if e.Who == a.wind {
+			a.drawn = e.Tile
+		}
+		return MjaiMessage{"type": "tsumo", "actor": a.seat(e.Who), "pai": a.tile(e.Tile)}
+	case *EventDiscard:
+		return a.dahai(e.Who, e.Tile, false)
+	case *EventTsumoGiri:
+		return a.dahai(e.Who, e.Tile, true)
+	case *EventChi:
+		return a.call("chi", e.Who, e.Call)
+	case *EventPon:
+		return a.call("pon", e.Who, e.Call)
+	case *EventDaiMinKan:
+		return a.call("daiminkan", e.Who, e.Call)
+	case *EventShouMinKan:
+		a.clearDrawn(e.Who)
+		a.kanWho = e.Who
+		return MjaiMessage{
+			"type":     "kakan",
+			"actor":    a.seat(e.Who),
+			"pai":      a.tile(e.Call.CallTiles[3]),
+			"consumed": a.tiles(e.Call.CallTiles[:3]),
+		}
+	case *EventAnKan:
+		a.clearDrawn(e.Who)
+		a.kanWho = e.Who
+		return MjaiMessage{"type": "ankan", "actor": a.seat(e.Who), "consumed": a.tiles(e.Call.CallTiles)}
+	case *EventKita:
+		a.clearDrawn(e.Who)
+		return MjaiMessage{"type": "nukidora", "actor": a.seat(e.Who), "pai": a.tile(e.Tile)}
+	case *EventNewIndicator:
+		return MjaiMessage{"type": "dora", "dora_marker": a.tile(e.Tile)}
+	case *EventRiichi:
+		if e.Step == 2 {
+			return MjaiMessage{"type": "reach_accepted", "actor": a.seat(e.Who)}
+		}
+		if e.Who == a.wind && a.reachSent {
+			a.reachSent = false
+			return nil
+		}
+		return MjaiMessage{"type": "reach", "actor": a.seat(e.Who)}
+	case *EventRon:
+		return a.hora(e.Who, e.FromWho, e.WinTile)
+	case *EventChanKan:
+		from := e.FromWho
+		if from == WindDummy {
+			from = a.kanWho
+		}
+		return a.hora(e.Who, from, e.WinTile)
+	case *EventTsumo:
+		return a.hora(e.Who, e.Who, e.WinTile)
+	case *EventTenpaiEnd:
+		a.tenpais[a.seat(e.Who)] = true
+	case *EventNagashiMangan:
+		a.isNagashi = true
+	case *EventRyuuKyoku:
+		m := MjaiMessage{"type": "ryukyoku", "reason": mjaiRyuuKyokuReasons[e.Reason]}
+		switch {
+		case e.Reason == RyuuKyokuKyuuShuKyuuHai:
+			m["actor"] = a.seat(e.Who)
+		case e.Reason == RyuuKyokuNormal && a.isNagashi:
+			m["reason"] = "nagashimangan"
+		case e.Reason == RyuuKyokuNormal:
+			tenpais := make([]bool, a.numPlayers)
+			for seat := range tenpais {
+				tenpais[seat] = a.tenpais[seat]
+			}
+			m["tenpais"] = tenpais
+		}
+		return m
+	case *EventEnd:
+		return MjaiMessage{"type": "end_kyoku"}
+	case *EventGameEnd:
+		scores := make([]int, a.numPlayers)
+		for _, player := range e.Result.Players {
+			scores[int(player.Seat)] = player.Points
+		}
+		return MjaiMessage{"type": "end_game", "scores": scores}
+	}
+	return nil
+}
+
+// clearDrawn forgets the tile drawn by the player after its action
+func (a *MjaiAdapter) clearDrawn(who Wind) {
+	if who == a.wind {
+		a.drawn = TileDummy
+	}
+}
+
+// dahai returns the message of a discard
+func (a *MjaiAdapter) dahai(who Wind, tile Tile, isTsumoGiri bool) MjaiMessage {
+	a.clearDrawn(who)
+	return MjaiMessage{"type": "dahai", "actor": a.seat(who), "pai": a.tile(tile), "tsumogiri": isTsumoGiri}
+}
+
+// call returns the message of a chi, pon or daiminkan
+func (a *MjaiAdapter) call(name string, who Wind, call *Call) MjaiMessage {
+	a.clearDrawn(who)
+	called, from, consumed := a.meld(who, call)
+	return MjaiMessage{
+		"type":     name,
+		"actor":    a.seat(who),
+		"target":   a.seat(from),
+		"pai":      a.tile(called),
+		"consumed": a.tiles(consumed),
+	}
+}
+
+// hora returns the message of a win
+func (a *MjaiAdapter) hora(who, from Wind, winTile Tile) MjaiMessage {
+	return MjaiMessage{"type": "hora", "actor": a.seat(who), "target": a.seat(from), "pai": a.tile(winTile)}
+}
+
+// ToCall
+//
+//	@Description: translate an mjai response of the player to one of the valid calls. The riichi is
+//	declared in two steps as mjai does: a reach response returns no call but the reach message to send back
+//	to the bot, the dahai responded to it is the riichi call of the tile
+//	@receiver a
+//	@param data: json response of the bot
+//	@param calls: valid calls of the player
+//	@return *Call: the valid call responded, nil after a reach
+//	@return MjaiMessage: the message to send back to the bot after a reach, nil for others
+//	@return error: wraps ErrIllegalCall when the response is not one of the valid calls
+func (a *MjaiAdapter) ToCall(data []byte, calls Calls) (*Call, MjaiMessage, error) {
+	var resp mjaiResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, nil, fmt.Errorf("mjai response: %w", err)
+	}
+	// the reach lasts for the next response only, any response but a matching dahai cancels it
+	reaching := a.reaching
+	a.reaching = false
+	var call *Call
+	switch resp.Type {
+	case "none":
+		if call = a.find(calls, Skip); call == nil {
+			call = a.find(calls, Next)
+		}
+	case "dahai":
+		callType := Discard
+		if reaching {
+			callType = Riichi
+		}
+		call = a.findDahai(calls, callType, &resp)
+		if call != nil && reaching {
+			a.reachSent = true
+		}
+	case "reach":
+		if a.find(calls, Riichi) != nil {
+			a.reaching = true
+			return nil, MjaiMessage{"type": "reach", "actor": a.Seat}, nil
+		}
+	case "chi":
+		call = a.findMeld(calls, Chi, &resp)
+	case "pon":
+		call = a.findMeld(calls, Pon, &resp)
+	case "daiminkan":
+		call = a.findMeld(calls, DaiMinKan, &resp)
+	case "kakan":
+		for _, c := range calls {
+			if c.CallType == ShouMinKan && a.tile(c.CallTiles[3]) == resp.Pai {
+				call = c
+				break
+			}
+		}
+	case "ankan":
+		for _, c := range calls {
+			if c.CallType == AnKan && sameMjaiTiles(a.tiles(c.CallTiles), resp.Consumed) {
+				call = c
+				break
+			}
+		}
+	case "hora":
+		for _, callType := range []CallType{Ron, Tsumo, ChanKan} {
+			if call = a.find(calls, callType); call != nil {
+				break
+			}
+		}
+	case "ryukyoku":
+		call = a.find(calls, KyuuShuKyuuHai)
+	case "nukidora":
+		call = a.find(calls, Kita)
+	}
+	if call == nil {
+		return nil, nil, fmt.Errorf("%w: mjai %s", ErrIllegalCall, data)
+	}
+	return call, nil, nil
+}
+
+// find returns the first call of the type, nil for none
+func (a *MjaiAdapter) find(calls Calls, callType CallType) *Call {
+	for _, call := range calls {
+		if call.CallType == callType {
+			return call
+		}
+	}
+	return nil
+}
+
+// findDahai returns the discard or riichi call of the tile, the tile just drawn for tsumogiri and another copy else if any
+func (a *MjaiAdapter) findDahai(calls Calls, callType CallType, resp *mjaiResponse) *Call {
+	var found *Call
+	for _, call := range calls {
+		if call.CallType != callType || a.tile(call.CallTiles[0]) != resp.Pai {
+			continue
+		}
+		if found == nil || (call.CallTiles[0] == a.drawn) == resp.Tsumogiri {
+			found = call
+		}
+	}
+	return found
+}
+
+// findMeld returns the chi, pon or daiminkan calling the tile with the tiles consumed
+func (a *MjaiAdapter) findMeld(calls Calls, callType CallType, resp *mjaiResponse) *Call {
+	for _, call := range calls {
+		if call.CallType != callType {
+			continue
+		}
+		called, from, consumed := a.meld(a.wind, call)
+		if a.tile(called) == resp.Pai && a.seat(from) == resp.Target && sameMjaiTiles(a.tiles(consumed), resp.Consumed) {
+			return call
+		}
+	}
+	return nil
+}
+
+// sameMjaiTiles returns whether two lists hold the same tiles in any order
+func sameMjaiTiles(tiles1, tiles2 []string) bool {
+	if len(tiles1) != len(tiles2) {
+		return false
+	}
+	tiles1 = append([]string{}, tiles1...)
+	tiles2 = append([]string{}, tiles2...)
+	sort.Strings(tiles1)
+	sort.Strings(tiles2)
+	for i := range tiles1 {
+		if tiles1[i] != tiles2[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -15,6 +15,7 @@ type Player struct {
 	TilesTsumoGiri  []bool
 	BoardTiles      Tiles
 	Melds           Calls
+	KitaTiles       Tiles
 	TenpaiTiles     Tiles
 	ShantenNum      int
 	TenpaiSlice     TileClasses
@@ -35,11 +36,15 @@ type Player struct {
 	IsTenhou        bool
 	IsChiihou       bool
 	RiichiStep      int
+	PaoWind         Wind        // player liable for the yakuman, WindDummy for none
+	PaoYakuman      Yakuman     // yakuman the pao player is liable for
+	RinshanPaoWind  Wind        // player who fed the daiminkan before the rinshan tile, WindDummy for none
+	KuikaeClasses   TileClasses // tile classes restricted by kuikae after the chi or pon, cleared after the discard
 }
 
 func NewMahjongPlayer() *Player {
 	p := Player{}
-	p.ResetForGame()
+	p.ResetForGame(GetDefaultRule().StartingPoints)
 	return &p
 }
 
@@ -122,6 +127,7 @@ func (player *Player) ResetForRound() {
 	player.TilesTsumoGiri = make([]bool, 0, 25)
 	player.BoardTiles = make(Tiles, 0, 25)
 	player.Melds = make(Calls, 0, 4)
+	player.KitaTiles = make(Tiles, 0, 4)
 	player.TenpaiTiles = make(Tiles, 0, 13)
 	player.ShantenNum = 7
 	player.TenpaiSlice = []TileClass{}
@@ -142,10 +148,14 @@ func (player *Player) ResetForRound() {
 	player.IsTenhou = false
 	player.IsChiihou = false
 	player.RiichiStep = 0
+	player.PaoWind = WindDummy
+	player.PaoYakuman = YakumanNone
+	player.RinshanPaoWind = WindDummy
+	player.KuikaeClasses = nil
 }
 
-func (player *Player) ResetForGame() {
-	player.Points = 25000
+func (player *Player) ResetForGame(startingPoints int) {
+	player.Points = startingPoints
 	player.ResetForRound()
 }
 
@@ -169,12 +179,13 @@ func (player *Player) Copy() *Player {
 	p.KanNum = player.KanNum
 	p.HandTiles = player.HandTiles.Copy()
 	p.DiscardTiles = player.DiscardTiles.Copy()
-	p.TilesTsumoGiri = player.TilesTsumoGiri
+	p.TilesTsumoGiri = append([]bool(nil), player.TilesTsumoGiri...)
 	p.BoardTiles = player.BoardTiles.Copy()
 	p.Melds = player.Melds.Copy()
+	p.KitaTiles = player.KitaTiles.Copy()
 	p.TenpaiTiles = player.TenpaiTiles.Copy()
 	p.ShantenNum = player.ShantenNum
-	p.TenpaiSlice = player.TenpaiSlice
+	p.TenpaiSlice = player.TenpaiSlice.Copy()
 	p.JunFuriten = player.JunFuriten
 	p.DiscardFuriten = player.DiscardFuriten
 	p.RiichiFuriten = player.RiichiFuriten
@@ -192,5 +203,9 @@ func (player *Player) Copy() *Player {
 	p.IsTenhou = player.IsTenhou
 	p.IsChiihou = player.IsChiihou
 	p.RiichiStep = player.RiichiStep
+	p.PaoWind = player.PaoWind
+	p.PaoYakuman = player.PaoYakuman
+	p.RinshanPaoWind = player.RinshanPaoWind
+	p.KuikaeClasses = player.KuikaeClasses.Copy()
 	return &p
 }
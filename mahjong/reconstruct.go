@@ -11,27 +11,35 @@ import (
 //	@param playerSlice: player slice
 //	@param globalEvents: global events
 //	@return *Game
+//	panics if the events can't be followed
 func ReConstructGame(playerSlice []*Player, globalEvents Events) *Game {
-	game := &Game{}
+	game, err := reConstructGame(playerSlice, globalEvents)
+	if err != nil {
+		panic(err)
+	}
+	return game
+}
+
+// reConstructGame reconstructs the game from the global events, an error if the events can't be followed
+func reConstructGame(playerSlice []*Player, globalEvents Events) (*Game, error) {
 	var posCalls = make(map[Wind]Calls)
 
 	e := globalEvents[0]
 	if e.GetType() != EventTypeGlobalInit {
-		panic(errors.New("first event must be EventTypeGlobalInit"))
+		return nil, errors.New("first event must be EventTypeGlobalInit")
 	}
 	et := e.(*EventGlobalInit)
-	game.Tiles = NewMahjongTiles(nil)
-	posCalls = game.Reset(playerSlice, et.AllTiles)
+	game := NewMahjongGame(et.Seed, et.Rule)
+	game.reset(playerSlice, et.AllTiles)
 	game.WindRound = et.WindRound
-	game.Seed = et.Seed
-	game.NumGame = et.NumGame
+	game.NumGame = et.NumGame - 1 // will be increased in the new round
 	game.NumHonba = et.NumHonba
 	game.NumRiichi = et.NumRiichi
-	game.Rule = et.Rule
-	game.PosPlayer[Wind((16-game.WindRound)%4)].Points = et.InitPoints[Wind((16-game.WindRound)%4)]
-	game.PosPlayer[Wind((17-game.WindRound)%4)].Points = et.InitPoints[Wind((17-game.WindRound)%4)]
-	game.PosPlayer[Wind((18-game.WindRound)%4)].Points = et.InitPoints[Wind((18-game.WindRound)%4)]
-	game.PosPlayer[Wind((19-game.WindRound)%4)].Points = et.InitPoints[Wind((19-game.WindRound)%4)]
+	game.seatPlayers()
+	for wind, player := range game.PosPlayer {
+		player.Points = et.InitPoints[wind]
+	}
+	posCalls, _ = game.Step(make(map[Wind]*Call, 4))
 
 	index := 1
 	for index < len(globalEvents) {
@@ -45,7 +53,7 @@ func ReConstructGame(playerSlice []*Player, globalEvents Events) *Game {
 		event := globalEvents[index]
 		switch event.GetType() {
 		case EventTypeGlobalInit:
-			return ReConstructGame(playerSlice, globalEvents[index:]) // reconstruct from this event
+			return reConstructGame(playerSlice, globalEvents[index:]) // reconstruct from this event
 		case EventTypeGet:
 			for wind := range posCalls {
 				if game.Position != wind || common.SliceContain(posCalls[wind], SkipCall) {
@@ -72,22 +80,28 @@ func ReConstructGame(playerSlice []*Player, globalEvents Events) *Game {
 			}
 		case EventTypeAnKan:
 			who := event.(*EventAnKan).Who
-			calls := posCalls[who]
-			for _, call := range calls {
-				if CallEqual(call, event.(*EventAnKan).Call) {
-					posCall[who] = call
-					break
-				}
-			}
+			posCall[who] = matchCall(posCalls[who], event.(*EventAnKan).Call)
 		case EventTypeShouMinKan:
 			who := event.(*EventShouMinKan).Who
+			posCall[who] = matchCall(posCalls[who], event.(*EventShouMinKan).Call)
+		case EventTypeKita:
+			who := event.(*EventKita).Who
 			calls := posCalls[who]
 			for _, call := range calls {
-				if CallEqual(call, event.(*EventShouMinKan).Call) {
+				if call.CallType == Kita && call.CallTiles[0] == event.(*EventKita).Tile {
 					posCall[who] = call
 					break
 				}
 			}
+		case EventTypeAgariYame:
+			for wind, calls := range posCalls {
+				for _, call := range calls {
+					if (wind == East && call.CallType == AgariYame) || (wind != East && call.CallType == Next) {
+						posCall[wind] = call
+						break
+					}
+				}
+			}
 		case EventTypeRiichi:
 			who := event.(*EventRiichi).Who
 			step := event.(*EventRiichi).Step
@@ -96,6 +110,9 @@ func ReConstructGame(playerSlice []*Player, globalEvents Events) *Game {
 				index++
 				continue
 			}
+			if index+1 == len(globalEvents) {
+				return nil, errors.New("riichi must be followed by its discard")
+			}
 			nextEvent := globalEvents[index+1]
 			switch nextEvent.GetType() {
 			case EventTypeDiscard:
@@ -103,7 +120,7 @@ func ReConstructGame(playerSlice []*Player, globalEvents Events) *Game {
 			case EventTypeTsumoGiri:
 				tile = nextEvent.(*EventTsumoGiri).Tile
 			default:
-				panic(errors.New("next event must be EventTypeDiscard or EventTypeTsumoGiri"))
+				return nil, errors.New("next event must be EventTypeDiscard or EventTypeTsumoGiri")
 			}
 			calls := posCalls[who]
 			for _, call := range calls {
@@ -113,7 +130,7 @@ func ReConstructGame(playerSlice []*Player, globalEvents Events) *Game {
 				}
 			}
 			if _, ok := posCall[who]; !ok {
-				panic(errors.New("can't find riichi call"))
+				return nil, errors.New("can't find riichi call")
 			}
 			index++
 		case EventTypeTsumo:
@@ -129,12 +146,7 @@ func ReConstructGame(playerSlice []*Player, globalEvents Events) *Game {
 			who := event.(*EventChi).Who
 			for wind, calls := range posCalls {
 				if wind == who {
-					for _, call := range calls {
-						if CallEqual(call, event.(*EventChi).Call) {
-							posCall[who] = call
-							break
-						}
-					}
+					posCall[who] = matchCall(calls, event.(*EventChi).Call)
 				} else {
 					posCall[wind] = SkipCall
 				}
@@ -143,12 +155,7 @@ func ReConstructGame(playerSlice []*Player, globalEvents Events) *Game {
 			who := event.(*EventPon).Who
 			for wind, calls := range posCalls {
 				if wind == who {
-					for _, call := range calls {
-						if CallEqual(call, event.(*EventPon).Call) {
-							posCall[who] = call
-							break
-						}
-					}
+					posCall[who] = matchCall(calls, event.(*EventPon).Call)
 				} else {
 					posCall[wind] = SkipCall
 				}
@@ -157,19 +164,21 @@ func ReConstructGame(playerSlice []*Player, globalEvents Events) *Game {
 			who := event.(*EventDaiMinKan).Who
 			for wind, calls := range posCalls {
 				if wind == who {
-					for _, call := range calls {
-						if CallEqual(call, event.(*EventDaiMinKan).Call) {
-							posCall[who] = call
-							break
-						}
-					}
+					posCall[who] = matchCall(calls, event.(*EventDaiMinKan).Call)
 				} else {
 					posCall[wind] = SkipCall
 				}
 			}
+		case EventTypeChombo:
+			// the illegal call ends the hand by itself
+			e := event.(*EventChombo)
+			posCalls, _ = game.Step(map[Wind]*Call{e.Who: e.Call})
+			index++
+			continue
 		case EventTypeRyuuKyoku:
 			reason := event.(*EventRyuuKyoku).Reason
-			if reason == RyuuKyokuKyuuShuKyuuHai {
+			switch reason {
+			case RyuuKyokuKyuuShuKyuuHai:
 				who := event.(*EventRyuuKyoku).Who
 				calls := posCalls[who]
 				for _, call := range calls {
@@ -178,61 +187,52 @@ func ReConstructGame(playerSlice []*Player, globalEvents Events) *Game {
 						break
 					}
 				}
-			}
-		case EventTypeRon:
-			who := event.(*EventRon).Who
-			for wind, calls := range posCalls {
-				if wind == who {
+			case RyuuKyokuSanChaHou:
+				// all players who can ron choose ron
+				for wind, calls := range posCalls {
 					for _, call := range calls {
-						if call.CallType != Ron {
-							continue
-						}
-						if call.CallTiles[0] == event.(*EventRon).WinTile {
-							posCall[who] = call
+						if call.CallType == Ron || call.CallType == ChanKan {
+							posCall[wind] = call
 							break
 						}
 					}
-				} else {
-					var isRon = false
-					for _, call := range calls {
-						// multiple ron
-						if call.CallType == Ron {
-							isRon = true
-							posCall[wind] = nil
-						}
-					}
-					if !isRon {
+				}
+			default:
+				// the last discard passes
+				for wind, calls := range posCalls {
+					if common.SliceContain(calls, SkipCall) {
 						posCall[wind] = SkipCall
 					}
 				}
 			}
-		case EventTypeChanKan:
-			who := event.(*EventChanKan).Who
-			for wind, calls := range posCalls {
-				if wind == who {
-					for _, call := range calls {
-						if call.CallType != ChanKan {
-							continue
-						}
-						if call.CallTiles[0] == event.(*EventChanKan).WinTile {
-							posCall[who] = call
-							break
-						}
-					}
+		case EventTypeRon, EventTypeChanKan:
+			// the winners of a multiple ron are in the following events,
+			// the players bumped by the head bump skip
+			var winTiles = make(map[Wind]Tile)
+			j := index
+			for ; j < len(globalEvents); j++ {
+				if e, ok := globalEvents[j].(*EventRon); ok {
+					winTiles[e.Who] = e.WinTile
+				} else if e, ok := globalEvents[j].(*EventChanKan); ok {
+					winTiles[e.Who] = e.WinTile
 				} else {
-					var isRon = false
-					for _, call := range calls {
-						// multiple chankan
-						if call.CallType == ChanKan {
-							isRon = true
-							posCall[wind] = nil
-						}
+					break
+				}
+			}
+			for wind, calls := range posCalls {
+				winTile, isWinner := winTiles[wind]
+				for _, call := range calls {
+					if isWinner && (call.CallType == Ron || call.CallType == ChanKan) && call.CallTiles[0] == winTile {
+						posCall[wind] = call
+						break
 					}
-					if !isRon {
-						posCall[wind] = SkipCall
+					if !isWinner && call.CallType == Skip {
+						posCall[wind] = call
+						break
 					}
 				}
 			}
+			index = j - 1
 		}
 		success := true
 		for wind, calls := range posCalls {
@@ -245,7 +245,7 @@ func ReConstructGame(playerSlice []*Player, globalEvents Events) *Game {
 				break
 			}
 			if calls.Index(posCall[wind]) == -1 {
-				panic("posCall not in posCalls")
+				return nil, errors.New("posCall not in posCalls")
 			}
 		}
 		if !success {
@@ -256,5 +256,16 @@ func ReConstructGame(playerSlice []*Player, globalEvents Events) *Game {
 		index++
 	}
 
-	return game
+	return game, nil
+}
+
+// matchCall returns the valid call of the recorded call, the same tiles are preferred to the same tile classes, nil for not found
+func matchCall(calls Calls, call *Call) *Call {
+	if idx := calls.indexExact(call); idx != -1 {
+		return calls[idx]
+	}
+	if idx := calls.Index(call); idx != -1 {
+		return calls[idx]
+	}
+	return nil
 }
@@ -42,15 +42,7 @@ func GenerateRonResult(yakuResult *yaku.Result, scoreResult *score.Score) (r *Re
 		IsClosed: yakuResult.IsClosed,
 	}
 
-	r.ScoreResult = &ScoreResult{
-		PayRon:         int(scoreResult.PayRon),
-		PayRonDealer:   int(scoreResult.PayRonDealer),
-		PayTsumo:       int(scoreResult.PayTsumo),
-		PayTsumoDealer: int(scoreResult.PayTsumoDealer),
-		Special:        Limit(scoreResult.Special),
-		Han:            int(scoreResult.Han),
-		Fu:             int(scoreResult.Fu),
-	}
+	r.ScoreResult = NewScoreResult(*scoreResult)
 	return r
 }
 
@@ -73,6 +65,26 @@ type ScoreResult struct {
 	Fu             int   `json:"fu,omitempty"`
 }
 
+func NewScoreResult(scoreResult score.Score) *ScoreResult {
+	return &ScoreResult{
+		PayRon:         int(scoreResult.PayRon),
+		PayRonDealer:   int(scoreResult.PayRonDealer),
+		PayTsumo:       int(scoreResult.PayTsumo),
+		PayTsumoDealer: int(scoreResult.PayTsumoDealer),
+		Special:        Limit(scoreResult.Special),
+		Han:            int(scoreResult.Han),
+		Fu:             int(scoreResult.Fu),
+	}
+}
+
+// removeHonba removes the honba points from the payments, for the winner who doesn't get the honba
+func (s *ScoreResult) removeHonba(numHonba, honbaValue int) {
+	s.PayRon -= 3 * numHonba * honbaValue
+	s.PayRonDealer -= 3 * numHonba * honbaValue
+	s.PayTsumo -= numHonba * honbaValue
+	s.PayTsumoDealer -= numHonba * honbaValue
+}
+
 type YakuResult struct {
 	Yaku     YakuSet  `json:"yaku,omitempty"`
 	Yakumans Yakumans `json:"yakumans,omitempty"`
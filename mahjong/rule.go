@@ -2,6 +2,7 @@ package mahjong
 
 import (
 	"encoding/json"
+	"fmt"
 	"github.com/dnovikoff/tempai-core/score"
 	"github.com/dnovikoff/tempai-core/tile"
 	"github.com/dnovikoff/tempai-core/yaku"
@@ -10,6 +11,24 @@ import (
 type Rule struct {
 	GameLength int `json:"game_length"` // game length 1 for 1 round only, 4 for tonpuusen, 8 for hanchan, etc.
 
+	// Game End Rule
+	StartingPoints  int             `json:"starting_points"`   // points of every player at the start of the game
+	TargetPoints    int             `json:"target_points"`     // return points, the game goes into extension if nobody reaches it after the all last round
+	IsTobi          bool            `json:"is_tobi"`           // true for game ends when a player is bankrupt(tobi), false for playing on with negative points
+	IsTobiAtZero    bool            `json:"is_tobi_at_zero"`   // true for bankrupt at exactly zero points, false for bankrupt only below zero
+	ExtensionPolicy ExtensionPolicy `json:"extension_policy"`  // extension after the all last round: None, SuddenDeath or FixedRounds
+	ExtraRounds     int             `json:"extra_rounds"`      // number of extra rounds for ExtensionFixedRounds
+	AgariYamePolicy AgariYamePolicy `json:"agari_yame_policy"` // whether the top dealer stops the game after winning in the final round: None, Optional or Auto
+	IsTenpaiYame    bool            `json:"is_tenpai_yame"`    // true for the top dealer also can stop the game when tenpai at ryuu kyoku
+
+	// Final Settlement Rule
+	Uma            []int          `json:"uma"`              // uma points by rank from the top, len must be the number of players, e.g. {20000, 10000, -10000, -20000}, empty for no uma
+	FloatingUma    [][]int        `json:"floating_uma"`     // uma tables indexed by the number of players at or above TargetPoints, overrides Uma when the table is set
+	IsOka          bool           `json:"is_oka"`           // true for scores counted from TargetPoints and the top gets oka (TargetPoints-StartingPoints)*players, false for counted from StartingPoints
+	TieBreakPolicy TieBreakPolicy `json:"tie_break_policy"` // ranks of the players with the same points: Seat or Split
+	KyoutakuPolicy KyoutakuPolicy `json:"kyoutaku_policy"`  // who receives the leftover riichi sticks: Top or Lost
+	RoundingPolicy RoundingPolicy `json:"rounding_policy"`  // how the final points are rounded to thousands: None, HalfUp, FiveDownSixUp, Floor or Ceil
+
 	// Yaku Rule
 	IsOpenTanyao         bool  `json:"is_open_tanyao"`           // true for open tanyao, false for closed tanyao
 	HasAkaDora           bool  `json:"has_aka_dora"`             // true for aka dora, false for no aka dora
@@ -27,9 +46,91 @@ type Rule struct {
 	IsYakumanSum     bool `json:"is_yakuman_sum"`      // true for  yakuman, false for no sum yakuman
 	HonbaValue       int  `json:"honba_value"`         // HonbaValue represent the value of one honba in score calculation, default is 100
 
+	// Payment Rule
+	RiichiDeposit      int   `json:"riichi_deposit"`       // points of one riichi stick, a player needs at least this to declare riichi
+	NotenPenalty       int   `json:"noten_penalty"`        // noten bappu pool at ryuu kyoku, paid by the noten players and shared by the tenpai players, must split evenly
+	NagashiManganLimit Limit `json:"nagashi_mangan_limit"` // nagashi mangan is paid as a tsumo of this limit, Mangan by default
+	IsNagashiRenchan   bool  `json:"is_nagashi_renchan"`   // true for nagashi mangan counts as a win for renchan, false for counts as a ryuu kyoku
+	IsTenpaiRenchan    bool  `json:"is_tenpai_renchan"`    // true for the dealer keeps the seat when tenpai at ryuu kyoku, false for only when winning(agari renchan)
+
 	// Other Rule
-	IsSanChaHou     bool `json:"is_san_cha_hou"`    // can san chan ron, true for can, false for can't -> ryuu kyoku
-	IsNagashiMangan bool `json:"is_nagashi_mangan"` // can nagashi/ryuukyoku mangan, true for can, false for can't
+	IsHeadBump      bool            `json:"is_head_bump"`      // true for head bump(atamahane), only the player nearest to the discarder wins a double ron, false for double ron
+	TripleRonPolicy TripleRonPolicy `json:"triple_ron_policy"` // three players ron the same tile: Abort(san cha hou), Allowed or HeadBump
+	IsNagashiMangan bool            `json:"is_nagashi_mangan"` // can nagashi/ryuukyoku mangan, true for can, false for can't
+	IsSanChaHou     bool            `json:"is_san_cha_hou"`    // Deprecated: use TripleRonPolicy, true allows the triple ron over TripleRonAbort
+	IsSuuKantsuPao  bool            `json:"is_suu_kantsu_pao"` // true for the player who fed the fourth kan(daiminkan) is liable for suukantsu
+	IsRinshanPao    bool            `json:"is_rinshan_pao"`    // true for the player who fed the daiminkan pays all of the rinshan kaihou tsumo
+	KuikaePolicy    KuikaePolicy    `json:"kuikae_policy"`     // swap calling after chi or pon: ForbidAll, ForbidGenbutsu, Allowed or Penalty
+	KuikaePenalty   int             `json:"kuikae_penalty"`    // points the swap caller pays to each other player under KuikaePenalty
+
+	// Penalty Rule
+	IsChombo     bool         `json:"is_chombo"`     // true for chombo mode, an illegal call is penalized and the hand is redealt, false for the illegal call panics
+	ChomboPolicy ChomboPolicy `json:"chombo_policy"` // penalty of the chombo: Mangan or Fixed
+	ChomboPoints int          `json:"chombo_points"` // points the offender loses under ChomboFixed
+
+	// Abortive Draw Rule
+	IsKyuuShuKyuuHai bool `json:"is_kyuu_shu_kyuu_hai"` // true for can declare kyuu shu kyuu hai(nine different terminals and honors) in the first turn
+	IsSuuFonRenda    bool `json:"is_suu_fon_renda"`     // true for ryuu kyoku when all four players discard the same wind in the first turn
+	IsSuuChaRiichi   bool `json:"is_suu_cha_riichi"`    // true for ryuu kyoku when all four players declare riichi
+	IsSuuKaiKan      bool `json:"is_suu_kai_kan"`       // true for ryuu kyoku after four kans by more than one player
+
+	// Sanma Rule
+	IsSanma          bool `json:"is_sanma"`            // true for three-player mahjong(sanma), false for four-player mahjong
+	IsSanmaTsumoLoss bool `json:"is_sanma_tsumo_loss"` // true for tsumo loss(the absent north share is not paid), false for the north share split between the two payers
+}
+
+// NumPlayers returns the number of seats at the table, 3 for sanma and 4 otherwise
+func (r *Rule) NumPlayers() int {
+	if r != nil && r.IsSanma {
+		return 3
+	}
+	return 4
+}
+
+// NumTiles returns the number of tiles in the wall, 108 for sanma and 136 otherwise
+func (r *Rule) NumTiles() int {
+	if r != nil && r.IsSanma {
+		return NumSanmaTiles
+	}
+	return NumTiles
+}
+
+// NumInitRemainTiles returns the number of live wall tiles after the initial deal
+func (r *Rule) NumInitRemainTiles() int {
+	return r.NumTiles() - NumDeadWallTiles - 13*r.NumPlayers()
+}
+
+// Validate returns ErrInvalidRule if an uma table does not have one value per player,
+// or the noten penalty pool cannot be split evenly among the tenpai and the noten players
+func (r *Rule) Validate() error {
+	n := r.NumPlayers()
+	for k := 1; k < n; k++ {
+		if r.NotenPenalty%k != 0 {
+			return fmt.Errorf("%w: noten penalty %d cannot be split evenly among %d players", ErrInvalidRule, r.NotenPenalty, k)
+		}
+	}
+	return r.validateUma(n)
+}
+
+// validateUma checks Uma and every FloatingUma table set have n values, an empty Uma means no uma
+func (r *Rule) validateUma(n int) error {
+	if len(r.Uma) != 0 && len(r.Uma) != n {
+		return fmt.Errorf("%w: uma %v must have %d values", ErrInvalidRule, r.Uma, n)
+	}
+	for i, uma := range r.FloatingUma {
+		if len(uma) != 0 && len(uma) != n {
+			return fmt.Errorf("%w: floating uma %d %v must have %d values", ErrInvalidRule, i, uma, n)
+		}
+	}
+	return nil
+}
+
+// tripleRonPolicy returns the policy of the triple ron, the deprecated IsSanChaHou allows it over TripleRonAbort
+func (r *Rule) tripleRonPolicy() TripleRonPolicy {
+	if r.IsSanChaHou && r.TripleRonPolicy == TripleRonAbort {
+		return TripleRonAllowed
+	}
+	return r.TripleRonPolicy
 }
 
 func (r *Rule) YakuRule() *yaku.RulesStruct {
@@ -72,6 +173,21 @@ func GetDefaultRule() *Rule {
 	return &Rule{
 		GameLength: 8,
 
+		StartingPoints:  25000,
+		TargetPoints:    30000,
+		IsTobi:          true,
+		IsTobiAtZero:    false,
+		ExtensionPolicy: ExtensionSuddenDeath,
+		ExtraRounds:     0,
+		AgariYamePolicy: AgariYameNone,
+		IsTenpaiYame:    false,
+
+		Uma:            []int{20000, 10000, -10000, -20000},
+		IsOka:          true,
+		TieBreakPolicy: TieBreakSeat,
+		KyoutakuPolicy: KyoutakuTop,
+		RoundingPolicy: RoundingNone,
+
 		IsOpenTanyao:         true,
 		HasAkaDora:           true,
 		RenhouLimit:          LimitNone,
@@ -86,11 +202,43 @@ func GetDefaultRule() *Rule {
 		IsYakumanSum:   true,
 		HonbaValue:     100,
 
-		IsSanChaHou:     false,
+		RiichiDeposit:      1000,
+		NotenPenalty:       3000,
+		NagashiManganLimit: LimitMangan,
+		IsNagashiRenchan:   false,
+		IsTenpaiRenchan:    true,
+
+		IsHeadBump:      false,
+		TripleRonPolicy: TripleRonAbort,
 		IsNagashiMangan: true,
+		IsSuuKantsuPao:  false,
+		IsRinshanPao:    false,
+		KuikaePolicy:    KuikaeForbidAll,
+		KuikaePenalty:   1000,
+
+		IsChombo:     false,
+		ChomboPolicy: ChomboMangan,
+		ChomboPoints: 20000,
+
+		IsKyuuShuKyuuHai: true,
+		IsSuuFonRenda:    true,
+		IsSuuChaRiichi:   true,
+		IsSuuKaiKan:      true,
 	}
 }
 
+func GetDefaultSanmaRule() *Rule {
+	// TenhouSanma
+	rule := GetDefaultRule()
+	rule.StartingPoints = 35000
+	rule.TargetPoints = 40000
+	rule.Uma = []int{15000, 0, -15000}
+	rule.NotenPenalty = 2000
+	rule.IsSanma = true
+	rule.IsSanmaTsumoLoss = true
+	return rule
+}
+
 func DefaultDoubleYakumans() map[yaku.Yakuman]bool {
 	return map[yaku.Yakuman]bool{
 		yaku.YakumanChuurenpooto9: true,
@@ -102,24 +250,72 @@ func DefaultDoubleYakumans() map[yaku.Yakuman]bool {
 
 func (r *Rule) MarshalJSON() ([]byte, error) {
 	return json.Marshal(&struct {
-		GameLength           int    `json:"game_length"`
-		IsOpenTanyao         bool   `json:"is_open_tanyao"`
-		HasAkaDora           bool   `json:"has_aka_dora"`
-		RenhouLimit          string `json:"renhou_limit"`
-		IsHaiteiFromLiveOnly bool   `json:"is_haitei_from_live_only"`
-		IsUra                bool   `json:"is_ura"`
-		IsIpatsu             bool   `json:"is_ipatsu"`
-		IsGreenRequired      bool   `json:"is_green_required"`
-		IsRinshanFu          bool   `json:"is_rinshan_fu"`
-		IsManganRound        bool   `json:"is_mangan_round"`
-		IsKazoeYakuman       bool   `json:"is_kazoe_yakuman"`
-		HasDoubleYakumans    bool   `json:"has_double_yakumans"`
-		IsYakumanSum         bool   `json:"is_yakuman_sum"`
-		HonbaValue           int    `json:"honba_value"`
-		IsSanChaHou          bool   `json:"is_san_cha_hou"`
-		IsNagashiMangan      bool   `json:"is_nagashi_mangan"`
+		GameLength           int     `json:"game_length"`
+		StartingPoints       int     `json:"starting_points"`
+		TargetPoints         int     `json:"target_points"`
+		IsTobi               bool    `json:"is_tobi"`
+		IsTobiAtZero         bool    `json:"is_tobi_at_zero"`
+		ExtensionPolicy      string  `json:"extension_policy"`
+		ExtraRounds          int     `json:"extra_rounds"`
+		AgariYamePolicy      string  `json:"agari_yame_policy"`
+		IsTenpaiYame         bool    `json:"is_tenpai_yame"`
+		Uma                  []int   `json:"uma"`
+		FloatingUma          [][]int `json:"floating_uma"`
+		IsOka                bool    `json:"is_oka"`
+		TieBreakPolicy       string  `json:"tie_break_policy"`
+		KyoutakuPolicy       string  `json:"kyoutaku_policy"`
+		RoundingPolicy       string  `json:"rounding_policy"`
+		IsOpenTanyao         bool    `json:"is_open_tanyao"`
+		HasAkaDora           bool    `json:"has_aka_dora"`
+		RenhouLimit          string  `json:"renhou_limit"`
+		IsHaiteiFromLiveOnly bool    `json:"is_haitei_from_live_only"`
+		IsUra                bool    `json:"is_ura"`
+		IsIpatsu             bool    `json:"is_ipatsu"`
+		IsGreenRequired      bool    `json:"is_green_required"`
+		IsRinshanFu          bool    `json:"is_rinshan_fu"`
+		IsManganRound        bool    `json:"is_mangan_round"`
+		IsKazoeYakuman       bool    `json:"is_kazoe_yakuman"`
+		HasDoubleYakumans    bool    `json:"has_double_yakumans"`
+		IsYakumanSum         bool    `json:"is_yakuman_sum"`
+		HonbaValue           int     `json:"honba_value"`
+		RiichiDeposit        int     `json:"riichi_deposit"`
+		NotenPenalty         int     `json:"noten_penalty"`
+		NagashiManganLimit   string  `json:"nagashi_mangan_limit"`
+		IsNagashiRenchan     bool    `json:"is_nagashi_renchan"`
+		IsTenpaiRenchan      bool    `json:"is_tenpai_renchan"`
+		IsHeadBump           bool    `json:"is_head_bump"`
+		TripleRonPolicy      string  `json:"triple_ron_policy"`
+		IsSanChaHou          bool    `json:"is_san_cha_hou"`
+		IsNagashiMangan      bool    `json:"is_nagashi_mangan"`
+		IsSuuKantsuPao       bool    `json:"is_suu_kantsu_pao"`
+		IsRinshanPao         bool    `json:"is_rinshan_pao"`
+		KuikaePolicy         string  `json:"kuikae_policy"`
+		KuikaePenalty        int     `json:"kuikae_penalty"`
+		IsChombo             bool    `json:"is_chombo"`
+		ChomboPolicy         string  `json:"chombo_policy"`
+		ChomboPoints         int     `json:"chombo_points"`
+		IsKyuuShuKyuuHai     bool    `json:"is_kyuu_shu_kyuu_hai"`
+		IsSuuFonRenda        bool    `json:"is_suu_fon_renda"`
+		IsSuuChaRiichi       bool    `json:"is_suu_cha_riichi"`
+		IsSuuKaiKan          bool    `json:"is_suu_kai_kan"`
+		IsSanma              bool    `json:"is_sanma"`
+		IsSanmaTsumoLoss     bool    `json:"is_sanma_tsumo_loss"`
 	}{
 		GameLength:           r.GameLength,
+		StartingPoints:       r.StartingPoints,
+		TargetPoints:         r.TargetPoints,
+		IsTobi:               r.IsTobi,
+		IsTobiAtZero:         r.IsTobiAtZero,
+		ExtensionPolicy:      r.ExtensionPolicy.String(),
+		ExtraRounds:          r.ExtraRounds,
+		AgariYamePolicy:      r.AgariYamePolicy.String(),
+		IsTenpaiYame:         r.IsTenpaiYame,
+		Uma:                  r.Uma,
+		FloatingUma:          r.FloatingUma,
+		IsOka:                r.IsOka,
+		TieBreakPolicy:       r.TieBreakPolicy.String(),
+		KyoutakuPolicy:       r.KyoutakuPolicy.String(),
+		RoundingPolicy:       r.RoundingPolicy.String(),
 		IsOpenTanyao:         r.IsOpenTanyao,
 		HasAkaDora:           r.HasAkaDora,
 		RenhouLimit:          r.RenhouLimit.String(),
@@ -133,34 +329,142 @@ func (r *Rule) MarshalJSON() ([]byte, error) {
 		HasDoubleYakumans:    r.IsDoubleYakumans,
 		IsYakumanSum:         r.IsYakumanSum,
 		HonbaValue:           r.HonbaValue,
+		RiichiDeposit:        r.RiichiDeposit,
+		NotenPenalty:         r.NotenPenalty,
+		NagashiManganLimit:   r.NagashiManganLimit.String(),
+		IsNagashiRenchan:     r.IsNagashiRenchan,
+		IsTenpaiRenchan:      r.IsTenpaiRenchan,
+		IsHeadBump:           r.IsHeadBump,
+		TripleRonPolicy:      r.TripleRonPolicy.String(),
 		IsSanChaHou:          r.IsSanChaHou,
 		IsNagashiMangan:      r.IsNagashiMangan,
+		IsSuuKantsuPao:       r.IsSuuKantsuPao,
+		IsRinshanPao:         r.IsRinshanPao,
+		KuikaePolicy:         r.KuikaePolicy.String(),
+		KuikaePenalty:        r.KuikaePenalty,
+		IsChombo:             r.IsChombo,
+		ChomboPolicy:         r.ChomboPolicy.String(),
+		ChomboPoints:         r.ChomboPoints,
+		IsKyuuShuKyuuHai:     r.IsKyuuShuKyuuHai,
+		IsSuuFonRenda:        r.IsSuuFonRenda,
+		IsSuuChaRiichi:       r.IsSuuChaRiichi,
+		IsSuuKaiKan:          r.IsSuuKaiKan,
+		IsSanma:              r.IsSanma,
+		IsSanmaTsumoLoss:     r.IsSanmaTsumoLoss,
 	})
 }
 
 func (r *Rule) UnmarshalJSON(data []byte) error {
 	var s struct {
-		GameLength           int    `json:"game_length"`
-		IsOpenTanyao         bool   `json:"is_open_tanyao"`
-		HasAkaDora           bool   `json:"has_aka_dora"`
-		RenhouLimit          string `json:"renhou_limit"`
-		IsHaiteiFromLiveOnly bool   `json:"is_haitei_from_live_only"`
-		IsUra                bool   `json:"is_ura"`
-		IsIpatsu             bool   `json:"is_ipatsu"`
-		IsGreenRequired      bool   `json:"is_green_required"`
-		IsRinshanFu          bool   `json:"is_rinshan_fu"`
-		IsManganRound        bool   `json:"is_mangan_round"`
-		IsKazoeYakuman       bool   `json:"is_kazoe_yakuman"`
-		HasDoubleYakumans    bool   `json:"has_double_yakumans"`
-		IsYakumanSum         bool   `json:"is_yakuman_sum"`
-		HonbaValue           int    `json:"honba_value"`
-		IsSanChaHou          bool   `json:"is_san_cha_hou"`
-		IsNagashiMangan      bool   `json:"is_nagashi_mangan"`
+		GameLength           int     `json:"game_length"`
+		StartingPoints       int     `json:"starting_points"`
+		TargetPoints         int     `json:"target_points"`
+		IsTobi               bool    `json:"is_tobi"`
+		IsTobiAtZero         bool    `json:"is_tobi_at_zero"`
+		ExtensionPolicy      string  `json:"extension_policy"`
+		ExtraRounds          int     `json:"extra_rounds"`
+		AgariYamePolicy      string  `json:"agari_yame_policy"`
+		IsTenpaiYame         bool    `json:"is_tenpai_yame"`
+		Uma                  []int   `json:"uma"`
+		FloatingUma          [][]int `json:"floating_uma"`
+		IsOka                bool    `json:"is_oka"`
+		TieBreakPolicy       string  `json:"tie_break_policy"`
+		KyoutakuPolicy       string  `json:"kyoutaku_policy"`
+		RoundingPolicy       string  `json:"rounding_policy"`
+		IsOpenTanyao         bool    `json:"is_open_tanyao"`
+		HasAkaDora           bool    `json:"has_aka_dora"`
+		RenhouLimit          string  `json:"renhou_limit"`
+		IsHaiteiFromLiveOnly bool    `json:"is_haitei_from_live_only"`
+		IsUra                bool    `json:"is_ura"`
+		IsIpatsu             bool    `json:"is_ipatsu"`
+		IsGreenRequired      bool    `json:"is_green_required"`
+		IsRinshanFu          bool    `json:"is_rinshan_fu"`
+		IsManganRound        bool    `json:"is_mangan_round"`
+		IsKazoeYakuman       bool    `json:"is_kazoe_yakuman"`
+		HasDoubleYakumans    bool    `json:"has_double_yakumans"`
+		IsYakumanSum         bool    `json:"is_yakuman_sum"`
+		HonbaValue           int     `json:"honba_value"`
+		RiichiDeposit        int     `json:"riichi_deposit"`
+		NotenPenalty         *int    `json:"noten_penalty"`
+		NagashiManganLimit   string  `json:"nagashi_mangan_limit"`
+		IsNagashiRenchan     bool    `json:"is_nagashi_renchan"`
+		IsTenpaiRenchan      bool    `json:"is_tenpai_renchan"`
+		IsHeadBump           bool    `json:"is_head_bump"`
+		TripleRonPolicy      string  `json:"triple_ron_policy"`
+		IsSanChaHou          bool    `json:"is_san_cha_hou"`
+		IsNagashiMangan      bool    `json:"is_nagashi_mangan"`
+		IsSuuKantsuPao       bool    `json:"is_suu_kantsu_pao"`
+		IsRinshanPao         bool    `json:"is_rinshan_pao"`
+		KuikaePolicy         string  `json:"kuikae_policy"`
+		KuikaePenalty        int     `json:"kuikae_penalty"`
+		IsChombo             bool    `json:"is_chombo"`
+		ChomboPolicy         string  `json:"chombo_policy"`
+		ChomboPoints         int     `json:"chombo_points"`
+		IsKyuuShuKyuuHai     bool    `json:"is_kyuu_shu_kyuu_hai"`
+		IsSuuFonRenda        bool    `json:"is_suu_fon_renda"`
+		IsSuuChaRiichi       bool    `json:"is_suu_cha_riichi"`
+		IsSuuKaiKan          bool    `json:"is_suu_kai_kan"`
+		IsSanma              bool    `json:"is_sanma"`
+		IsSanmaTsumoLoss     bool    `json:"is_sanma_tsumo_loss"`
+	}
+	// the fields not specified keep the default rule of the number of players
+	var mode struct {
+		IsSanma bool `json:"is_sanma"`
 	}
+	if err := json.Unmarshal(data, &mode); err != nil {
+		return err
+	}
+	defaultRule := GetDefaultRule()
+	if mode.IsSanma {
+		defaultRule = GetDefaultSanmaRule()
+	}
+	s.StartingPoints = defaultRule.StartingPoints
+	s.TargetPoints = defaultRule.TargetPoints
+	s.IsTobi = defaultRule.IsTobi
+	s.ExtensionPolicy = defaultRule.ExtensionPolicy.String()
+	s.AgariYamePolicy = defaultRule.AgariYamePolicy.String()
+	s.Uma = defaultRule.Uma
+	s.IsOka = defaultRule.IsOka
+	s.TieBreakPolicy = defaultRule.TieBreakPolicy.String()
+	s.KyoutakuPolicy = defaultRule.KyoutakuPolicy.String()
+	s.RoundingPolicy = defaultRule.RoundingPolicy.String()
+	s.RiichiDeposit = defaultRule.RiichiDeposit
+	s.NagashiManganLimit = defaultRule.NagashiManganLimit.String()
+	s.IsTenpaiRenchan = defaultRule.IsTenpaiRenchan
+	s.KuikaePolicy = defaultRule.KuikaePolicy.String()
+	s.KuikaePenalty = defaultRule.KuikaePenalty
+	s.ChomboPolicy = defaultRule.ChomboPolicy.String()
+	s.ChomboPoints = defaultRule.ChomboPoints
+	s.IsKyuuShuKyuuHai = defaultRule.IsKyuuShuKyuuHai
+	s.IsSuuFonRenda = defaultRule.IsSuuFonRenda
+	s.IsSuuChaRiichi = defaultRule.IsSuuChaRiichi
+	s.IsSuuKaiKan = defaultRule.IsSuuKaiKan
+	s.IsSanmaTsumoLoss = defaultRule.IsSanmaTsumoLoss
 	if err := json.Unmarshal(data, &s); err != nil {
 		return err
 	}
+	if s.TripleRonPolicy == "" {
+		s.TripleRonPolicy = defaultRule.TripleRonPolicy.String()
+	}
+	if s.NotenPenalty == nil {
+		notenPenalty := defaultRule.NotenPenalty
+		s.NotenPenalty = &notenPenalty
+	}
 	r.GameLength = s.GameLength
+	r.StartingPoints = s.StartingPoints
+	r.TargetPoints = s.TargetPoints
+	r.IsTobi = s.IsTobi
+	r.IsTobiAtZero = s.IsTobiAtZero
+	r.ExtensionPolicy = MapStringToExtensionPolicy[s.ExtensionPolicy]
+	r.ExtraRounds = s.ExtraRounds
+	r.AgariYamePolicy = MapStringToAgariYamePolicy[s.AgariYamePolicy]
+	r.IsTenpaiYame = s.IsTenpaiYame
+	r.Uma = s.Uma
+	r.FloatingUma = s.FloatingUma
+	r.IsOka = s.IsOka
+	r.TieBreakPolicy = MapStringToTieBreakPolicy[s.TieBreakPolicy]
+	r.KyoutakuPolicy = MapStringToKyoutakuPolicy[s.KyoutakuPolicy]
+	r.RoundingPolicy = MapStringToRoundingPolicy[s.RoundingPolicy]
 	r.IsOpenTanyao = s.IsOpenTanyao
 	r.HasAkaDora = s.HasAkaDora
 	r.RenhouLimit = MapStringToLimit[s.RenhouLimit]
@@ -174,7 +478,27 @@ func (r *Rule) UnmarshalJSON(data []byte) error {
 	r.IsDoubleYakumans = s.HasDoubleYakumans
 	r.IsYakumanSum = s.IsYakumanSum
 	r.HonbaValue = s.HonbaValue
+	r.RiichiDeposit = s.RiichiDeposit
+	r.NotenPenalty = *s.NotenPenalty
+	r.NagashiManganLimit = MapStringToLimit[s.NagashiManganLimit]
+	r.IsNagashiRenchan = s.IsNagashiRenchan
+	r.IsTenpaiRenchan = s.IsTenpaiRenchan
+	r.IsHeadBump = s.IsHeadBump
+	r.TripleRonPolicy = MapStringToTripleRonPolicy[s.TripleRonPolicy]
 	r.IsSanChaHou = s.IsSanChaHou
 	r.IsNagashiMangan = s.IsNagashiMangan
+	r.IsSuuKantsuPao = s.IsSuuKantsuPao
+	r.IsRinshanPao = s.IsRinshanPao
+	r.KuikaePolicy = MapStringToKuikaePolicy[s.KuikaePolicy]
+	r.KuikaePenalty = s.KuikaePenalty
+	r.IsChombo = s.IsChombo
+	r.ChomboPolicy = MapStringToChomboPolicy[s.ChomboPolicy]
+	r.ChomboPoints = s.ChomboPoints
+	r.IsKyuuShuKyuuHai = s.IsKyuuShuKyuuHai
+	r.IsSuuFonRenda = s.IsSuuFonRenda
+	r.IsSuuChaRiichi = s.IsSuuChaRiichi
+	r.IsSuuKaiKan = s.IsSuuKaiKan
+	r.IsSanma = s.IsSanma
+	r.IsSanmaTsumoLoss = s.IsSanmaTsumoLoss
 	return nil
 }
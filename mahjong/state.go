@@ -10,6 +10,7 @@ type gameState interface {
 	step() map[Wind]Calls
 	next(posCalls map[Wind]*Call) error
 	String() string
+	clone(g *Game) gameState // copy the state for the cloned game
 }
 
 type InitState struct {
@@ -29,6 +30,10 @@ func (s *InitState) step() map[Wind]Calls {
 		sort.Sort(&player.HandTiles)
 		player.Wind = wind
 		player.ShantenNum = player.GetShantenNum()
+		if player.ShantenNum == 0 {
+			// dealt tenpai, can ron before the first discard
+			player.TenpaiSlice = player.GetTenpaiSlice()
+		}
 		posEvent[wind] = &EventStart{
 			WindRound:         s.g.WindRound,
 			InitWind:          wind,
@@ -57,12 +62,19 @@ func (s *InitState) next(posCalls map[Wind]*Call) error {
 		return errors.New("invalid call nums")
 	}
 	s.g.State = &DealState{
-		s.g,
-		false,
+		g: s.g,
 	}
 	return nil
 }
 
+func (s *InitState) clone(g *Game) gameState {
+	state := &InitState{g: g}
+	if s.tiles != nil {
+		state.tiles = s.tiles.Copy()
+	}
+	return state
+}
+
 func (s *InitState) String() string {
 	return "Init"
 }
@@ -70,14 +82,20 @@ func (s *InitState) String() string {
 type DealState struct {
 	g           *Game
 	dealRinshan bool
+	dealKita    bool // rinshan tile after kita, no new indicator
 }
 
 func (s *DealState) step() map[Wind]Calls {
 	if s.g.GetNumRemainTiles() == 0 {
 		return make(map[Wind]Calls)
 	}
-	tile := s.g.Tiles.DealTile(s.dealRinshan)
-	if s.dealRinshan {
+	var tile Tile
+	if s.dealKita {
+		tile = s.g.Tiles.DealKitaTile()
+	} else {
+		tile = s.g.Tiles.DealTile(s.dealRinshan)
+	}
+	if s.dealRinshan && !s.dealKita {
 		// generate new indicator event
 		indicatorTileID := s.g.Tiles.GetCurrentIndicator()
 		var posEvent = make(map[Wind]Event)
@@ -125,13 +143,8 @@ func (s *DealState) next(posCalls map[Wind]*Call) error {
 			panic("remain tiles not 0")
 		}
 		s.g.State = &EndState{
-			g: s.g,
-			posResults: map[Wind]*Result{
-				East:  {RyuuKyokuReason: RyuuKyokuNormal},
-				South: {RyuuKyokuReason: RyuuKyokuNormal},
-				West:  {RyuuKyokuReason: RyuuKyokuNormal},
-				North: {RyuuKyokuReason: RyuuKyokuNormal},
-			},
+			g:          s.g,
+			posResults: s.g.getRyuuKyokuResults(RyuuKyokuNormal),
 		}
 		return nil
 	}
@@ -143,7 +156,7 @@ func (s *DealState) next(posCalls map[Wind]*Call) error {
 	switch call.CallType {
 	case Discard:
 		furitenBef := pMain.IsFuriten()
-		s.g.discardTileProcess(pMain, call.CallTiles[0])
+		s.g.discardTileProcess(pMain, call.CallTiles[0], true)
 		tsumoGiri := pMain.TilesTsumoGiri[len(pMain.TilesTsumoGiri)-1]
 		s.g.State = &DiscardState{
 			g:                s.g,
@@ -194,6 +207,12 @@ func (s *DealState) next(posCalls map[Wind]*Call) error {
 			g:          s.g,
 			posResults: map[Wind]*Result{pMain.Wind: result},
 		}
+	case Kita:
+		s.g.processKita(pMain, call)
+		s.g.State = &KitaState{
+			g:    s.g,
+			call: call,
+		}
 	case KyuuShuKyuuHai:
 		s.g.processKyuuShuKyuuHai()
 		s.g.State = &EndState{
@@ -206,6 +225,12 @@ func (s *DealState) next(posCalls map[Wind]*Call) error {
 	return nil
 }
 
+func (s *DealState) clone(g *Game) gameState {
+	state := *s
+	state.g = g
+	return &state
+}
+
 func (s *DealState) String() string {
 	return "After Deal"
 }
@@ -215,6 +240,7 @@ type DiscardState struct {
 	tileID           Tile
 	tsumoGiri        bool
 	isFuritenChanged bool
+	isKuikae         bool // the discard is restricted by kuikae, the penalty is paid
 }
 
 // step after one player discard a tile
@@ -268,6 +294,19 @@ func (s *DiscardState) step() map[Wind]Calls {
 	}
 	s.g.addPosEvent(posEvent)
 
+	// generate kuikae penalty event
+	if s.isKuikae {
+		posEvent = make(map[Wind]Event)
+		for wind := range s.g.PosPlayer {
+			posEvent[wind] = &EventKuikae{
+				Who:    s.g.Position,
+				Tile:   s.tileID,
+				Points: s.g.Rule.KuikaePenalty,
+			}
+		}
+		s.g.addPosEvent(posEvent)
+	}
+
 	// self player furiten check
 	posEvent = make(map[Wind]Event)
 	if s.isFuritenChanged {
@@ -290,73 +329,10 @@ func (s *DiscardState) step() map[Wind]Calls {
 
 func (s *DiscardState) next(posCalls map[Wind]*Call) error {
 	// other players furiten check
-	for _, wind := range s.g.getOtherWinds() {
-		player := s.g.PosPlayer[wind]
-		if !player.FuritenStatus {
-			continue
-		}
-		if _, ok := posCalls[wind]; !ok {
-			continue
-		}
-		if posCalls[wind].CallType == Ron {
-			continue
-		}
-		var furitenReason FuritenReason
-		if player.IsRiichi {
-			player.RiichiFuriten = true
-			furitenReason = FuritenRiichi
-		} else {
-			player.JunFuriten = true
-			furitenReason = FuritenJun
-		}
-		furitenEvent := &EventFuriten{
-			Who:           wind,
-			FuritenReason: furitenReason,
-		}
-		s.g.addPosEvent(map[Wind]Event{
-			wind: furitenEvent,
-		})
-	}
+	s.g.processSkipFuriten(posCalls)
 
 	pMain := s.g.PosPlayer[s.g.Position]
-	// if there is no call after discard, then next player deal
-	if len(posCalls) == 0 {
-		if s.g.judgeSuuChaRiichi() {
-			// if suu cha riichi
-			s.g.State = &EndState{
-				g: s.g,
-				posResults: map[Wind]*Result{
-					East:  {RyuuKyokuReason: RyuuKyokuSuuChaRiichi},
-					South: {RyuuKyokuReason: RyuuKyokuSuuChaRiichi},
-					West:  {RyuuKyokuReason: RyuuKyokuSuuChaRiichi},
-					North: {RyuuKyokuReason: RyuuKyokuSuuChaRiichi},
-				},
-			}
-			return nil
-		}
-
-		if pMain.RiichiStep == 1 {
-			var posEvent map[Wind]Event
-			s.g.processRiichiStep2(pMain)
-			// generate riichi step 2 event
-			posEvent = make(map[Wind]Event)
-			for wind := range s.g.PosPlayer {
-				posEvent[wind] = &EventRiichi{
-					Who:  pMain.Wind,
-					Step: 2,
-				}
-			}
-			s.g.addPosEvent(posEvent)
-		}
-		s.g.Position = (s.g.Position + 1) % 4
-		s.g.State = &DealState{
-			s.g,
-			false,
-		}
-		return nil
-	}
-
-	// if there are calls, then process it
+	// find the max call, skip if there is no call
 	var maxCallType = Skip
 	for _, call := range posCalls {
 		if call.CallType > maxCallType {
@@ -380,30 +356,7 @@ func (s *DiscardState) next(posCalls map[Wind]*Call) error {
 		}
 		return nil
 	} else {
-		// if max call is not ron
-		if s.g.judgeSuuChaRiichi() {
-			// if suu cha riichi
-			s.g.State = &EndState{
-				g: s.g,
-				posResults: map[Wind]*Result{
-					East:  {RyuuKyokuReason: RyuuKyokuSuuChaRiichi},
-					South: {RyuuKyokuReason: RyuuKyokuSuuChaRiichi},
-					West:  {RyuuKyokuReason: RyuuKyokuSuuChaRiichi},
-					North: {RyuuKyokuReason: RyuuKyokuSuuChaRiichi},
-				},
-			}
-			return nil
-		}
-
-		var wind Wind
-		var call *Call
-		for w, c := range posCalls {
-			if c.CallType == maxCallType {
-				wind = w
-				call = c
-				break
-			}
-		}
+		// if max call is not ron, the riichi is established
 		if pMain.RiichiStep == 1 {
 			var posEvent map[Wind]Event
 			s.g.processRiichiStep2(pMain)
@@ -417,15 +370,41 @@ func (s *DiscardState) next(posCalls map[Wind]*Call) error {
 			}
 			s.g.addPosEvent(posEvent)
 		}
+
+		// judge abortive draws after the discard passes
+		var ryuuKyokuReason = NoRyuuKyoku
+		if s.g.judgeSuuFonRenDa() {
+			ryuuKyokuReason = RyuuKyokuSuufonRenda
+		} else if s.g.judgeSuuChaRiichi() {
+			ryuuKyokuReason = RyuuKyokuSuuChaRiichi
+		} else if s.g.judgeSuuKaiKan() {
+			ryuuKyokuReason = RyuuKyokuSuuKaiKan
+		}
+		if ryuuKyokuReason != NoRyuuKyoku {
+			s.g.State = &EndState{
+				g:          s.g,
+				posResults: s.g.getRyuuKyokuResults(ryuuKyokuReason),
+			}
+			return nil
+		}
+
 		// if max call is skip, then next player deal
 		if maxCallType == Skip {
-			s.g.Position = (s.g.Position + 1) % 4
+			s.g.Position = s.g.getNextWind(s.g.Position)
 			s.g.State = &DealState{
-				g:           s.g,
-				dealRinshan: false,
+				g: s.g,
 			}
 			return nil
 		}
+		var wind Wind
+		var call *Call
+		for w, c := range posCalls {
+			if c.CallType == maxCallType {
+				wind = w
+				call = c
+				break
+			}
+		}
 		player := s.g.PosPlayer[wind]
 		s.g.Position = wind
 		switch call.CallType {
@@ -459,6 +438,12 @@ func (s *DiscardState) next(posCalls map[Wind]*Call) error {
 	return nil
 }
 
+func (s *DiscardState) clone(g *Game) gameState {
+	state := *s
+	state.g = g
+	return &state
+}
+
 func (s *DiscardState) String() string {
 	return "After Discard"
 }
@@ -468,6 +453,12 @@ type ChiPonState struct {
 	call *Call
 }
 
+func (s *ChiPonState) clone(g *Game) gameState {
+	state := *s
+	state.g = g
+	return &state
+}
+
 func (s *ChiPonState) String() string {
 	return "After Chi Pon"
 }
@@ -480,37 +471,25 @@ func (s *ChiPonState) step() map[Wind]Calls {
 
 	// generate event
 	var posEvent = make(map[Wind]Event)
-	var event Event
-	switch s.call.CallType {
-	case Chi:
-		event = &EventChi{
-			Who:  s.g.Position,
-			Call: s.call,
-		}
-	case Pon:
-		event = &EventPon{
-			Who:  s.g.Position,
-			Call: s.call,
-		}
-	}
 	for wind := range s.g.PosPlayer {
-		if wind == s.g.Position {
-			switch event.GetType() {
-			case EventTypeChi:
-				event = &EventChi{
-					Who:         s.g.Position,
-					Call:        s.call,
-					TenpaiInfos: GetTenpaiInfos(s.g, pMain),
-				}
-			case EventTypePon:
-				event = &EventPon{
-					Who:         s.g.Position,
-					Call:        s.call,
-					TenpaiInfos: GetTenpaiInfos(s.g, pMain),
-				}
+		var tenpaiInfos TenpaiInfos
+		if wind == pMain.Wind {
+			tenpaiInfos = GetTenpaiInfos(s.g, pMain)
+		}
+		switch s.call.CallType {
+		case Chi:
+			posEvent[wind] = &EventChi{
+				Who:         s.g.Position,
+				Call:        s.call,
+				TenpaiInfos: tenpaiInfos,
+			}
+		case Pon:
+			posEvent[wind] = &EventPon{
+				Who:         s.g.Position,
+				Call:        s.call,
+				TenpaiInfos: tenpaiInfos,
 			}
 		}
-		posEvent[wind] = event
 	}
 	s.g.addPosEvent(posEvent)
 	return validCalls
@@ -523,12 +502,14 @@ func (s *ChiPonState) next(posCalls map[Wind]*Call) error {
 	}
 	player := s.g.PosPlayer[s.g.Position]
 	tileID := posCalls[s.g.Position].CallTiles[0]
-	s.g.discardTileProcess(player, tileID)
+	isKuikae := s.g.processKuikaePenalty(player, tileID)
+	s.g.discardTileProcess(player, tileID, false)
 	tsumoGiri := player.TilesTsumoGiri[len(player.TilesTsumoGiri)-1]
 	s.g.State = &DiscardState{
 		g:         s.g,
 		tileID:    tileID,
 		tsumoGiri: tsumoGiri,
+		isKuikae:  isKuikae,
 	}
 	return nil
 }
@@ -547,7 +528,7 @@ func (s *KanState) step() map[Wind]Calls {
 			continue
 		}
 		if calls := s.g.judgeChanKan(player, s.call.CallTiles[3], s.call.CallType == AnKan); len(calls) > 0 {
-			validCalls[wind] = calls
+			validCalls[wind] = append(Calls{SkipCall}, calls...)
 		}
 	}
 
@@ -580,32 +561,21 @@ func (s *KanState) step() map[Wind]Calls {
 }
 
 func (s *KanState) next(posCalls map[Wind]*Call) error {
+	var posResults = make(map[Wind]*Result)
+	for wind, call := range posCalls {
+		if call.CallType != ChanKan {
+			continue
+		}
+		posResults[wind] = s.g.processChanKan(s.g.PosPlayer[wind], call)
+	}
 	// if there is no chan kan call
-	if len(posCalls) == 0 {
+	if len(posResults) == 0 {
 		s.g.PosPlayer[s.g.Position].KanNum++
-		if s.g.judgeSuuKaiKan() {
-			s.g.State = &EndState{
-				g: s.g,
-				posResults: map[Wind]*Result{
-					East:  {RyuuKyokuReason: RyuuKyokuSuuKaiKan},
-					South: {RyuuKyokuReason: RyuuKyokuSuuKaiKan},
-					West:  {RyuuKyokuReason: RyuuKyokuSuuKaiKan},
-					North: {RyuuKyokuReason: RyuuKyokuSuuKaiKan},
-				},
-			}
-			return nil
-		}
 		s.g.State = &DealState{
 			g:           s.g,
 			dealRinshan: true,
 		}
 	} else {
-		var posResults = make(map[Wind]*Result)
-		// if there are chan kan call, process it
-		for wind, call := range posCalls {
-			player := s.g.PosPlayer[wind]
-			posResults[wind] = s.g.processChanKan(player, call)
-		}
 		s.g.State = &EndState{
 			g:          s.g,
 			posResults: posResults,
@@ -614,122 +584,204 @@ func (s *KanState) next(posCalls map[Wind]*Call) error {
 	return nil
 }
 
+func (s *KanState) clone(g *Game) gameState {
+	state := *s
+	state.g = g
+	return &state
+}
+
 func (s *KanState) String() string {
 	return "After Kan"
 }
 
+type KitaState struct {
+	g    *Game
+	call *Call
+}
+
+// step after one player kita, other players can ron the north tile
+func (s *KitaState) step() map[Wind]Calls {
+	tileID := s.call.CallTiles[0]
+	var validCalls = make(map[Wind]Calls)
+	for wind, player := range s.g.PosPlayer {
+		if wind == s.g.Position {
+			continue
+		}
+		if calls := s.g.judgeRon(player, tileID); len(calls) > 0 {
+			validCalls[wind] = append(Calls{SkipCall}, calls...)
+		}
+	}
+
+	// generate event
+	var posEvent = make(map[Wind]Event)
+	for wind := range s.g.PosPlayer {
+		posEvent[wind] = &EventKita{
+			Who:  s.g.Position,
+			Tile: tileID,
+		}
+	}
+	s.g.addPosEvent(posEvent)
+	return validCalls
+}
+
+func (s *KitaState) next(posCalls map[Wind]*Call) error {
+	s.g.processSkipFuriten(posCalls)
+
+	var posResults = make(map[Wind]*Result)
+	for wind, call := range posCalls {
+		if call.CallType != Ron {
+			continue
+		}
+		posResults[wind] = s.g.processRon(s.g.PosPlayer[wind], call)
+	}
+	if len(posResults) == 0 {
+		s.g.State = &DealState{
+			g:           s.g,
+			dealRinshan: true,
+			dealKita:    true,
+		}
+		return nil
+	}
+	s.g.State = &EndState{
+		g:          s.g,
+		posResults: posResults,
+	}
+	return nil
+}
+
+func (s *KitaState) clone(g *Game) gameState {
+	state := *s
+	state.g = g
+	return &state
+}
+
+func (s *KitaState) String() string {
+	return "After Kita"
+}
+
 type EndState struct {
-	g          *Game
-	posResults map[Wind]*Result
+	g            *Game
+	posResults   map[Wind]*Result
+	isTenpaiYame bool  // the dealer can stop the game by tenpai yame
+	chomboWind   Wind  // the offender of the chombo
+	chomboCall   *Call // the illegal call, nil for no chombo
 }
 
 func (s *EndState) step() map[Wind]Calls {
-	var prePoints = map[Wind]int{
-		East:  s.g.PosPlayer[East].Points,
-		South: s.g.PosPlayer[South].Points,
-		West:  s.g.PosPlayer[West].Points,
-		North: s.g.PosPlayer[North].Points,
+	var prePoints = make(map[Wind]int)
+	for wind, player := range s.g.PosPlayer {
+		prePoints[wind] = player.Points
 	}
 	var pointsChanges = make(map[Wind]int)
 	var posEvent = make(map[Wind]Event)
 
-	switch len(s.posResults) {
-	case 4:
-		// ryuu kyoku
-		if s.posResults[East].RyuuKyokuReason == RyuuKyokuNormal {
-			TenpaiWinds := s.g.judgeTenpaiWinds()
-			if s.g.Rule.IsNagashiMangan {
-				// judge ryuu kyoku mangan
-				bSlice := s.g.judgeNagashiMangan()
-				if len(bSlice) != 0 {
-					s.g.processNagashiMangan(bSlice)
-					// generate nagashi mangan events
-					for _, wind := range bSlice {
-						for w := range s.g.PosPlayer {
-							posEvent[w] = &EventNagashiMangan{
-								Who: wind,
-							}
+	// ryuu kyoku results are given to all players
+	var ryuuKyokuReason = NoRyuuKyoku
+	if len(s.posResults) == len(s.g.PosPlayer) {
+		ryuuKyokuReason = s.posResults[East].RyuuKyokuReason
+	}
+
+	switch {
+	case s.chomboCall != nil:
+		// chombo, the hand is redealt without moving the dealer or adding honba
+		chomboChange := s.g.processChombo(s.chomboWind)
+		for w := range s.g.PosPlayer {
+			posEvent[w] = &EventChombo{
+				Who:          s.chomboWind,
+				Call:         s.chomboCall,
+				Policy:       s.g.Rule.ChomboPolicy,
+				PointsChange: chomboChange,
+			}
+		}
+		s.g.addPosEvent(posEvent)
+		posEvent = make(map[Wind]Event)
+
+	case ryuuKyokuReason == RyuuKyokuNormal:
+		TenpaiWinds := s.g.judgeTenpaiWinds()
+		var bSlice []Wind
+		if s.g.Rule.IsNagashiMangan {
+			// judge ryuu kyoku mangan
+			bSlice = s.g.judgeNagashiMangan()
+			if len(bSlice) != 0 {
+				s.g.processNagashiMangan(bSlice)
+				// generate nagashi mangan events
+				for _, wind := range bSlice {
+					for w := range s.g.PosPlayer {
+						posEvent[w] = &EventNagashiMangan{
+							Who: wind,
 						}
-						s.g.addPosEvent(posEvent)
-						posEvent = make(map[Wind]Event)
 					}
-
-				} else {
-					s.g.processNormalRyuuKyoku(TenpaiWinds)
+					s.g.addPosEvent(posEvent)
+					posEvent = make(map[Wind]Event)
 				}
+
 			} else {
-				// process normal ryuu kyoku
 				s.g.processNormalRyuuKyoku(TenpaiWinds)
 			}
+		} else {
+			// process normal ryuu kyoku
+			s.g.processNormalRyuuKyoku(TenpaiWinds)
+		}
+		for _, wind := range TenpaiWinds {
+			player := s.g.PosPlayer[wind]
 			for w := range s.g.PosPlayer {
-				for _, wind := range TenpaiWinds {
-					player := s.g.PosPlayer[wind]
-					posEvent[w] = &EventTenpaiEnd{
-						Who:         wind,
-						HandTiles:   player.HandTiles,
-						TenpaiSlice: player.TenpaiSlice,
-					}
+				posEvent[w] = &EventTenpaiEnd{
+					Who:         wind,
+					HandTiles:   player.HandTiles,
+					TenpaiSlice: player.TenpaiSlice,
 				}
-				s.g.addPosEvent(posEvent)
-				posEvent = make(map[Wind]Event)
 			}
+			s.g.addPosEvent(posEvent)
+			posEvent = make(map[Wind]Event)
+		}
 
-			if !common.SliceContain(TenpaiWinds, East) {
-				// east not ten hai
+		switch {
+		case len(bSlice) != 0 && s.g.Rule.IsNagashiRenchan:
+			// nagashi mangan counts as a win, the dealer keeps the seat only as one of the winners
+			if common.SliceContain(bSlice, East) {
+				s.g.honbaPlus = true
+			} else {
 				s.g.nextRound = true
 			}
+		case !s.g.Rule.IsTenpaiRenchan || !common.SliceContain(TenpaiWinds, East):
+			// east not ten hai, or the dealer keeps only by winning(agari renchan)
+			s.g.nextRound = true
 			s.g.honbaPlus = true
-
-		} else {
-			// process special ryuu kyoku
+		default:
 			s.g.honbaPlus = true
 		}
-		for wind, result := range s.posResults {
+		s.addRyuuKyokuEvents()
+
+	case ryuuKyokuReason != NoRyuuKyoku:
+		// process abortive ryuu kyoku, dealer keeps
+		s.g.honbaPlus = true
+		s.addRyuuKyokuEvents()
+
+	case len(s.posResults) == 3 && !s.g.Rule.IsSanma && s.g.Rule.tripleRonPolicy() == TripleRonAbort:
+		// san cha ron not allowed
+		// generate ryuu kyoku events
+		for wind := range s.g.PosPlayer {
 			posEvent[wind] = &EventRyuuKyoku{
 				Who:    wind,
-				Reason: result.RyuuKyokuReason,
+				Reason: RyuuKyokuSanChaHou,
 			}
 		}
 		s.g.addPosEvent(posEvent)
 		posEvent = make(map[Wind]Event)
 
-	case 3:
-		// san cha ron
-		if !s.g.Rule.IsSanChaHou {
-			// san cha ron not allowed
-			// generate ryuu kyoku events
-			for wind := range s.g.PosPlayer {
-				posEvent[wind] = &EventRyuuKyoku{
-					Who:    wind,
-					Reason: RyuuKyokuSanChaHou,
-				}
-			}
-			s.g.addPosEvent(posEvent)
-			posEvent = make(map[Wind]Event)
-
-			_, ok := s.posResults[East]
-			if !ok {
-				s.g.nextRound = true
-			}
-			s.g.honbaPlus = true
-		} else {
-			s.g.processRonResult(s.posResults)
-			// generate san cha ron events
-			s.g.addRonEvents(s.posResults)
+		// abortive draw, dealer keeps, the aborted ron results are not wins
+		s.g.honbaPlus = true
+		s.posResults = make(map[Wind]*Result)
 
-			_, ok := s.posResults[East]
-			if ok {
-				s.g.honbaPlus = true
-			} else {
-				s.g.nextRound = true
-			}
+	case len(s.posResults) > 1:
+		// double ron or triple ron, the players bumped by the head bump don't win
+		if (len(s.posResults) == 2 && s.g.Rule.IsHeadBump) ||
+			(len(s.posResults) == 3 && s.g.Rule.tripleRonPolicy() == TripleRonHeadBump) {
+			headWind := s.g.sortWindsFromDiscarder(s.posResults)[0]
+			s.posResults = map[Wind]*Result{headWind: s.posResults[headWind]}
 		}
+		s.processRonResults()
 
-	case 2:
-		// double ron
-		s.g.processRonResult(s.posResults)
-		// generate double ron events
-		s.g.addRonEvents(s.posResults)
 	default:
 		// normal ron, tsumo, chankan, kyuushukyuuhai
 		var wind Wind
@@ -750,32 +802,24 @@ func (s *EndState) step() map[Wind]Calls {
 			s.g.addPosEvent(posEvent)
 			posEvent = make(map[Wind]Event)
 
-			if wind != East {
-				s.g.nextRound = true
-			}
+			// abortive draw, dealer keeps
 			s.g.honbaPlus = true
+		} else if result.RonCall.CallType != Tsumo {
+			s.processRonResults()
 		} else {
-			if result.RonCall.CallType != Tsumo {
-				s.g.processRonResult(s.posResults)
-				// generate ron events
-				s.g.addRonEvents(s.posResults)
-			} else {
-				if result.RonCall.CallType != Tsumo {
-					panic("result.RonCall.CallType != Tsumo")
-				}
-				s.g.processTsumoResult(wind, result)
-				// generate tsumo events
-				for w := range s.g.PosPlayer {
-					posEvent[w] = &EventTsumo{
-						Who:       wind,
-						HandTiles: s.g.PosPlayer[wind].HandTiles,
-						WinTile:   result.RonCall.CallTiles[0],
-						Result:    result,
-					}
+			s.g.processTsumoResult(wind, result)
+			// generate tsumo events
+			for w := range s.g.PosPlayer {
+				posEvent[w] = &EventTsumo{
+					Who:       wind,
+					PaoWho:    s.g.getPaoWind(wind, result),
+					HandTiles: s.g.PosPlayer[wind].HandTiles,
+					WinTile:   result.RonCall.CallTiles[0],
+					Result:    result,
 				}
-				s.g.addPosEvent(posEvent)
-				posEvent = make(map[Wind]Event)
 			}
+			s.g.addPosEvent(posEvent)
+			posEvent = make(map[Wind]Event)
 
 			if wind == East {
 				s.g.honbaPlus = true
@@ -801,24 +845,82 @@ func (s *EndState) step() map[Wind]Calls {
 	if s.g.CheckGameEnd() {
 		return make(map[Wind]Calls)
 	}
-	return map[Wind]Calls{
-		East:  {NextCall},
-		South: {NextCall},
-		West:  {NextCall},
-		North: {NextCall},
+	var validCalls = make(map[Wind]Calls)
+	for wind := range s.g.PosPlayer {
+		validCalls[wind] = Calls{NextCall}
+	}
+	if s.judgeAgariYame() {
+		if s.g.Rule.AgariYamePolicy == AgariYameAuto {
+			s.g.processAgariYame(s.isTenpaiYame)
+			return make(map[Wind]Calls)
+		}
+		validCalls[East] = append(validCalls[East], AgariYameCall)
 	}
+	return validCalls
+}
+
+// judgeAgariYame judge the dealer can stop the game after winning or tenpai(if tenpai yame is allowed)
+func (s *EndState) judgeAgariYame() bool {
+	if result, ok := s.posResults[East]; ok && result.RonCall != nil {
+		s.isTenpaiYame = false
+		return s.g.judgeAgariYame()
+	}
+	if result, ok := s.posResults[East]; ok && result.RyuuKyokuReason == RyuuKyokuNormal && s.g.Rule.IsTenpaiYame {
+		s.isTenpaiYame = true
+		return s.g.judgeAgariYame()
+	}
+	return false
+}
+
+// processRonResults settles all ron results, the dealer keeps the seat if the dealer is one of the winners
+func (s *EndState) processRonResults() {
+	s.g.processRonResult(s.posResults)
+	s.g.addRonEvents(s.posResults)
+
+	if _, ok := s.posResults[East]; ok {
+		s.g.honbaPlus = true
+	} else {
+		s.g.nextRound = true
+	}
+}
+
+func (s *EndState) addRyuuKyokuEvents() {
+	var posEvent = make(map[Wind]Event)
+	for wind, result := range s.posResults {
+		posEvent[wind] = &EventRyuuKyoku{
+			Who:    wind,
+			Reason: result.RyuuKyokuReason,
+		}
+	}
+	s.g.addPosEvent(posEvent)
 }
 
 func (s *EndState) next(posCalls map[Wind]*Call) error {
 	if len(posCalls) == 0 {
 		return ErrGameEnd
 	}
+	if call, ok := posCalls[East]; ok && call.CallType == AgariYame {
+		s.g.processAgariYame(s.isTenpaiYame)
+		return ErrGameEnd
+	}
+	// keep the events of the finished round
+	s.g.allEvents = append(s.g.allEvents, s.g.GetGlobalEvents()...)
 	s.g.State = &InitState{
 		g: s.g,
 	}
 	return nil
 }
 
+func (s *EndState) clone(g *Game) gameState {
+	state := *s
+	state.g = g
+	state.posResults = make(map[Wind]*Result, len(s.posResults))
+	for wind, result := range s.posResults {
+		state.posResults[wind] = result
+	}
+	return &state
+}
+
 func (s *EndState) String() string {
 	return "End"
 }
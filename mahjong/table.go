@@ -0,0 +1,210 @@
+package mahjong
+
+import (
+	"context"
+	"fmt"
+)
+
+// Agent chooses the calls of a player at a Table, the Go agents and the subprocess bots of ProcessBot alike
+type Agent interface {
+	// Observe receives the new events of the player in order, the events of every round start with an EventStart
+	Observe(events Events) error
+	// Choose returns one of the valid calls of the player, state is the board seen by the player
+	Choose(ctx context.Context, calls Calls, state *BoardState) (*Call, error)
+}
+
+// TableResult the result of a game played at a Table
+type TableResult struct {
+	Final     *FinalResult
+	Players   []*PlayerResult // results by the index of the agent
+	NumRounds int
+	NumSteps  int
+	Fallbacks []int  // calls of every agent replaced by DefaultCall
+	Events    Events // global events of all the rounds
+}
+
+// Table plays games of the agents, the agent of the index i plays the player index i, who starts at
+// the wind i and moves one seat every wind round
+type Table struct {
+	Game   *Game
+	Agents []Agent
+
+	players []*Player
+	winds   []Wind  // wind of every agent in the current round
+	starts  []Event // first event of the round observed by every agent
+	read    []int   // number of the events of the round observed by every agent
+}
+
+// NewTable
+//
+//	@Description: seat the agents at the game
+//	@param game: game to play
+//	@param agents: agents by the index of the player
+//	@return *Table
+//	@return error: the number of agents is not the number of players
+func NewTable(game *Game, agents []Agent) (*Table, error) {
+	numPlayers := game.Rule.NumPlayers()
+	if len(agents) != numPlayers {
+		return nil, fmt.Errorf("%d agents for %d players", len(agents), numPlayers)
+	}
+	t := &Table{
+		Game:    game,
+		Agents:  agents,
+		players: make([]*Player, numPlayers),
+		winds:   make([]Wind, numPlayers),
+		starts:  make([]Event, numPlayers),
+		read:    make([]int, numPlayers),
+	}
+	for i := range t.players {
+		t.players[i] = NewMahjongPlayer()
+	}
+	return t, nil
+}
+
+// Run
+//
+//	@Description: reset the game and play it to the end, the agents choose at the same time when several players
+//	are offered calls, an agent failing to choose a valid call takes DefaultCall
+//	@receiver t
+//	@param ctx: the game stops when ctx is done, the agents choosing are not waited for
+//	@return *TableResult
+//	@return error: the error of ctx or the first error of Observe
+func (t *Table) Run(ctx context.Context) (*TableResult, error) {
+	for i := range t.Agents {
+		t.starts[i], t.read[i] = nil, 0
+	}
+	result := &TableResult{Fallbacks: make([]int, len(t.Agents))}
+	posCalls := t.Game.Reset(t.players, nil)
+	startWinds := make([]Wind, len(t.Agents))
+	t.seat()
+	copy(startWinds, t.winds)
+
+	for flag := EndTypeNone; flag != EndTypeGame; {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err := t.observe(); err != nil {
+			return nil, err
+		}
+		posCall, err := t.choose(ctx, posCalls, result.Fallbacks)
+		if err != nil {
+			return nil, err
+		}
+		if posCalls, flag, err = t.Game.StepE(posCall); err != nil {
+			return nil, err
+		}
+		result.NumSteps++
+		if flag == EndTypeRound || flag == EndTypeGame {
+			result.NumRounds++
+		}
+		t.seat()
+	}
+	if err := t.observe(); err != nil {
+		return nil, err
+	}
+
+	result.Final = t.Game.GetFinalResult()
+	result.Events = t.Game.GetAllGlobalEvents()
+	result.Players = make([]*PlayerResult, len(t.Agents))
+	for i, wind := range startWinds {
+		result.Players[i] = result.Final.GetPlayerResult(wind)
+	}
+	return result, nil
+}
+
+// seat finds the wind of every agent, the players are seated again every round
+func (t *Table) seat() {
+	for wind, player := range t.Game.PosPlayer {
+		for i, p := range t.players {
+			if p == player {
+				t.winds[i] = wind
+			}
+		}
+	}
+}
+
+// observe sends the new events of every player to its agent, the events are renewed every round
+func (t *Table) observe() error {
+	for i, agent := range t.Agents {
+		events := t.Game.GetPosEvents(t.winds[i], 0)
+		if len(events) > 0 && events[0] != t.starts[i] {
+			t.starts[i], t.read[i] = events[0], 0
+		}
+		if t.read[i] == len(events) {
+			continue
+		}
+		if err := agent.Observe(events[t.read[i]:]); err != nil {
+			return fmt.Errorf("agent %d: %w", i, err)
+		}
+		t.read[i] = len(events)
+	}
+	return nil
+}
+
+// choose collects the calls of the agents offered calls, a failed choice is counted in fallbacks,
+// the agents answer on a buffered channel so an agent still choosing after ctx is done never blocks
+// and never touches fallbacks or the calls returned
+func (t *Table) choose(ctx context.Context, posCalls map[Wind]Calls, fallbacks []int) (map[Wind]*Call, error) {
+	type choice struct {
+		i    int
+		call *Call
+		err  error
+	}
+	choices := make(chan choice, len(t.Agents))
+	states := make([]*BoardState, len(t.Agents))
+	var numChoosing int
+	for i, agent := range t.Agents {
+		calls, ok := posCalls[t.winds[i]]
+		if !ok {
+			continue
+		}
+		states[i] = t.Game.GetPosBoardState(t.winds[i], calls)
+		numChoosing++
+		go func(i int, agent Agent, calls Calls, state *BoardState) {
+			call, err := agent.Choose(ctx, calls, state)
+			choices <- choice{i: i, call: call, err: err}
+		}(i, agent, calls, states[i])
+	}
+
+	posCall := make(map[Wind]*Call, len(posCalls))
+	for ; numChoosing > 0; numChoosing-- {
+		select {
+		case c := <-choices:
+			wind := t.winds[c.i]
+			calls := posCalls[wind]
+			if c.err != nil || calls.indexExact(c.call) == -1 {
+				c.call = DefaultCall(calls, states[c.i].DrawnTile)
+				fallbacks[c.i]++
+			}
+			posCall[wind] = c.call
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return posCall, nil
+}
+
+// DefaultCall
+//
+//	@Description: the safe call of a player that fails to choose, skip or next if offered, else the discard of
+//	the tile just drawn, else the last discard of the valid calls like after a chi or pon
+//	@param calls: valid calls of the player
+//	@param drawn: the tile just drawn by the player, BoardState.DrawnTile, TileDummy for none
+//	@return *Call
+func DefaultCall(calls Calls, drawn Tile) *Call {
+	var discard *Call
+	for _, call := range calls {
+		switch call.CallType {
+		case Skip, Next:
+			return call
+		case Discard:
+			if discard == nil || discard.CallTiles[0] != drawn {
+				discard = call
+			}
+		}
+	}
+	if discard != nil {
+		return discard
+	}
+	return calls[0]
+}
@@ -0,0 +1,1225 @@
+package mahjong
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// tile codes of the tenhou.net/6 format besides the tiles
+const (
+	tenhou6Placeholder = 0  // discard of a daiminkan, the player discards after the rinshan tile
+	tenhou6TsumoGiri   = 60 // discard of the tile just drawn
+)
+
+// tenhou6MaxReplays the most replays of a round to find the discards called
+const tenhou6MaxReplays = 1 << 12
+
+// tenhou6Log the json log of tenhou.net/6
+type tenhou6Log struct {
+	Title []string        `json:"title"`
+	Name  []string        `json:"name"`
+	Rule  tenhou6Rule     `json:"rule"`
+	Log   [][]interface{} `json:"log"`
+	Sc    []float64       `json:"sc,omitempty"`
+}
+
+// tenhou6Rule the rule of a tenhou.net/6 log, disp is the name of the lobby like 般南喰赤
+type tenhou6Rule struct {
+	Disp  string `json:"disp"`
+	Aka   int    `json:"aka"`
+	Aka51 int    `json:"aka51"`
+	Aka52 int    `json:"aka52"`
+	Aka53 int    `json:"aka53"`
+}
+
+// tenhou6YakuNames names of the yaku in the results of tenhou.net/6
+var tenhou6YakuNames = map[Yaku]string{
+	YakuRiichi:         "立直",
+	YakuDaburi:         "両立直",
+	YakuIppatsu:        "一発",
+	YakuTsumo:          "門前清自摸和",
+	YakuTanyao:         "断幺九",
+	YakuChanta:         "混全帯幺九",
+	YakuJunchan:        "純全帯幺九",
+	YakuHonrouto:       "混老頭",
+	YakuYakuhai:        "役牌",
+	YakuHaku:           "役牌 白",
+	YakuHatsu:          "役牌 發",
+	YakuChun:           "役牌 中",
+	YakuWindRound:      "場風",
+	YakuWindSelf:       "自風",
+	YakuTon:            "役牌 東",
+	YakuNan:            "役牌 南",
+	YakuSja:            "役牌 西",
+	YakuPei:            "役牌 北",
+	YakuTonSelf:        "自風 東",
+	YakuNanSelf:        "自風 南",
+	YakuSjaSelf:        "自風 西",
+	YakuPeiSelf:        "自風 北",
+	YakuTonRound:       "場風 東",
+	YakuNanRound:       "場風 南",
+	YakuSjaRound:       "場風 西",
+	YakuPeiRound:       "場風 北",
+	YakuChiitoi:        "七対子",
+	YakuToitoi:         "対々和",
+	YakuSanankou:       "三暗刻",
+	YakuSankantsu:      "三槓子",
+	YakuSanshoku:       "三色同順",
+	YakuShousangen:     "小三元",
+	YakuPinfu:          "平和",
+	YakuIppeiko:        "一盃口",
+	YakuRyanpeikou:     "二盃口",
+	YakuItsuu:          "一気通貫",
+	YakuSanshokuDoukou: "三色同刻",
+	YakuHonitsu:        "混一色",
+	YakuChinitsu:       "清一色",
+	YakuDora:           "ドラ",
+	YakuUraDora:        "裏ドラ",
+	YakuAkaDora:        "赤ドラ",
+	YakuRenhou:         "人和",
+	YakuHaitei:         "海底摸月",
+	YakuHoutei:         "河底撈魚",
+	YakuRinshan:        "嶺上開花",
+	YakuChankan:        "槍槓",
+	YakuNukiDora:       "抜きドラ",
+}
+
+// tenhou6YakumanNames names of the yakuman in the results of tenhou.net/6
+var tenhou6YakumanNames = map[Yakuman]string{
+	YakumanKokushi:       "国士無双",
+	YakumanKokushi13:     "国士無双１３面",
+	YakumanSuukantsu:     "四槓子",
+	YakumanSuuankou:      "四暗刻",
+	YakumanSuuankouTanki: "四暗刻単騎",
+	YakumanDaisangen:     "大三元",
+	YakumanShousuushi:    "小四喜",
+	YakumanDaisuushi:     "大四喜",
+	YakumanRyuuiisou:     "緑一色",
+	YakumanTsuiisou:      "字一色",
+	YakumanChinrouto:     "清老頭",
+	YakumanChuurenpooto:  "九蓮宝燈",
+	YakumanChuurenpooto9: "純正九蓮宝燈",
+	YakumanTenhou:        "天和",
+	YakumanChihou:        "地和",
+	YakumanRenhou:        "人和",
+}
+
+// tenhou6LimitNames names of the limit hands in the results of tenhou.net/6
+var tenhou6LimitNames = map[Limit]string{
+	LimitMangan:    "満貫",
+	LimitHaneman:   "跳満",
+	LimitBaiman:    "倍満",
+	LimitSanbaiman: "三倍満",
+	LimitYakuman:   "役満",
+}
+
+// tenhou6RyuuKyokuNames names of the ryuu kyoku results of tenhou.net/6
+var tenhou6RyuuKyokuNames = map[RyuuKyokuReason]string{
+	RyuuKyokuNormal:         "流局",
+	RyuuKyokuKyuuShuKyuuHai: "九種九牌",
+	RyuuKyokuSuuChaRiichi:   "四家立直",
+	RyuuKyokuSuuKaiKan:      "四槓散了",
+	RyuuKyokuSuufonRenda:    "四風連打",
+	RyuuKyokuSanChaHou:      "三家和了",
+}
+
+// tenhou6RyuuKyokuReasons reasons of the ryuu kyoku results of tenhou.net/6, all tenpai, all noten and nagashi mangan are normal
+var tenhou6RyuuKyokuReasons = map[string]RyuuKyokuReason{
+	"流局":   RyuuKyokuNormal,
+	"全員聴牌": RyuuKyokuNormal,
+	"全員不聴": RyuuKyokuNormal,
+	"流し満貫": RyuuKyokuNormal,
+	"九種九牌": RyuuKyokuKyuuShuKyuuHai,
+	"四家立直": RyuuKyokuSuuChaRiichi,
+	"四槓散了": RyuuKyokuSuuKaiKan,
+	"四風連打": RyuuKyokuSuufonRenda,
+	"三家和了": RyuuKyokuSanChaHou,
+}
+
+// tenhou6Code returns the code of the tile in tenhou.net/6,
+// 11~19 man, 21~29 pin, 31~39 sou, 41~47 honors and 51~53 red fives
+func tenhou6Code(tile Tile, hasAkaDora bool) int {
+	class := tile.Class()
+	switch {
+	case hasAkaDora && tile%4 == 0 && (class == Man5 || class == Pin5 || class == Sou5):
+		return 51 + int(class)/9
+	case class >= Ton:
+		return 41 + int(class-Ton)
+	}
+	return (int(class)/9+1)*10 + int(class)%9 + 1
+}
+
+// tenhou6Class returns the tile class of the code and whether it is a red five
+func tenhou6Class(code int) (TileClass, bool, error) {
+	switch {
+	case code >= 11 && code <= 39 && code%10 != 0:
+		return TileClass((code/10-1)*9 + code%10 - 1), false, nil
+	case code >= 41 && code <= 47:
+		return Ton + TileClass(code-41), false, nil
+	case code >= 51 && code <= 53:
+		return Man5 + TileClass((code-51)*9), true, nil
+	}
+	return 0, false, fmt.Errorf("invalid tile code %d", code)
+}
+
+// tenhou6Slot returns the slot of the tile called in a chi, pon or kan string, by the seat called from relative to the caller
+func tenhou6Slot(callType CallType, rel, numPlayers int) int {
+	switch {
+	case rel == numPlayers-1:
+		return 0
+	case rel == 2:
+		return 1
+	case callType == DaiMinKan:
+		return 3
+	}
+	return 2
+}
+
+// tenhou6Rel returns the seat called from relative to the caller by the marker and its slot, false for an invalid slot
+func tenhou6Rel(marker byte, slot, numPlayers int) (int, bool) {
+	switch {
+	case slot == 0:
+		return numPlayers - 1, true
+	case marker == 'c':
+		return 0, false
+	case slot == 1 && numPlayers == 4:
+		return 2, true
+	case (marker == 'p' && slot == 2) || (marker == 'm' && slot == 3):
+		return 1, true
+	}
+	return 0, false
+}
+
+// tenhou6Meld returns the string of a meld, the marker and the tiles after it are put at the slot among the other tiles
+func tenhou6Meld(marker byte, slot int, marked, others []int) string {
+	var b strings.Builder
+	for i := 0; i <= len(others); i++ {
+		if i == slot {
+			b.WriteByte(marker)
+			for _, code := range marked {
+				b.WriteString(strconv.Itoa(code))
+			}
+		}
+		if i < len(others) {
+			b.WriteString(strconv.Itoa(others[i]))
+		}
+	}
+	return b.String()
+}
+
+// parseTenhou6Meld splits the string of a meld into the marker, its slot and the codes of all tiles,
+// codes[slot] is the tile right after the marker
+func parseTenhou6Meld(s string) (marker byte, slot int, codes []int, err error) {
+	slot = -1
+	for i := 0; i < len(s); {
+		if s[i] < '0' || s[i] > '9' {
+			if slot != -1 {
+				return 0, 0, nil, fmt.Errorf("meld %q has two markers", s)
+			}
+			marker, slot = s[i], len(codes)
+			i++
+			continue
+		}
+		if i+2 > len(s) {
+			return 0, 0, nil, fmt.Errorf("invalid meld %q", s)
+		}
+		code, err := strconv.Atoi(s[i : i+2])
+		if err != nil {
+			return 0, 0, nil, fmt.Errorf("invalid meld %q", s)
+		}
+		codes = append(codes, code)
+		i += 2
+	}
+	if slot == -1 || slot >= len(codes) {
+		return 0, 0, nil, fmt.Errorf("invalid meld %q", s)
+	}
+	return marker, slot, codes, nil
+}
+
+// ExportTenhou6
+//
+//	@Description: export a game to the json log of tenhou.net/6 read by Tenhou and the community viewers,
+//	tiles of the same kind are not told apart in the format except the red fives
+//	@param events: global events of the game, see GetAllGlobalEvents
+//	@param names: names of the players by the seat of the first dealer, missing names are empty
+//	@return []byte: the json log
+//	@return error
+func ExportTenhou6(events Events, names []string) ([]byte, error) {
+	log := &tenhou6Log{Title: []string{"", ""}, Name: make([]string, 4), Log: [][]interface{}{}}
+	copy(log.Name, names)
+	var round *tenhou6Writer
+	for _, event := range events {
+		switch e := event.(type) {
+		case *EventGlobalInit:
+			if round == nil {
+				log.Rule = newTenhou6Rule(e.Rule)
+			}
+			round = newTenhou6Writer(e)
+			continue
+		case *EventGameEnd:
+			if e.Result == nil {
+				continue
+			}
+			log.Sc = make([]float64, 8)
+			for _, player := range e.Result.Players {
+				log.Sc[2*int(player.Seat)] = float64(player.Points / 100)
+				log.Sc[2*int(player.Seat)+1] = player.Score
+			}
+			continue
+		}
+		if round == nil {
+			continue
+		}
+		if err := round.write(event); err != nil {
+			return nil, err
+		}
+		if event.GetType() == EventTypeEnd {
+			log.Log = append(log.Log, round.round())
+			round = nil
+		}
+	}
+	return json.Marshal(log)
+}
+
+// newTenhou6Rule returns the tenhou.net/6 rule of the rule
+func newTenhou6Rule(rule *Rule) tenhou6Rule {
+	disp := "般南"
+	if rule.IsSanma {
+		disp = "三" + disp
+	}
+	if rule.GameLength <= 4 {
+		disp = strings.Replace(disp, "南", "東", 1)
+	}
+	if rule.IsOpenTanyao {
+		disp += "喰"
+	}
+	var r tenhou6Rule
+	if rule.HasAkaDora {
+		disp += "赤"
+		r.Aka, r.Aka51, r.Aka52, r.Aka53 = 1, 1, 1, 1
+		if rule.IsSanma {
+			r.Aka51 = 0
+		}
+	}
+	r.Disp = disp
+	return r
+}
+
+// tenhou6Writer collects the events of a round into a round of tenhou.net/6
+type tenhou6Writer struct {
+	init     *EventGlobalInit
+	dealer   int
+	dora     []int
+	takes    [4][]interface{}
+	discards [4][]interface{}
+	drawn    map[Wind]bool // the last action of the player is a draw
+	riichi   map[Wind]bool // the next discard of the player declares riichi
+	kanWho   Wind          // the player of the last added or closed kan, robbed by chankan
+	wins     []Event
+	nagashi  bool
+	tenpai   int
+	reason   RyuuKyokuReason
+	result   []interface{}
+}
+
+// newTenhou6Writer starts a round
+func newTenhou6Writer(init *EventGlobalInit) *tenhou6Writer {
+	return &tenhou6Writer{
+		init:   init,
+		dealer: int(init.WindRound-WindRoundEast1) % 4,
+		dora:   []int{tenhou6Code(init.AllTiles[len(init.AllTiles)-6], init.Rule.HasAkaDora)},
+		drawn:  make(map[Wind]bool),
+		riichi: make(map[Wind]bool),
+		kanWho: WindDummy,
+	}
+}
+
+// seat returns the seat of Tenhou of the wind
+func (w *tenhou6Writer) seat(wind Wind) int {
+	return (int(wind) + w.dealer) % w.init.Rule.NumPlayers()
+}
+
+// code returns the code of the tile
+func (w *tenhou6Writer) code(tile Tile) int {
+	return tenhou6Code(tile, w.init.Rule.HasAkaDora)
+}
+
+// codes returns the codes of the tiles
+func (w *tenhou6Writer) codes(tiles Tiles) []int {
+	codes := make([]int, 0, len(tiles))
+	for _, tile := range tiles {
+		codes = append(codes, w.code(tile))
+	}
+	return codes
+}
+
+// slot returns the slot of the tile called in a meld of who called from the player
+func (w *tenhou6Writer) slot(callType CallType, who, from Wind) int {
+	numPlayers := w.init.Rule.NumPlayers()
+	return tenhou6Slot(callType, (int(from)-int(who)+numPlayers)%numPlayers, numPlayers)
+}
+
+// deltas returns the points changes by the seat
+func (w *tenhou6Writer) deltas(pointsChange map[Wind]int) []int {
+	deltas := make([]int, 4)
+	for wind, points := range pointsChange {
+		deltas[w.seat(wind)] = points
+	}
+	return deltas
+}
+
+func (w *tenhou6Writer) write(event Event) error {
+	switch e := event.(type) {
+	case *EventGet:
+		w.takes[w.seat(e.Who)] = append(w.takes[w.seat(e.Who)], w.code(e.Tile))
+		w.drawn[e.Who] = true
+	case *EventDiscard:
+		w.discard(e.Who, e.Tile, false)
+	case *EventTsumoGiri:
+		w.discard(e.Who, e.Tile, w.drawn[e.Who])
+	case *EventRiichi:
+		if e.Step == 1 {
+			w.riichi[e.Who] = true
+		}
+	case *EventChi:
+		delete(w.drawn, e.Who)
+		tiles := w.codes(e.Call.CallTiles[:3])
+		w.takes[w.seat(e.Who)] = append(w.takes[w.seat(e.Who)], tenhou6Meld('c', 0, tiles[2:], tiles[:2]))
+	case *EventPon:
+		delete(w.drawn, e.Who)
+		tiles := w.codes(e.Call.CallTiles[:3])
+		slot := w.slot(Pon, e.Who, e.Call.CallTilesFromWho[2])
+		w.takes[w.seat(e.Who)] = append(w.takes[w.seat(e.Who)], tenhou6Meld('p', slot, tiles[2:], tiles[:2]))
+	case *EventDaiMinKan:
+		tiles := w.codes(e.Call.CallTiles)
+		slot := w.slot(DaiMinKan, e.Who, e.Call.CallTilesFromWho[3])
+		w.takes[w.seat(e.Who)] = append(w.takes[w.seat(e.Who)], tenhou6Meld('m', slot, tiles[3:], tiles[:3]))
+		w.discards[w.seat(e.Who)] = append(w.discards[w.seat(e.Who)], tenhou6Placeholder)
+	case *EventShouMinKan:
+		tiles := w.codes(e.Call.CallTiles)
+		slot := w.slot(Pon, e.Who, e.Call.CallTilesFromWho[2])
+		meld := tenhou6Meld('k', slot, []int{tiles[3], tiles[2]}, tiles[:2])
+		w.discards[w.seat(e.Who)] = append(w.discards[w.seat(e.Who)], meld)
+		w.kanWho = e.Who
+	case *EventAnKan:
+		tiles := w.codes(e.Call.CallTiles)
+		w.kanWho = e.Who
+		w.discards[w.seat(e.Who)] = append(w.discards[w.seat(e.Who)], tenhou6Meld('a', 3, tiles[3:], tiles[:3]))
+	case *EventKita:
+		w.discards[w.seat(e.Who)] = append(w.discards[w.seat(e.Who)], tenhou6Meld('f', 0, []int{w.code(e.Tile)}, nil))
+	case *EventNewIndicator:
+		w.dora = append(w.dora, w.code(e.Tile))
+	case *EventRon, *EventTsumo, *EventChanKan:
+		w.wins = append(w.wins, e)
+	case *EventNagashiMangan:
+		w.nagashi = true
+	case *EventTenpaiEnd:
+		w.tenpai++
+	case *EventRyuuKyoku:
+		w.reason = e.Reason
+	case *EventEnd:
+		return w.end(e.PointsChange)
+	}
+	return nil
+}
+
+// discard adds a discard, the tile just drawn is written as tenhou6TsumoGiri
+func (w *tenhou6Writer) discard(who Wind, tile Tile, isTsumoGiri bool) {
+	var entry interface{} = w.code(tile)
+	if isTsumoGiri {
+		entry = tenhou6TsumoGiri
+	}
+	if w.riichi[who] {
+		entry = fmt.Sprintf("r%d", entry)
+		w.riichi[who] = false
+	}
+	delete(w.drawn, who)
+	w.discards[w.seat(who)] = append(w.discards[w.seat(who)], entry)
+}
+
+// end writes the result of the round
+func (w *tenhou6Writer) end(pointsChange map[Wind]int) error {
+	if len(w.wins) == 0 {
+		name, ok := tenhou6RyuuKyokuNames[w.reason]
+		if !ok {
+			return fmt.Errorf("unknown ryuu kyoku reason %s", w.reason)
+		}
+		if w.reason != RyuuKyokuNormal {
+			w.result = []interface{}{name}
+			return nil
+		}
+		switch {
+		case w.nagashi:
+			name = "流し満貫"
+		case w.tenpai == w.init.Rule.NumPlayers():
+			name = "全員聴牌"
+		case w.tenpai == 0:
+			name = "全員不聴"
+		}
+		w.result = []interface{}{name, w.deltas(pointsChange)}
+		return nil
+	}
+
+	// every win but the last carries the payment of its ron, the last one carries the rest of the changes
+	w.result = []interface{}{"和了"}
+	rest := make(map[Wind]int, len(pointsChange))
+	for wind, points := range pointsChange {
+		rest[wind] = points
+	}
+	for i, event := range w.wins {
+		var who, from, pao Wind
+		var result *Result
+		switch e := event.(type) {
+		case *EventRon:
+			who, from, pao, result = e.Who, e.FromWho, e.PaoWho, e.Result
+		case *EventChanKan:
+			who, from, pao, result = e.Who, e.FromWho, e.PaoWho, e.Result
+			if from == WindDummy {
+				from = w.kanWho
+			}
+		case *EventTsumo:
+			who, from, pao, result = e.Who, e.Who, e.PaoWho, e.Result
+		}
+		if result == nil || result.YakuResult == nil || result.ScoreResult == nil {
+			return fmt.Errorf("win of %s without a result", who)
+		}
+		changes := rest
+		if i < len(w.wins)-1 {
+			pay := result.ScoreResult.PayRon
+			if who == East {
+				pay = result.ScoreResult.PayRonDealer
+			}
+			changes = map[Wind]int{who: pointsChange[who], from: -pay}
+			rest[who] -= pointsChange[who]
+			rest[from] += pay
+		}
+		if pao == WindDummy {
+			pao = who
+		}
+		info := []interface{}{w.seat(who), w.seat(from), w.seat(pao), w.points(who, who == from, i == 0, result.ScoreResult)}
+		for _, name := range w.yaku(result.YakuResult) {
+			info = append(info, name)
+		}
+		w.result = append(w.result, w.deltas(changes), info)
+	}
+	return nil
+}
+
+// points returns the points text of a win without honba, like 30符1飜1000点, 満貫2000-4000点 or 1300点∀
+func (w *tenhou6Writer) points(who Wind, isTsumo, hasHonba bool, score *ScoreResult) string {
+	var honba int
+	if hasHonba {
+		honba = w.init.NumHonba * w.init.Rule.HonbaValue
+	}
+	var text string
+	switch {
+	case !isTsumo && who == East:
+		text = fmt.Sprintf("%d点", score.PayRonDealer-3*honba)
+	case !isTsumo:
+		text = fmt.Sprintf("%d点", score.PayRon-3*honba)
+	case who == East:
+		text = fmt.Sprintf("%d点∀", score.PayTsumoDealer-honba)
+	default:
+		text = fmt.Sprintf("%d-%d点", score.PayTsumo-honba, score.PayTsumoDealer-honba)
+	}
+	if name, ok := tenhou6LimitNames[score.Special]; ok {
+		return name + text
+	}
+	return fmt.Sprintf("%d符%d飜", score.Fu, score.Han) + text
+}
+
+// yaku returns the yaku texts of a win, like 立直(1飜) or 国士無双(役満), dora come last
+func (w *tenhou6Writer) yaku(result *YakuResult) []string {
+	var texts []string
+	for _, yakuman := range result.Yakumans {
+		texts = append(texts, tenhou6YakumanNames[yakuman]+"(役満)")
+	}
+	var yakus []int
+	for yaku := range result.Yaku {
+		yakus = append(yakus, int(yaku))
+	}
+	sort.Ints(yakus)
+	for _, yaku := range yakus {
+		texts = append(texts, fmt.Sprintf("%s(%d飜)", tenhou6YakuNames[Yaku(yaku)], result.Yaku[Yaku(yaku)]))
+	}
+	for _, yaku := range []Yaku{YakuDora, YakuAkaDora, YakuUraDora, YakuNukiDora} {
+		if han := result.Bonuses[yaku]; han > 0 {
+			texts = append(texts, fmt.Sprintf("%s(%d飜)", tenhou6YakuNames[yaku], han))
+		}
+	}
+	return texts
+}
+
+// round returns the round of tenhou.net/6,
+// [[round, honba, riichi sticks], points, dora, ura dora, haipai, takes and discards of every seat, result]
+func (w *tenhou6Writer) round() []interface{} {
+	rule := w.init.Rule
+	tiles := w.init.AllTiles
+	points := make([]int, 4)
+	for wind, p := range w.init.InitPoints {
+		points[w.seat(wind)] = p
+	}
+	var ura []int
+	for _, event := range w.wins {
+		var result *Result
+		switch e := event.(type) {
+		case *EventRon:
+			result = e.Result
+		case *EventChanKan:
+			result = e.Result
+		case *EventTsumo:
+			result = e.Result
+		}
+		if _, ok := result.YakuResult.Yaku[YakuRiichi]; !ok {
+			if _, ok = result.YakuResult.Yaku[YakuDaburi]; !ok {
+				continue
+			}
+		}
+		for i := range w.dora {
+			ura = append(ura, w.code(tiles[len(tiles)-5-2*i]))
+		}
+		break
+	}
+	round := []interface{}{
+		[]int{int(w.init.WindRound - WindRoundEast1), w.init.NumHonba, w.init.NumRiichi},
+		points,
+		w.dora,
+		append([]int{}, ura...),
+	}
+	for seat := 0; seat < 4; seat++ {
+		haipai := []int{}
+		if seat < rule.NumPlayers() {
+			wind := (seat - w.dealer + rule.NumPlayers()) % rule.NumPlayers()
+			haipai = w.codes(tiles[13*wind : 13*wind+13])
+		}
+		takes := append([]interface{}{}, w.takes[seat]...)
+		discards := append([]interface{}{}, w.discards[seat]...)
+		round = append(round, haipai, takes, discards)
+	}
+	return append(round, w.result)
+}
+
+// ImportTenhou6
+//
+//	@Description: import a json log of tenhou.net/6, every round can be rebuilt by ReConstructGame,
+//	tiles of the same kind get the ids in order and the wall positions never revealed are filled with the unseen tiles
+//	@param data: the json log
+//	@return *TenhouLog
+//	@return error
+func ImportTenhou6(data []byte) (*TenhouLog, error) {
+	var log tenhou6Log
+	if err := json.Unmarshal(data, &log); err != nil {
+		return nil, err
+	}
+	rule, err := log.Rule.rule()
+	if err != nil {
+		return nil, err
+	}
+	names := log.Name
+	if len(names) > rule.NumPlayers() {
+		names = names[:rule.NumPlayers()]
+	}
+	result := &TenhouLog{Rule: rule, Names: names}
+	for i, entries := range log.Log {
+		events, err := importTenhou6Round(rule, i, entries)
+		if err != nil {
+			return nil, fmt.Errorf("tenhou6 round %d: %w", i, err)
+		}
+		result.Rounds = append(result.Rounds, events)
+	}
+	return result, nil
+}
+
+// rule returns the rule of the log, the lobby name tells sanma(三), tonpuu(東) and open tanyao(喰)
+func (r tenhou6Rule) rule() (*Rule, error) {
+	rule := GetDefaultRule()
+	aka := []int{r.Aka51, r.Aka52, r.Aka53}
+	if strings.Contains(r.Disp, "三") {
+		rule = GetDefaultSanmaRule()
+		aka = aka[1:]
+	}
+	if strings.Contains(r.Disp, "東") {
+		rule.GameLength = 4
+	}
+	rule.IsOpenTanyao = strings.Contains(r.Disp, "喰")
+	if r.Aka51 == 0 && r.Aka52 == 0 && r.Aka53 == 0 {
+		aka = []int{r.Aka}
+	}
+	for _, n := range aka {
+		if n != aka[0] || n > 1 {
+			return nil, fmt.Errorf("aka dora %d, %d, %d not supported", r.Aka51, r.Aka52, r.Aka53)
+		}
+	}
+	rule.HasAkaDora = aka[0] == 1
+	return rule, nil
+}
+
+// tenhou6Int returns the integer of a json number
+func tenhou6Int(v interface{}) (int, bool) {
+	f, ok := v.(float64)
+	if !ok || f != math.Trunc(f) {
+		return 0, false
+	}
+	return int(f), true
+}
+
+// tenhou6Ints returns the integers of a json array of numbers
+func tenhou6Ints(v interface{}) ([]int, error) {
+	values, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%v is not an array", v)
+	}
+	ints := make([]int, 0, len(values))
+	for _, value := range values {
+		n, ok := tenhou6Int(value)
+		if !ok {
+			return nil, fmt.Errorf("%v is not an integer", value)
+		}
+		ints = append(ints, n)
+	}
+	return ints, nil
+}
+
+// tenhou6Round replays the takes and discards of every seat in turn order,
+// tiles of the same code get the ids in order as they appear
+type tenhou6Round struct {
+	*tenhouRound
+	used     map[Tile]bool
+	hands    map[Wind]Tiles
+	lastTile Tile   // the last tile discarded or added to a kan, the win tile of a ron
+	riichiBy Wind   // the player whose riichi discard is not passed yet, WindDummy for none
+	kanDoras []int  // the dora indicators revealed by the kans to come
+	choices  []bool // whether to take the n-th call found, taken beyond the choices
+	made     []bool // whether the n-th call found is taken in the replay
+}
+
+// importTenhou6Round returns the events of a round of tenhou.net/6
+func importTenhou6Round(rule *Rule, numGame int, entries []interface{}) (Events, error) {
+	numPlayers := rule.NumPlayers()
+	numSeats := (len(entries) - 5) / 3
+	if len(entries) != 5+3*numSeats || numSeats < numPlayers || numSeats > 4 {
+		return nil, fmt.Errorf("%d entries in the round", len(entries))
+	}
+	info, err := tenhou6Ints(entries[0])
+	if err != nil {
+		return nil, err
+	}
+	if len(info) != 3 {
+		return nil, fmt.Errorf("round info %v must have 3 values", info)
+	}
+	points, err := tenhou6Ints(entries[1])
+	if err != nil {
+		return nil, err
+	}
+	if len(points) < numPlayers {
+		return nil, fmt.Errorf("points %v must have %d values", points, numPlayers)
+	}
+	dora, err := tenhou6Ints(entries[2])
+	if err != nil {
+		return nil, err
+	}
+	ura, err := tenhou6Ints(entries[3])
+	if err != nil {
+		return nil, err
+	}
+	if len(dora) == 0 {
+		return nil, errors.New("no dora indicator")
+	}
+	result, ok := entries[len(entries)-1].([]interface{})
+	if !ok || len(result) == 0 {
+		return nil, errors.New("no result")
+	}
+
+	var haipais [][]int
+	takes := make([][]interface{}, numPlayers)
+	discards := make([][]interface{}, numPlayers)
+	for seat := 0; seat < numSeats; seat++ {
+		haipai, err := tenhou6Ints(entries[4+3*seat])
+		if err != nil {
+			return nil, err
+		}
+		take, ok1 := entries[5+3*seat].([]interface{})
+		discard, ok2 := entries[6+3*seat].([]interface{})
+		if !ok1 || !ok2 {
+			return nil, fmt.Errorf("takes and discards of seat %d are not arrays", seat)
+		}
+		if seat >= numPlayers {
+			if len(haipai)+len(take)+len(discard) > 0 {
+				return nil, fmt.Errorf("no seat %d", seat)
+			}
+			continue
+		}
+		haipais = append(haipais, haipai)
+		takes[seat], discards[seat] = take, discard
+	}
+
+	// replay plays the round taking the calls by the choices
+	replay := func(choices []bool) (*tenhou6Round, error) {
+		r := &tenhou6Round{
+			tenhouRound: &tenhouRound{rule: rule},
+			used:        make(map[Tile]bool),
+			hands:       make(map[Wind]Tiles),
+			lastTile:    TileDummy,
+			riichiBy:    WindDummy,
+			kanDoras:    dora[1:],
+			choices:     choices,
+		}
+		var hands []Tiles
+		for _, haipai := range haipais {
+			hand, err := r.alloc(haipai...)
+			if err != nil {
+				return r, err
+			}
+			hands = append(hands, hand)
+		}
+		doraIndicator, err := r.alloc(dora[0])
+		if err != nil {
+			return r, err
+		}
+		used := r.used
+		if r.tenhouRound, err = newTenhouRound(rule, numGame, info[0], info[1], info[2], doraIndicator[0], info[0]%4, points, hands); err != nil {
+			return r, err
+		}
+		r.used = used
+		for seat, hand := range hands {
+			r.hands[r.wind(seat)] = hand
+		}
+
+		restTakes := append([][]interface{}{}, takes...)
+		restDiscards := append([][]interface{}{}, discards...)
+		if err = r.play(restTakes, restDiscards); err != nil {
+			return r, err
+		}
+		if err = r.result(result, ura); err != nil {
+			return r, err
+		}
+		for seat := range restTakes {
+			if len(restTakes[seat])+len(restDiscards[seat]) > 0 {
+				return r, fmt.Errorf("seat %d has takes or discards after the result", seat)
+			}
+		}
+		return r, nil
+	}
+
+	// the log does not tell which discard of the same code a player calls after passing one,
+	// calls are taken first and passed from the last one when the replay fails
+	var choices []bool
+	var firstErr error
+	for n := 0; n < tenhou6MaxReplays; n++ {
+		r, err := replay(choices)
+		if err == nil {
+			return r.finish(), nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+		i := len(r.made) - 1
+		for i >= 0 && !r.made[i] {
+			i--
+		}
+		if i < 0 {
+			break
+		}
+		choices = append(r.made[:i:i], false)
+	}
+	return nil, firstErr
+}
+
+// alloc returns a tile not used yet for every code
+func (r *tenhou6Round) alloc(codes ...int) (Tiles, error) {
+	tiles := make(Tiles, 0, len(codes))
+	for _, code := range codes {
+		class, isRed, err := tenhou6Class(code)
+		if err != nil {
+			return nil, err
+		}
+		if isRed && !r.rule.HasAkaDora {
+			return nil, fmt.Errorf("red five %d without aka dora", code)
+		}
+		tile := TileDummy
+		for _, t := range class.To4Tiles() {
+			if !r.used[t] && tenhou6Code(t, r.rule.HasAkaDora) == code {
+				tile = t
+				break
+			}
+		}
+		if tile == TileDummy {
+			return nil, fmt.Errorf("no tile %d left", code)
+		}
+		r.used[tile] = true
+		tiles = append(tiles, tile)
+	}
+	return tiles, nil
+}
+
+// take removes a tile of the code from the hand, the tile just drawn first if preferDrawn,
+// else the tile of the lowest id kept in the hand, as the engine chooses
+func (r *tenhou6Round) take(who Wind, code int, preferDrawn bool) (Tile, error) {
+	drawn, isDrawn := r.lastDrawn[who]
+	hand := r.hands[who]
+	index, drawnIndex := -1, -1
+	for i, tile := range hand {
+		switch {
+		case tenhou6Code(tile, r.rule.HasAkaDora) != code:
+		case isDrawn && tile == drawn:
+			drawnIndex = i
+		case index == -1 || tile < hand[index]:
+			index = i
+		}
+	}
+	if drawnIndex != -1 && (preferDrawn || index == -1) {
+		index = drawnIndex
+	}
+	if index == -1 {
+		return TileDummy, fmt.Errorf("%s has no tile %d", who, code)
+	}
+	return r.remove(who, index), nil
+}
+
+// remove removes the tile at the index from the hand
+func (r *tenhou6Round) remove(who Wind, index int) Tile {
+	hand := r.hands[who]
+	tile := hand[index]
+	r.hands[who] = append(hand[:index:index], hand[index+1:]...)
+	return tile
+}
+
+// has returns whether the hand holds tiles of all the codes
+func (r *tenhou6Round) has(who Wind, codes []int) bool {
+	hand := r.hands[who]
+	hand = hand.Copy()
+	for _, code := range codes {
+		found := false
+		for i, tile := range hand {
+			if tenhou6Code(tile, r.rule.HasAkaDora) == code {
+				hand = append(hand[:i:i], hand[i+1:]...)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// play replays the takes and discards from the dealer until a player has no action left
+func (r *tenhou6Round) play(takes, discards [][]interface{}) error {
+	numPlayers := r.rule.NumPlayers()
+	seat := r.dealer
+	isDraw := true
+	for {
+		who := r.wind(seat)
+		if isDraw {
+			if len(takes[seat]) == 0 {
+				return nil
+			}
+			code, ok := tenhou6Int(takes[seat][0])
+			if !ok {
+				return fmt.Errorf("seat %d takes %v without a discard to call", seat, takes[seat][0])
+			}
+			takes[seat] = takes[seat][1:]
+			tiles, err := r.alloc(code)
+			if err != nil {
+				return err
+			}
+			r.hands[who] = append(r.hands[who], tiles[0])
+			if err = r.draw(who, tiles[0]); err != nil {
+				return err
+			}
+		}
+		isDraw = true
+		if len(discards[seat]) == 0 {
+			return nil
+		}
+		entry := discards[seat][0]
+		discards[seat] = discards[seat][1:]
+		if s, ok := entry.(string); ok && !strings.HasPrefix(s, "r") {
+			// kan and kita, the player draws again
+			if err := r.selfCall(who, s); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := r.discardEntry(who, entry); err != nil {
+			return err
+		}
+
+		caller, err := r.caller(seat, takes)
+		if err != nil {
+			return err
+		}
+		if caller != -1 {
+			n := len(r.made)
+			if n < len(r.choices) && !r.choices[n] {
+				caller = -1
+			}
+			r.made = append(r.made, caller != -1)
+		}
+		if caller == -1 {
+			seat = (seat + 1) % numPlayers
+			if len(takes[seat]) == 0 {
+				return nil
+			}
+			r.passRiichi()
+			continue
+		}
+		r.passRiichi()
+		marker, slot, codes, _ := parseTenhou6Meld(takes[caller][0].(string))
+		takes[caller] = takes[caller][1:]
+		seat, who = caller, r.wind(caller)
+		var tiles Tiles
+		for i, code := range codes {
+			if i == slot {
+				continue
+			}
+			tile, err := r.take(who, code, false)
+			if err != nil {
+				return err
+			}
+			tiles = append(tiles, tile)
+		}
+		sort.Sort(&tiles)
+		switch marker {
+		case 'c':
+			isDraw = false
+			err = r.claim(who, Chi, tiles, r.lastTile)
+		case 'p':
+			isDraw = false
+			err = r.claim(who, Pon, tiles, r.lastTile)
+		default:
+			if len(discards[seat]) == 0 || discards[seat][0] != float64(tenhou6Placeholder) {
+				return fmt.Errorf("seat %d discards after a daiminkan without the placeholder 0", seat)
+			}
+			discards[seat] = discards[seat][1:]
+			if err = r.claim(who, DaiMinKan, tiles, r.lastTile); err == nil {
+				err = r.kanDora()
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// discardEntry discards a code, or declares riichi by r and the code
+func (r *tenhou6Round) discardEntry(who Wind, entry interface{}) error {
+	code, ok := tenhou6Int(entry)
+	if s, isString := entry.(string); isString {
+		var err error
+		if code, err = strconv.Atoi(s[1:]); err != nil {
+			return fmt.Errorf("invalid riichi %q", s)
+		}
+		if err = r.riichi(who, 1); err != nil {
+			return err
+		}
+		r.riichiBy, ok = who, true
+	}
+	if !ok {
+		return fmt.Errorf("invalid discard %v", entry)
+	}
+	var tile Tile
+	if code == tenhou6TsumoGiri {
+		drawn, ok := r.lastDrawn[who]
+		if !ok {
+			return fmt.Errorf("%s discards the tile drawn without a draw", who)
+		}
+		tile = drawn
+		for i, t := range r.hands[who] {
+			if t == drawn {
+				r.remove(who, i)
+				break
+			}
+		}
+	} else {
+		var err error
+		if tile, err = r.take(who, code, false); err != nil {
+			return err
+		}
+	}
+	r.lastTile = tile
+	return r.discard(who, tile)
+}
+
+// passRiichi finishes the riichi of the last discard as no one wins on it
+func (r *tenhou6Round) passRiichi() {
+	if r.riichiBy != WindDummy {
+		_ = r.riichi(r.riichiBy, 2)
+		r.riichiBy = WindDummy
+	}
+}
+
+// selfCall adds an ankan, a shouminkan or a kita
+func (r *tenhou6Round) selfCall(who Wind, s string) error {
+	marker, slot, codes, err := parseTenhou6Meld(s)
+	if err != nil {
+		return err
+	}
+	switch marker {
+	case 'a':
+		if len(codes) != 4 {
+			return fmt.Errorf("invalid ankan %q", s)
+		}
+		var tiles Tiles
+		for _, code := range codes {
+			tile, err := r.take(who, code, false)
+			if err != nil {
+				return err
+			}
+			tiles = append(tiles, tile)
+		}
+		sort.Sort(&tiles)
+		r.lastTile = tiles[len(tiles)-1]
+		if err = r.closedKan(who, tiles); err != nil {
+			return err
+		}
+		return r.kanDora()
+	case 'k':
+		tile, err := r.take(who, codes[slot], false)
+		if err != nil {
+			return err
+		}
+		r.lastTile = tile
+		if err = r.addedKan(who, tile); err != nil {
+			return err
+		}
+		return r.kanDora()
+	case 'f':
+		// the engine takes the north just drawn for kita
+		tile, err := r.take(who, codes[slot], true)
+		if err != nil {
+			return err
+		}
+		return r.kita(who, tile)
+	}
+	return fmt.Errorf("invalid meld %q in the discards", s)
+}
+
+// kanDora reveals the next dora indicator of the log after a kan, if any
+func (r *tenhou6Round) kanDora() error {
+	if len(r.kanDoras) == 0 {
+		return nil
+	}
+	tiles, err := r.alloc(r.kanDoras[0])
+	if err != nil {
+		return err
+	}
+	r.kanDoras = r.kanDoras[1:]
+	return r.dora(tiles[0])
+}
+
+// caller returns the seat calling the last discard of the seat, -1 for none,
+// a call is taken when it is the next take of a player holding its tiles, pon and kan before chi
+func (r *tenhou6Round) caller(seat int, takes [][]interface{}) (int, error) {
+	numPlayers := r.rule.NumPlayers()
+	caller := -1
+	for s := 0; s < numPlayers; s++ {
+		if s == seat || len(takes[s]) == 0 {
+			continue
+		}
+		str, ok := takes[s][0].(string)
+		if !ok {
+			continue
+		}
+		marker, slot, codes, err := parseTenhou6Meld(str)
+		if err != nil {
+			return -1, err
+		}
+		rel, ok := tenhou6Rel(marker, slot, numPlayers)
+		if !ok || (marker != 'c' && marker != 'p' && marker != 'm') {
+			return -1, fmt.Errorf("invalid call %q", str)
+		}
+		if (seat-s+numPlayers)%numPlayers != rel || codes[slot] != tenhou6Code(r.lastTile, r.rule.HasAkaDora) {
+			continue
+		}
+		others := append(codes[:slot:slot], codes[slot+1:]...)
+		if r.has(r.wind(s), others) && (caller == -1 || marker != 'c') {
+			caller = s
+		}
+	}
+	return caller, nil
+}
+
+// result ends the round by the result entry, a list of points changes and win infos for wins
+func (r *tenhou6Round) result(result []interface{}, ura []int) error {
+	name, _ := result[0].(string)
+	if name != "和了" {
+		reason, ok := tenhou6RyuuKyokuReasons[name]
+		if !ok {
+			return fmt.Errorf("unknown result %q", name)
+		}
+		pointsChange := make(map[Wind]int)
+		if len(result) > 1 {
+			deltas, err := tenhou6Ints(result[1])
+			if err != nil {
+				return err
+			}
+			for seat := 0; seat < len(deltas) && seat < r.rule.NumPlayers(); seat++ {
+				pointsChange[r.wind(seat)] = deltas[seat]
+			}
+		}
+		r.passRiichi()
+		hands := make(map[Wind]Tiles)
+		for wind, hand := range r.hands {
+			if reason != RyuuKyokuNormal || len(GetTenpaiSlice(hand, append(Calls{}, r.melds[wind]...))) > 0 {
+				hands[wind] = hand.Copy()
+			}
+		}
+		return r.ryuuKyoku(reason, name == "流し満貫", hands, pointsChange)
+	}
+
+	uraIndicators, err := r.alloc(ura...)
+	if err != nil {
+		return err
+	}
+	if err = r.indicators(nil, uraIndicators); err != nil {
+		return err
+	}
+	if len(result) < 3 || len(result)%2 != 1 {
+		return fmt.Errorf("win result of %d entries", len(result))
+	}
+	for i := 1; i < len(result); i += 2 {
+		deltas, err := tenhou6Ints(result[i])
+		if err != nil {
+			return err
+		}
+		info, ok := result[i+1].([]interface{})
+		if !ok || len(info) < 3 {
+			return fmt.Errorf("invalid win info %v", result[i+1])
+		}
+		seats, err := tenhou6Ints(info[:3])
+		if err != nil {
+			return err
+		}
+		for _, seat := range seats {
+			if seat < 0 || seat >= r.rule.NumPlayers() {
+				return fmt.Errorf("no seat %d", seat)
+			}
+		}
+		who, from, pao := r.wind(seats[0]), r.wind(seats[1]), r.wind(seats[2])
+		if pao == who {
+			pao = WindDummy
+		}
+		pointsChange := make(map[Wind]int)
+		for seat := 0; seat < len(deltas) && seat < r.rule.NumPlayers(); seat++ {
+			pointsChange[r.wind(seat)] = deltas[seat]
+		}
+		hand, winTile := r.hands[who], r.lastTile
+		hand = hand.Copy()
+		if who == from {
+			winTile = r.lastDrawn[who]
+		} else {
+			hand = append(hand, winTile)
+		}
+		if err = r.win(who, from, pao, hand, winTile, pointsChange); err != nil {
+			return err
+		}
+	}
+	return nil
+}
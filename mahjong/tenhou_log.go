@@ -0,0 +1,750 @@
+package mahjong
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/hphphp123321/go-common"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// flags of the game type in the GO tag of Tenhou
+const (
+	tenhouTypeHuman    = 0x0001 // against humans, not bots
+	tenhouTypeNoAka    = 0x0002 // no aka dora
+	tenhouTypeNoKuitan = 0x0004 // no open tanyao
+	tenhouTypeHanchan  = 0x0008 // east and south rounds
+	tenhouTypeSanma    = 0x0010 // three players
+	tenhouTypeTokujou  = 0x0020 // lobby
+	tenhouTypeFast     = 0x0040 // short thinking time
+	tenhouTypeJoukyuu  = 0x0080 // lobby
+
+	tenhouTypeSupported = tenhouTypeHuman | tenhouTypeNoAka | tenhouTypeNoKuitan | tenhouTypeHanchan |
+		tenhouTypeSanma | tenhouTypeTokujou | tenhouTypeFast | tenhouTypeJoukyuu
+)
+
+// TenhouLog a game imported from Tenhou
+type TenhouLog struct {
+	Rule   *Rule
+	Names  []string // names of the players by the seat of Tenhou, seat 0 is the first dealer
+	Rounds []Events // global events of every round, each starts with an EventGlobalInit
+	Seed   string   // seed attribute of the SHUFFLE tag, empty if the log has none
+}
+
+// TenhouRule
+//
+//	@Description: the rule of a Tenhou game type, the default rules are the rules of Tenhou
+//	@param gameType: type attribute of the GO tag
+//	@return *Rule
+//	@return error: the game type has flags not supported
+func TenhouRule(gameType int) (*Rule, error) {
+	if flags := gameType &^ tenhouTypeSupported; flags != 0 {
+		return nil, fmt.Errorf("unsupported tenhou rule flags 0x%x", flags)
+	}
+	rule := GetDefaultRule()
+	if gameType&tenhouTypeSanma != 0 {
+		rule = GetDefaultSanmaRule()
+	}
+	if gameType&tenhouTypeHanchan == 0 {
+		rule.GameLength = 4
+	}
+	rule.HasAkaDora = gameType&tenhouTypeNoAka == 0
+	rule.IsOpenTanyao = gameType&tenhouTypeNoKuitan == 0
+	return rule, nil
+}
+
+// ImportMjlog
+//
+//	@Description: import a Tenhou mjlog, every round can be rebuilt by ReConstructGame,
+//	the wall positions never revealed in the log are filled with the unseen tiles.
+//	The walls are not dealt from the SHUFFLE seed: old logs have no seed or a seed of another shuffle,
+//	and three-player walls can't be dealt, the seed is kept in TenhouLog.Seed for NewTenhouWallGenerator
+//	@param r: the mjlog xml, gzip compressed or not
+//	@return *TenhouLog
+//	@return error
+func ImportMjlog(r io.Reader) (*TenhouLog, error) {
+	br := bufio.NewReader(r)
+	r = br
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		r = zr
+	}
+
+	importer := &mjlogImporter{log: &TenhouLog{}}
+	decoder := xml.NewDecoder(r)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if element, ok := token.(xml.StartElement); ok {
+			if err := importer.element(element); err != nil {
+				return nil, fmt.Errorf("mjlog tag %s: %w", element.Name.Local, err)
+			}
+		}
+	}
+	importer.finishRound()
+	if importer.log.Rule == nil {
+		return nil, errors.New("mjlog: no GO tag")
+	}
+	return importer.log, nil
+}
+
+// mjlogImporter reads the tags of a mjlog in order
+type mjlogImporter struct {
+	log   *TenhouLog
+	round *tenhouRound
+}
+
+// element handles one tag
+func (im *mjlogImporter) element(element xml.StartElement) error {
+	attrs := make(map[string]string, len(element.Attr))
+	for _, attr := range element.Attr {
+		attrs[attr.Name.Local] = attr.Value
+	}
+	name := element.Name.Local
+
+	// T46, U46, V46, W46 are draws and D46, E46, F46, G46 are discards of the seats
+	if len(name) > 1 {
+		if n, err := strconv.Atoi(name[1:]); err == nil {
+			seat := strings.IndexByte("TUVW", name[0])
+			isDraw := seat != -1
+			if !isDraw {
+				seat = strings.IndexByte("DEFG", name[0])
+			}
+			if seat != -1 {
+				if im.round == nil {
+					return errors.New("no INIT before")
+				}
+				if seat >= im.log.Rule.NumPlayers() {
+					return fmt.Errorf("no seat %d", seat)
+				}
+				if isDraw {
+					return im.round.draw(im.round.wind(seat), Tile(n))
+				}
+				return im.round.discard(im.round.wind(seat), Tile(n))
+			}
+		}
+	}
+
+	switch name {
+	case "GO":
+		gameType, err := strconv.Atoi(attrs["type"])
+		if err != nil {
+			return err
+		}
+		im.log.Rule, err = TenhouRule(gameType)
+		return err
+	case "UN":
+		// players reconnecting send UN again
+		if im.log.Names != nil {
+			return nil
+		}
+		for i := 0; i < 4; i++ {
+			name, ok := attrs["n"+strconv.Itoa(i)]
+			if !ok {
+				break
+			}
+			name, err := url.PathUnescape(name)
+			if err != nil {
+				return err
+			}
+			im.log.Names = append(im.log.Names, name)
+		}
+		return nil
+	case "SHUFFLE":
+		im.log.Seed = attrs["seed"]
+		return nil
+	case "INIT":
+		return im.init(attrs)
+	case "N", "REACH", "DORA", "AGARI", "RYUUKYOKU":
+		if im.round == nil {
+			return errors.New("no INIT before")
+		}
+	default:
+		return nil
+	}
+
+	round := im.round
+	who, err := im.seatAttr(attrs, "who")
+	if err != nil && name != "DORA" && name != "RYUUKYOKU" {
+		return err
+	}
+	switch name {
+	case "N":
+		m, err := strconv.Atoi(attrs["m"])
+		if err != nil {
+			return err
+		}
+		callType, tiles, called := decodeTenhouMeld(m)
+		switch callType {
+		case Chi, Pon, DaiMinKan:
+			return round.claim(who, callType, tiles, called)
+		case AnKan:
+			return round.closedKan(who, tiles)
+		case ShouMinKan:
+			return round.addedKan(who, called)
+		default:
+			return round.kita(who, called)
+		}
+	case "REACH":
+		step, err := strconv.Atoi(attrs["step"])
+		if err != nil {
+			return err
+		}
+		return round.riichi(who, step)
+	case "DORA":
+		tile, err := strconv.Atoi(attrs["hai"])
+		if err != nil {
+			return err
+		}
+		return round.dora(Tile(tile))
+	case "AGARI":
+		from, err := im.seatAttr(attrs, "fromWho")
+		if err != nil {
+			return err
+		}
+		pao := WindDummy
+		if _, ok := attrs["paoWho"]; ok {
+			if pao, err = im.seatAttr(attrs, "paoWho"); err != nil {
+				return err
+			}
+		}
+		hand, err := parseTenhouTiles(attrs["hai"])
+		if err != nil {
+			return err
+		}
+		winTile, err := strconv.Atoi(attrs["machi"])
+		if err != nil {
+			return err
+		}
+		doraIndicators, err := parseTenhouTiles(attrs["doraHai"])
+		if err != nil {
+			return err
+		}
+		uraIndicators, err := parseTenhouTiles(attrs["doraHaiUra"])
+		if err != nil {
+			return err
+		}
+		if err = round.indicators(doraIndicators, uraIndicators); err != nil {
+			return err
+		}
+		pointsChange, err := im.pointsChange(attrs["sc"])
+		if err != nil {
+			return err
+		}
+		return round.win(who, from, pao, hand, Tile(winTile), pointsChange)
+	default:
+		hands := make(map[Wind]Tiles)
+		for seat := 0; seat < im.log.Rule.NumPlayers(); seat++ {
+			hai, ok := attrs["hai"+strconv.Itoa(seat)]
+			if !ok {
+				continue
+			}
+			hand, err := parseTenhouTiles(hai)
+			if err != nil {
+				return err
+			}
+			hands[round.wind(seat)] = hand
+		}
+		reason, ok := tenhouRyuuKyokuReasons[attrs["type"]]
+		if !ok {
+			return fmt.Errorf("unknown ryuu kyoku type %q", attrs["type"])
+		}
+		pointsChange, err := im.pointsChange(attrs["sc"])
+		if err != nil {
+			return err
+		}
+		return round.ryuuKyoku(reason, attrs["type"] == "nm", hands, pointsChange)
+	}
+}
+
+// tenhouRyuuKyokuReasons reasons of the type attribute of the RYUUKYOKU tag, nagashi mangan(nm) is a normal ryuu kyoku
+var tenhouRyuuKyokuReasons = map[string]RyuuKyokuReason{
+	"":       RyuuKyokuNormal,
+	"nm":     RyuuKyokuNormal,
+	"yao9":   RyuuKyokuKyuuShuKyuuHai,
+	"reach4": RyuuKyokuSuuChaRiichi,
+	"kan4":   RyuuKyokuSuuKaiKan,
+	"kaze4":  RyuuKyokuSuufonRenda,
+	"ron3":   RyuuKyokuSanChaHou,
+}
+
+// init starts a new round by the INIT tag
+func (im *mjlogImporter) init(attrs map[string]string) error {
+	im.finishRound()
+	rule := im.log.Rule
+	if rule == nil {
+		return errors.New("no GO before")
+	}
+	seed, err := parseTenhouInts(attrs["seed"])
+	if err != nil {
+		return err
+	}
+	if len(seed) != 6 {
+		return fmt.Errorf("seed %q must have 6 values", attrs["seed"])
+	}
+	ten, err := parseTenhouInts(attrs["ten"])
+	if err != nil {
+		return err
+	}
+	if len(ten) < rule.NumPlayers() {
+		return fmt.Errorf("ten %q must have %d values", attrs["ten"], rule.NumPlayers())
+	}
+	dealer, err := strconv.Atoi(attrs["oya"])
+	if err != nil {
+		return err
+	}
+	var points []int
+	var hands []Tiles
+	for seat := 0; seat < rule.NumPlayers(); seat++ {
+		points = append(points, ten[seat]*100)
+		hand, err := parseTenhouTiles(attrs["hai"+strconv.Itoa(seat)])
+		if err != nil {
+			return err
+		}
+		hands = append(hands, hand)
+	}
+	im.round, err = newTenhouRound(rule, len(im.log.Rounds), seed[0], seed[1], seed[2], Tile(seed[5]), dealer, points, hands)
+	return err
+}
+
+// finishRound adds the current round to the log
+func (im *mjlogImporter) finishRound() {
+	if im.round != nil {
+		im.log.Rounds = append(im.log.Rounds, im.round.finish())
+		im.round = nil
+	}
+}
+
+// seatAttr returns the wind of the seat in the attribute
+func (im *mjlogImporter) seatAttr(attrs map[string]string, key string) (Wind, error) {
+	seat, err := strconv.Atoi(attrs[key])
+	if err != nil {
+		return WindDummy, fmt.Errorf("%s: %w", key, err)
+	}
+	if seat < 0 || seat >= im.log.Rule.NumPlayers() {
+		return WindDummy, fmt.Errorf("%s: no seat %d", key, seat)
+	}
+	return im.round.wind(seat), nil
+}
+
+// pointsChange parses the sc attribute, points and changes of every seat in hundreds
+func (im *mjlogImporter) pointsChange(sc string) (map[Wind]int, error) {
+	values, err := parseTenhouInts(sc)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) < 2*im.log.Rule.NumPlayers() {
+		return nil, fmt.Errorf("sc %q must have %d values", sc, 2*im.log.Rule.NumPlayers())
+	}
+	pointsChange := make(map[Wind]int, im.log.Rule.NumPlayers())
+	for seat := 0; seat < im.log.Rule.NumPlayers(); seat++ {
+		pointsChange[im.round.wind(seat)] = values[2*seat+1] * 100
+	}
+	return pointsChange, nil
+}
+
+// decodeTenhouMeld decodes the m attribute of the N tag into the tiles from the hand in order and the tile called,
+// the tile called is the tile added for ShouMinKan and the north tile for Kita
+func decodeTenhouMeld(m int) (callType CallType, tiles Tiles, called Tile) {
+	switch {
+	case m&0x4 != 0:
+		t := (m & 0xfc00) >> 10
+		r := t % 3
+		t /= 3
+		base := (t/7*9 + t%7) * 4
+		run := Tiles{Tile(base + (m&0x18)>>3), Tile(base + 4 + (m&0x60)>>5), Tile(base + 8 + (m&0x180)>>7)}
+		return Chi, append(run[:r:r], run[r+1:]...), run[r]
+	case m&0x8 != 0:
+		unused := (m & 0x60) >> 5
+		t := (m & 0xfe00) >> 9
+		r := t % 3
+		var set Tiles
+		for i := 0; i < 4; i++ {
+			if i != unused {
+				set = append(set, Tile(t/3*4+i))
+			}
+		}
+		return Pon, append(set[:r:r], set[r+1:]...), set[r]
+	case m&0x10 != 0:
+		added := (m & 0x60) >> 5
+		t := (m & 0xfe00) >> 9
+		return ShouMinKan, nil, Tile(t/3*4 + added)
+	case m&0x20 != 0:
+		return Kita, nil, Tile(m >> 8)
+	default:
+		hai := (m & 0xff00) >> 8
+		set := Tile(hai).Class().To4Tiles()
+		if m&0x3 == 0 {
+			return AnKan, set, TileDummy
+		}
+		set.Remove(Tile(hai))
+		return DaiMinKan, set, Tile(hai)
+	}
+}
+
+// parseTenhouInts parses comma separated integers, an empty string for none
+func parseTenhouInts(s string) ([]int, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var values []int
+	for _, v := range strings.Split(s, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, n)
+	}
+	return values, nil
+}
+
+// parseTenhouTiles parses comma separated tile ids of Tenhou, the same as Tile
+func parseTenhouTiles(s string) (Tiles, error) {
+	values, err := parseTenhouInts(s)
+	if err != nil {
+		return nil, err
+	}
+	tiles := make(Tiles, 0, len(values))
+	for _, v := range values {
+		tiles = append(tiles, Tile(v))
+	}
+	return tiles, nil
+}
+
+// tenhouRound builds the global events of a round recorded by Tenhou and puts every tile revealed into the wall
+type tenhouRound struct {
+	rule      *Rule
+	init      *EventGlobalInit
+	events    Events
+	dealer    int
+	probe     *MahjongTiles // wall whose position i holds the i-th tile, to find the position of every draw
+	positions map[Tile]int
+	wall      Tiles
+	placed    map[Tile]bool
+
+	numIndicators int
+	lastDrawn     map[Wind]Tile // the tile just drawn, removed after a discard or a call
+	lastDrawer    Wind
+	lastDiscard   Tile
+	lastDiscarder Wind
+	dealRinshan   map[Wind]bool
+	dealKita      map[Wind]bool
+	melds         map[Wind]Calls
+	pointsChange  map[Wind]int
+}
+
+// newTenhouRound starts a round, points and hands are indexed by the seat of Tenhou
+func newTenhouRound(rule *Rule, numGame, round, numHonba, numRiichi int, doraIndicator Tile, dealer int, points []int, hands []Tiles) (*tenhouRound, error) {
+	numPlayers := rule.NumPlayers()
+	if round < 0 || WindRoundEast1+WindRound(round) > WindRoundNorth4 || (rule.IsSanma && round%4 == 3) {
+		return nil, fmt.Errorf("no round %d", round)
+	}
+	if dealer != round%4 {
+		return nil, fmt.Errorf("dealer of round %d can't be seat %d", round, dealer)
+	}
+	probe := newMahjongTiles(nil, rule.IsSanma)
+	probe.Reset()
+	probe.Setup(probe.wallTiles())
+	r := &tenhouRound{
+		rule: rule,
+		init: &EventGlobalInit{
+			WindRound:  WindRoundEast1 + WindRound(round),
+			NumGame:    numGame,
+			NumHonba:   numHonba,
+			NumRiichi:  numRiichi,
+			Rule:       rule,
+			InitPoints: make(map[Wind]int, numPlayers),
+		},
+		dealer:        dealer,
+		probe:         probe,
+		positions:     make(map[Tile]int, len(probe.tiles)),
+		wall:          make(Tiles, len(probe.tiles)),
+		placed:        make(map[Tile]bool, len(probe.tiles)),
+		lastDrawn:     make(map[Wind]Tile),
+		lastDiscard:   TileDummy,
+		lastDiscarder: WindDummy,
+		dealRinshan:   make(map[Wind]bool),
+		dealKita:      make(map[Wind]bool),
+		melds:         make(map[Wind]Calls),
+		pointsChange:  make(map[Wind]int, numPlayers),
+	}
+	r.events = Events{r.init}
+	for i, tile := range probe.tiles {
+		r.positions[tile] = i
+		r.wall[i] = TileDummy
+	}
+	for seat := 0; seat < numPlayers; seat++ {
+		wind := r.wind(seat)
+		r.init.InitPoints[wind] = points[seat]
+		r.pointsChange[wind] = 0
+		if len(hands[seat]) != 13 {
+			return nil, fmt.Errorf("seat %d is dealt %d tiles", seat, len(hands[seat]))
+		}
+		for i, tile := range hands[seat] {
+			if err := r.place(13*int(wind)+i, tile); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return r, r.place(len(r.wall)-6, doraIndicator)
+}
+
+// wind returns the seat wind of the seat of Tenhou
+func (r *tenhouRound) wind(seat int) Wind {
+	numPlayers := r.rule.NumPlayers()
+	return Wind((seat - r.dealer + numPlayers) % numPlayers)
+}
+
+// place puts the tile at the wall position, placing the same tile again is allowed
+func (r *tenhouRound) place(slot int, tile Tile) error {
+	if r.wall[slot] == tile {
+		return nil
+	}
+	if tile < 0 || int(tile) >= NumTiles || (r.rule.IsSanma && common.SliceContain(SanmaRemovedTileClasses, tile.Class())) {
+		return fmt.Errorf("tile %d is not in the wall", tile)
+	}
+	if r.placed[tile] {
+		return fmt.Errorf("tile %s appears twice", tile)
+	}
+	if r.wall[slot] != TileDummy {
+		return fmt.Errorf("wall position %d holds %s and %s", slot, r.wall[slot], tile)
+	}
+	r.wall[slot] = tile
+	r.placed[tile] = true
+	return nil
+}
+
+func (r *tenhouRound) draw(who Wind, tile Tile) error {
+	if r.probe.NumRemainTiles <= 0 {
+		return errors.New("no tile left in the wall")
+	}
+	var dealt Tile
+	switch {
+	case r.dealRinshan[who]:
+		if r.probe.kanNum == 4 {
+			return errors.New("no rinshan tile left")
+		}
+		dealt = r.probe.DealTile(true)
+	case r.dealKita[who]:
+		dealt = r.probe.DealKitaTile()
+	default:
+		dealt = r.probe.DealTile(false)
+	}
+	r.dealRinshan[who] = false
+	r.dealKita[who] = false
+	r.lastDrawn[who] = tile
+	r.lastDrawer = who
+	r.events = append(r.events, &EventGet{Who: who, Tile: tile})
+	return r.place(r.positions[dealt], tile)
+}
+
+func (r *tenhouRound) discard(who Wind, tile Tile) error {
+	if drawn, ok := r.lastDrawn[who]; ok && drawn == tile {
+		r.events = append(r.events, &EventTsumoGiri{Who: who, Tile: tile})
+	} else {
+		r.events = append(r.events, &EventDiscard{Who: who, Tile: tile})
+	}
+	delete(r.lastDrawn, who)
+	r.lastDiscard = tile
+	r.lastDiscarder = who
+	return nil
+}
+
+// claim adds a chi, pon or daiminkan of the last discard, tiles are the tiles from the hand
+func (r *tenhouRound) claim(who Wind, callType CallType, tiles Tiles, called Tile) error {
+	if called != r.lastDiscard || r.lastDiscarder == who {
+		return fmt.Errorf("%s of %s is not the last discard", callType, called)
+	}
+	call := &Call{
+		CallType:         callType,
+		CallTiles:        append(tiles.Copy(), called),
+		CallTilesFromWho: []Wind{who, who, r.lastDiscarder, WindDummy},
+	}
+	switch callType {
+	case Chi:
+		call.CallTiles = append(call.CallTiles, TileDummy)
+		r.events = append(r.events, &EventChi{Who: who, Call: call})
+	case Pon:
+		call.CallTiles = append(call.CallTiles, TileDummy)
+		r.events = append(r.events, &EventPon{Who: who, Call: call})
+	default:
+		call.CallTilesFromWho = []Wind{who, who, who, r.lastDiscarder}
+		r.events = append(r.events, &EventDaiMinKan{Who: who, Call: call})
+		r.dealRinshan[who] = true
+	}
+	delete(r.lastDrawn, who)
+	r.lastDiscard = TileDummy
+	r.melds[who] = append(r.melds[who], call)
+	return nil
+}
+
+func (r *tenhouRound) closedKan(who Wind, tiles Tiles) error {
+	call := &Call{
+		CallType:         AnKan,
+		CallTiles:        tiles.Copy(),
+		CallTilesFromWho: []Wind{who, who, who, who},
+	}
+	r.events = append(r.events, &EventAnKan{Who: who, Call: call})
+	delete(r.lastDrawn, who)
+	r.dealRinshan[who] = true
+	r.melds[who] = append(r.melds[who], call)
+	return nil
+}
+
+// addedKan adds the tile to the pon of the same class
+func (r *tenhouRound) addedKan(who Wind, tile Tile) error {
+	for i, meld := range r.melds[who] {
+		if meld.CallType != Pon || meld.CallTiles[0].Class() != tile.Class() {
+			continue
+		}
+		call := meld.Copy()
+		call.CallType = ShouMinKan
+		call.CallTiles = append(call.CallTiles[:3], tile)
+		call.CallTilesFromWho = append(call.CallTilesFromWho[:3], who)
+		r.melds[who][i] = call
+		r.events = append(r.events, &EventShouMinKan{Who: who, Call: call})
+		delete(r.lastDrawn, who)
+		r.dealRinshan[who] = true
+		return nil
+	}
+	return fmt.Errorf("no pon for the kan of %s", tile)
+}
+
+func (r *tenhouRound) kita(who Wind, tile Tile) error {
+	if !r.rule.IsSanma || tile.Class() != Pei {
+		return fmt.Errorf("kita of %s", tile)
+	}
+	r.events = append(r.events, &EventKita{Who: who, Tile: tile})
+	delete(r.lastDrawn, who)
+	r.dealKita[who] = true
+	return nil
+}
+
+func (r *tenhouRound) riichi(who Wind, step int) error {
+	if step != 1 && step != 2 {
+		return fmt.Errorf("riichi step %d", step)
+	}
+	r.events = append(r.events, &EventRiichi{Who: who, Step: step})
+	return nil
+}
+
+// dora reveals the next dora indicator
+func (r *tenhouRound) dora(tile Tile) error {
+	r.numIndicators++
+	if r.numIndicators > 4 {
+		return errors.New("more than 5 dora indicators")
+	}
+	r.events = append(r.events, &EventNewIndicator{Tile: tile})
+	return r.place(len(r.wall)-6-2*r.numIndicators, tile)
+}
+
+// indicators puts the dora and ura dora indicators revealed at the end of the round into the wall
+func (r *tenhouRound) indicators(doraIndicators, uraIndicators Tiles) error {
+	for i, tile := range doraIndicators {
+		if err := r.place(len(r.wall)-6-2*i, tile); err != nil {
+			return err
+		}
+	}
+	for i, tile := range uraIndicators {
+		if err := r.place(len(r.wall)-5-2*i, tile); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// win adds a tsumo, ron or chan kan, hand includes the win tile
+func (r *tenhouRound) win(who, from, pao Wind, hand Tiles, winTile Tile, pointsChange map[Wind]int) error {
+	if !common.SliceContain(hand, winTile) {
+		return fmt.Errorf("win tile %s is not in the hand", winTile)
+	}
+	for wind, points := range pointsChange {
+		r.pointsChange[wind] += points
+	}
+	if who == from {
+		r.events = append(r.events, &EventTsumo{Who: who, PaoWho: pao, HandTiles: hand.Copy(), WinTile: winTile})
+		return nil
+	}
+	handTiles := hand.Copy()
+	handTiles.Remove(winTile)
+
+	// a win on a kan is a chan kan, the winners of a multiple ron follow each other
+	var last Event
+	for i := len(r.events) - 1; i >= 0; i-- {
+		if t := r.events[i].GetType(); t != EventTypeRon && t != EventTypeChanKan {
+			last = r.events[i]
+			break
+		}
+	}
+	switch last.GetType() {
+	case EventTypeShouMinKan, EventTypeAnKan:
+		r.events = append(r.events, &EventChanKan{Who: who, FromWho: from, PaoWho: pao, HandTiles: handTiles, WinTile: winTile})
+	default:
+		r.events = append(r.events, &EventRon{Who: who, FromWho: from, PaoWho: pao, HandTiles: handTiles, WinTile: winTile})
+	}
+	return nil
+}
+
+// ryuuKyoku ends the round without a winner, hands are the hands revealed
+func (r *tenhouRound) ryuuKyoku(reason RyuuKyokuReason, isNagashi bool, hands map[Wind]Tiles, pointsChange map[Wind]int) error {
+	for wind, points := range pointsChange {
+		r.pointsChange[wind] += points
+	}
+	switch reason {
+	case RyuuKyokuNormal:
+		// the nagashi mangan players are the only players paid
+		for wind := Wind(0); int(wind) < r.rule.NumPlayers(); wind++ {
+			if isNagashi && pointsChange[wind] > 0 {
+				r.events = append(r.events, &EventNagashiMangan{Who: wind})
+			}
+		}
+		for wind := Wind(0); int(wind) < r.rule.NumPlayers(); wind++ {
+			if hand, ok := hands[wind]; ok {
+				r.events = append(r.events, &EventTenpaiEnd{
+					Who:         wind,
+					HandTiles:   hand,
+					TenpaiSlice: GetTenpaiSlice(hand, append(Calls{}, r.melds[wind]...)),
+				})
+			}
+		}
+		r.events = append(r.events, &EventRyuuKyoku{Who: East, Reason: reason})
+	case RyuuKyokuKyuuShuKyuuHai:
+		r.events = append(r.events, &EventRyuuKyoku{Who: r.lastDrawer, HandTiles: hands[r.lastDrawer], Reason: reason})
+	default:
+		r.events = append(r.events, &EventRyuuKyoku{Who: East, Reason: reason})
+	}
+	return nil
+}
+
+// finish fills the wall positions never revealed with the unseen tiles in order and returns the events of the round
+func (r *tenhouRound) finish() Events {
+	var unseen Tiles
+	for _, tile := range r.probe.wallTiles() {
+		if !r.placed[tile] {
+			unseen = append(unseen, tile)
+		}
+	}
+	for i, tile := range r.wall {
+		if tile == TileDummy {
+			r.wall[i] = unseen[0]
+			unseen = unseen[1:]
+		}
+	}
+	r.init.AllTiles = r.wall
+	r.events = append(r.events, &EventEnd{PointsChange: r.pointsChange})
+	return r.events
+}
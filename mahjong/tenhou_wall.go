@@ -0,0 +1,212 @@
+package mahjong
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// TenhouSeedPrefix prefix of the seed attribute of the SHUFFLE tag in Tenhou logs
+const TenhouSeedPrefix = "mt19937ar-sha512-n288-base64,"
+
+const (
+	mtN         = 624
+	mtM         = 397
+	mtMatrixA   = 0x9908b0df
+	mtUpperMask = 0x80000000
+	mtLowerMask = 0x7fffffff
+
+	tenhouSeedBytes   = mtN * 4
+	tenhouSrcWords    = 288
+	tenhouRndWords    = tenhouSrcWords / 2
+	tenhouSrcBlockLen = sha512.BlockSize
+)
+
+// mt19937 the 32-bit Mersenne Twister of mt19937ar.c
+type mt19937 struct {
+	mt  [mtN]uint32
+	mti int
+}
+
+// initGenrand initializes the state by a seed
+func (m *mt19937) initGenrand(s uint32) {
+	m.mt[0] = s
+	for i := 1; i < mtN; i++ {
+		m.mt[i] = 1812433253*(m.mt[i-1]^(m.mt[i-1]>>30)) + uint32(i)
+	}
+	m.mti = mtN
+}
+
+// initByArray initializes the state by an array of seeds
+func (m *mt19937) initByArray(key []uint32) {
+	m.initGenrand(19650218)
+	i, j := 1, 0
+	k := mtN
+	if len(key) > k {
+		k = len(key)
+	}
+	for ; k > 0; k-- {
+		m.mt[i] = (m.mt[i] ^ ((m.mt[i-1] ^ (m.mt[i-1] >> 30)) * 1664525)) + key[j] + uint32(j)
+		i++
+		j++
+		if i >= mtN {
+			m.mt[0] = m.mt[mtN-1]
+			i = 1
+		}
+		if j >= len(key) {
+			j = 0
+		}
+	}
+	for k = mtN - 1; k > 0; k-- {
+		m.mt[i] = (m.mt[i] ^ ((m.mt[i-1] ^ (m.mt[i-1] >> 30)) * 1566083941)) - uint32(i)
+		i++
+		if i >= mtN {
+			m.mt[0] = m.mt[mtN-1]
+			i = 1
+		}
+	}
+	m.mt[0] = 0x80000000
+}
+
+// genrandInt32 generates a random number on [0,0xffffffff]
+func (m *mt19937) genrandInt32() uint32 {
+	mag01 := [2]uint32{0, mtMatrixA}
+	if m.mti >= mtN {
+		var kk int
+		for ; kk < mtN-mtM; kk++ {
+			y := (m.mt[kk] & mtUpperMask) | (m.mt[kk+1] & mtLowerMask)
+			m.mt[kk] = m.mt[kk+mtM] ^ (y >> 1) ^ mag01[y&1]
+		}
+		for ; kk < mtN-1; kk++ {
+			y := (m.mt[kk] & mtUpperMask) | (m.mt[kk+1] & mtLowerMask)
+			m.mt[kk] = m.mt[kk+(mtM-mtN)] ^ (y >> 1) ^ mag01[y&1]
+		}
+		y := (m.mt[mtN-1] & mtUpperMask) | (m.mt[0] & mtLowerMask)
+		m.mt[mtN-1] = m.mt[mtM-1] ^ (y >> 1) ^ mag01[y&1]
+		m.mti = 0
+	}
+	y := m.mt[m.mti]
+	m.mti++
+	y ^= y >> 11
+	y ^= (y << 7) & 0x9d2c5680
+	y ^= (y << 15) & 0xefc60000
+	y ^= y >> 18
+	return y
+}
+
+// TenhouWallGenerator deals the walls of Tenhou from the seed of a Tenhou game,
+// the wall of the n-th round is the n-th wall shuffled by the seed
+type TenhouWallGenerator struct {
+	key []uint32
+}
+
+// NewTenhouWallGenerator
+//
+//	@Description: create a wall generator from the seed of a Tenhou game
+//	@param seed: base64 seed of the SHUFFLE tag, with or without TenhouSeedPrefix
+//	@return *TenhouWallGenerator
+//	@return error
+func NewTenhouWallGenerator(seed string) (*TenhouWallGenerator, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(seed, TenhouSeedPrefix))
+	if err != nil {
+		return nil, fmt.Errorf("tenhou seed: %w", err)
+	}
+	if len(data) != tenhouSeedBytes {
+		return nil, fmt.Errorf("tenhou seed: %d bytes, expect %d", len(data), tenhouSeedBytes)
+	}
+	key := make([]uint32, mtN)
+	for i := range key {
+		key[i] = binary.LittleEndian.Uint32(data[4*i:])
+	}
+	return &TenhouWallGenerator{key: key}, nil
+}
+
+// Yama
+//
+//	@Description: the wall of the round in the order of Tenhou, hands are dealt from yama[135] down,
+//	the dead wall is yama[0:14] with the dora indicator yama[5]
+//	@param numGame: number of the round in the game, from 0
+//	@return Tiles: yama
+//	@return [2]int: dice, from 0 to 5
+func (g *TenhouWallGenerator) Yama(numGame int) (Tiles, [2]int) {
+	var m mt19937
+	m.initByArray(g.key)
+	for i := 0; i < numGame*tenhouSrcWords; i++ {
+		m.genrandInt32()
+	}
+
+	src := make([]byte, tenhouSrcWords*4)
+	for i := 0; i < tenhouSrcWords; i++ {
+		binary.LittleEndian.PutUint32(src[4*i:], m.genrandInt32())
+	}
+	rnd := make([]uint32, 0, tenhouRndWords)
+	for i := 0; i < len(src); i += tenhouSrcBlockLen {
+		sum := sha512.Sum512(src[i : i+tenhouSrcBlockLen])
+		for j := 0; j < len(sum); j += 4 {
+			rnd = append(rnd, binary.LittleEndian.Uint32(sum[j:]))
+		}
+	}
+
+	yama := make(Tiles, NumTiles)
+	for i := range yama {
+		yama[i] = Tile(i)
+	}
+	for i := 0; i < NumTiles-1; i++ {
+		j := i + int(rnd[i]%uint32(NumTiles-i))
+		yama[i], yama[j] = yama[j], yama[i]
+	}
+	return yama, [2]int{int(rnd[135] % 6), int(rnd[136] % 6)}
+}
+
+// TenhouYamaToWall
+//
+//	@Description: arrange a four-player wall of Tenhou as the wall of MahjongTiles,
+//	hands are dealt 4, 4, 4 and 1 tiles from the dealer, kan dora indicators are yama[7], yama[9]...,
+//	ura dora indicators are yama[4], yama[6]... and rinshan tiles are yama[1], yama[0], yama[3], yama[2]
+//	@param yama: wall in the order of Tenhou
+//	@return Tiles: wall of MahjongTiles
+func TenhouYamaToWall(yama Tiles) Tiles {
+	wall := make(Tiles, 0, NumTiles)
+	p := NumTiles - 1
+	hands := make([]Tiles, 4)
+	for n := 0; n < 13; {
+		num := 4
+		if n == 12 {
+			num = 1
+		}
+		for seat := range hands {
+			for i := 0; i < num; i++ {
+				hands[seat] = append(hands[seat], yama[p])
+				p--
+			}
+		}
+		n += num
+	}
+	for _, hand := range hands {
+		wall = append(wall, hand...)
+	}
+	for ; p >= NumDeadWallTiles; p-- {
+		wall = append(wall, yama[p])
+	}
+	// dead wall from the last kan dora indicator to the first rinshan tile
+	for i := NumDeadWallTiles - 1; i >= numRinshanTiles; i-- {
+		wall = append(wall, yama[i])
+	}
+	return append(wall, yama[2], yama[3], yama[0], yama[1])
+}
+
+func (g *TenhouWallGenerator) Wall(info WallInfo, _ Tiles) (Tiles, error) {
+	if info.IsSanma {
+		return nil, errors.New("tenhou walls of three-player games are not supported")
+	}
+	yama, _ := g.Yama(info.NumGame)
+	return TenhouYamaToWall(yama), nil
+}
+
+// Clone shares the key, every wall is generated from the key alone
+func (g *TenhouWallGenerator) Clone() WallGenerator {
+	return g
+}
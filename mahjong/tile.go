@@ -3,12 +3,17 @@ package mahjong
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/hphphp123321/go-common"
 	"math/rand"
+	"reflect"
 	"sort"
 )
 
 const NumTiles int = 136
+const NumSanmaTiles int = 108
+const NumDeadWallTiles int = 14
+const numRinshanTiles int = 4
 
 func (tile Tile) Class() TileClass {
 	if tile == TileDummy {
@@ -71,11 +76,14 @@ func newTile(tileId Tile) *TileT {
 }
 
 type MahjongTiles struct {
-	randP *rand.Rand
+	randP  *rand.Rand
+	source *seededSource // source of randP if it is created by the game, nil for a random source given by the caller
 
+	isSanma        bool
 	allTiles       map[Tile]*TileT
 	tiles          Tiles
 	kanNum         int
+	rinshanNum     int // tiles dealt from the dead wall, kan and kita both count
 	NumRemainTiles int
 
 	tilePointer    int
@@ -83,64 +91,181 @@ type MahjongTiles struct {
 }
 
 func NewMahjongTiles(randP *rand.Rand) *MahjongTiles {
+	return newMahjongTiles(randP, false)
+}
+
+// NewSanmaTiles
+//
+//	@Description: create the 108 tiles wall for three-player mahjong, 2m~8m are removed
+//	@param randP: random source, nil for a fixed seed
+//	@return *MahjongTiles
+func NewSanmaTiles(randP *rand.Rand) *MahjongTiles {
+	return newMahjongTiles(randP, true)
+}
+
+func newMahjongTiles(randP *rand.Rand, isSanma bool) *MahjongTiles {
 	if randP == nil {
 		randP = rand.New(rand.NewSource(1))
 	}
 	mahjongTiles := MahjongTiles{
-		allTiles: make(map[Tile]*TileT, NumTiles),
-		tiles:    make(Tiles, NumTiles),
-		randP:    randP,
+		isSanma: isSanma,
+		randP:   randP,
 	}
-	for i := 0; i < NumTiles; i++ {
-		mahjongTiles.allTiles[Tile(i)] = newTile(Tile(i))
-		mahjongTiles.tiles[i] = Tile(i)
+	mahjongTiles.tiles = mahjongTiles.wallTiles()
+	mahjongTiles.allTiles = make(map[Tile]*TileT, len(mahjongTiles.tiles))
+	for _, tile := range mahjongTiles.tiles {
+		mahjongTiles.allTiles[tile] = newTile(tile)
 	}
 	return &mahjongTiles
 }
 
-func (tiles *MahjongTiles) Reset() {
-
+// wallTiles returns all tiles of the wall in order
+func (tiles *MahjongTiles) wallTiles() Tiles {
+	ts := make(Tiles, 0, NumTiles)
 	for i := 0; i < NumTiles; i++ {
-		tiles.allTiles[Tile(i)] = newTile(Tile(i))
-		tiles.tiles[i] = Tile(i)
+		if tiles.isSanma && common.SliceContain(SanmaRemovedTileClasses, Tile(i).Class()) {
+			continue
+		}
+		ts = append(ts, Tile(i))
+	}
+	return ts
+}
+
+func (tiles *MahjongTiles) numPlayers() int {
+	if tiles.isSanma {
+		return 3
+	}
+	return 4
+}
+
+// seededSource is a math/rand source which can be copied in its state
+type seededSource struct {
+	src rand.Source64
+}
+
+func newSeededSource(seed int64) *seededSource {
+	return &seededSource{
+		src: rand.NewSource(seed).(rand.Source64),
+	}
+}
+
+func (s *seededSource) Int63() int64 {
+	return s.src.Int63()
+}
+
+func (s *seededSource) Uint64() uint64 {
+	return s.src.Uint64()
+}
+
+func (s *seededSource) Seed(seed int64) {
+	s.src.Seed(seed)
+}
+
+// clone returns a source in the same state, the state of the math/rand source is copied
+func (s *seededSource) clone() *seededSource {
+	state := reflect.ValueOf(s.src).Elem()
+	src := reflect.New(state.Type())
+	src.Elem().Set(state)
+	return &seededSource{src: src.Interface().(rand.Source64)}
+}
+
+// Clone
+//
+//	@Description: deep copy the tiles, the random source is copied only if it is created by the game, otherwise it is shared
+//	@receiver tiles
+//	@return *MahjongTiles
+func (tiles *MahjongTiles) Clone() *MahjongTiles {
+	t := *tiles
+	if tiles.source != nil {
+		t.source = tiles.source.clone()
+		t.randP = rand.New(t.source)
 	}
-	tiles.randP.Shuffle(NumTiles, func(i, j int) {
+	t.allTiles = make(map[Tile]*TileT, len(tiles.allTiles))
+	for tile, tileT := range tiles.allTiles {
+		tt := *tileT
+		t.allTiles[tile] = &tt
+	}
+	t.tiles = tiles.tiles.Copy()
+	return &t
+}
+
+func (tiles *MahjongTiles) Reset() {
+	tiles.tiles = tiles.wallTiles()
+	tiles.randP.Shuffle(len(tiles.tiles), func(i, j int) {
 		tiles.tiles[i], tiles.tiles[j] = tiles.tiles[j], tiles.tiles[i]
 	})
+	tiles.resetPointers()
+}
 
+// ResetWall
+//
+//	@Description: reset the tiles with a prepared wall instead of shuffling
+//	@receiver tiles
+//	@param wall: the wall, must hold every tile of the game once
+//	@return error
+func (tiles *MahjongTiles) ResetWall(wall Tiles) error {
+	if err := tiles.checkWall(wall); err != nil {
+		return err
+	}
+	tiles.setWall(wall)
+	return nil
+}
+
+// checkWall checks the wall holds every tile of the game once
+func (tiles *MahjongTiles) checkWall(wall Tiles) error {
+	allTiles := tiles.wallTiles()
+	if len(wall) != len(allTiles) {
+		return fmt.Errorf("len of the wall must be %d, got %d", len(allTiles), len(wall))
+	}
+	var count = make(map[Tile]int, len(wall))
+	for _, tile := range wall {
+		count[tile]++
+	}
+	for _, tile := range allTiles {
+		if count[tile] != 1 {
+			return fmt.Errorf("tile %s appears %d times in the wall", tile, count[tile])
+		}
+	}
+	return nil
+}
+
+// setWall resets the tiles with the checked wall
+func (tiles *MahjongTiles) setWall(wall Tiles) {
+	tiles.tiles = wall.Copy()
+	tiles.resetPointers()
+}
+
+// resetPointers clears the tile states and the pointers for a new wall
+func (tiles *MahjongTiles) resetPointers() {
+	for _, tile := range tiles.tiles {
+		tiles.allTiles[tile] = newTile(tile)
+	}
 	tiles.kanNum = 0
-	tiles.NumRemainTiles = 70
-	tiles.tilePointer = 52
-	tiles.rinshanPointer = 135
+	tiles.rinshanNum = 0
+	tiles.NumRemainTiles = len(tiles.tiles) - NumDeadWallTiles - 13*tiles.numPlayers()
+	tiles.tilePointer = 13 * tiles.numPlayers()
+	tiles.rinshanPointer = len(tiles.tiles) - 1
 }
 
 // Setup
 //
 //	@Description: setup tiles for each player
 //	@receiver tiles
-//	@param ts: prepared tiles, len must be 136, nil for default random tiles
+//	@param ts: prepared tiles, len must be 136(108 for sanma), nil for default random tiles
 //	@return map[Wind]Tiles
 func (tiles *MahjongTiles) Setup(ts Tiles) map[Wind]Tiles {
 	if ts != nil {
-		if len(ts) != NumTiles {
-			panic(errors.New("len of prepared tiles must be 136"))
+		if len(ts) != len(tiles.tiles) {
+			panic(fmt.Errorf("len of prepared tiles must be %d", len(tiles.tiles)))
 		}
 		tiles.tiles = ts
 	}
-	t := tiles.tiles[0:13]
-	tonTiles := t.Copy()
-	t = tiles.tiles[13:26]
-	nanTiles := t.Copy()
-	t = tiles.tiles[26:39]
-	shaaTiles := t.Copy()
-	t = tiles.tiles[39:52]
-	peiTiles := t.Copy()
-	return map[Wind]Tiles{
-		East:  tonTiles,
-		South: nanTiles,
-		West:  shaaTiles,
-		North: peiTiles,
+	posTiles := make(map[Wind]Tiles, tiles.numPlayers())
+	for i := 0; i < tiles.numPlayers(); i++ {
+		t := tiles.tiles[13*i : 13*(i+1)]
+		posTiles[Wind(i)] = t.Copy()
 	}
+	return posTiles
 }
 
 func (tiles *MahjongTiles) DealTile(isRinshan bool) Tile {
@@ -150,20 +275,42 @@ func (tiles *MahjongTiles) DealTile(isRinshan bool) Tile {
 	if isRinshan && tiles.kanNum == 4 {
 		panic(errors.New("no more rinshan tiles"))
 	}
+	if isRinshan {
+		tiles.kanNum++
+		return tiles.dealRinshanTile()
+	}
+	tiles.NumRemainTiles--
+	tile := tiles.tiles[tiles.tilePointer]
+	tiles.tilePointer++
+	if tiles.NumRemainTiles == 0 {
+		tiles.allTiles[tile].isLast = true
+	}
+	return tile
+}
+
+// DealKitaTile deal the replacement tile after a kita(north extraction), no new dora indicator is revealed
+func (tiles *MahjongTiles) DealKitaTile() Tile {
+	if tiles.NumRemainTiles <= 0 {
+		panic(errors.New("no more tiles"))
+	}
+	return tiles.dealRinshanTile()
+}
+
+// dealRinshanTile deal a tile from the dead wall, the dead wall is refilled from the end of the live wall,
+// so once the four rinshan tiles are used up the refilled tiles are dealt
+func (tiles *MahjongTiles) dealRinshanTile() Tile {
 	tiles.NumRemainTiles--
 	var tile Tile
-	if isRinshan {
+	if tiles.rinshanNum < numRinshanTiles {
 		tile = tiles.tiles[tiles.rinshanPointer]
 		tiles.rinshanPointer--
-		tiles.kanNum++
-		tiles.allTiles[tile].isRinshan = true
-
 	} else {
-		tile = tiles.tiles[tiles.tilePointer]
-		tiles.tilePointer++
-		if tiles.NumRemainTiles == 0 {
-			tiles.allTiles[tile].isLast = true
-		}
+		tile = tiles.tiles[tiles.tilePointer+tiles.NumRemainTiles]
+	}
+	tiles.rinshanNum++
+	tiles.allTiles[tile].isRinshan = true
+	if tiles.NumRemainTiles == 0 {
+		tiles.allTiles[tile].isLast = true
 	}
 	return tile
 }
@@ -171,7 +318,7 @@ func (tiles *MahjongTiles) DealTile(isRinshan bool) Tile {
 func (tiles *MahjongTiles) DoraIndicators() Tiles {
 	t := make(Tiles, 0, 5)
 	for i := 0; i < tiles.kanNum+1; i++ {
-		t.Append(tiles.tiles[130-2*i])
+		t.Append(tiles.tiles[len(tiles.tiles)-6-2*i])
 	}
 	return t
 }
@@ -179,7 +326,7 @@ func (tiles *MahjongTiles) DoraIndicators() Tiles {
 func (tiles *MahjongTiles) UraDoraIndicators() Tiles {
 	t := make(Tiles, 0, 5)
 	for i := 0; i < tiles.kanNum+1; i++ {
-		t.Append(tiles.tiles[131-2*i])
+		t.Append(tiles.tiles[len(tiles.tiles)-5-2*i])
 	}
 	return t
 }
@@ -396,8 +543,9 @@ func NewTenpaiInfo() *TenpaiInfo {
 }
 
 type TenpaiResult struct {
-	RemainNum int     `json:"remain_num"`
-	Result    *Result `json:"result"`
+	RemainNum int          `json:"remain_num"`
+	Result    *Result      `json:"result"`
+	Waits     []*WaitValue `json:"waits,omitempty"` // values of every tile of the class not in the hand, see WithWaitValues
 }
 
 func NewTenpaiResult(remainNum int, result *Result) *TenpaiResult {
@@ -407,6 +555,52 @@ func NewTenpaiResult(remainNum int, result *Result) *TenpaiResult {
 	}
 }
 
+// WaitValue the points of a win on a waiting tile, the points the winner gets from the others with the honba
+// and without the riichi deposits, the situational yaku like ippatsu or haitei are left out, 0 without a yaku
+type WaitValue struct {
+	Tile        Tile    `json:"tile"`
+	Ron         int     `json:"ron"`          // without riichi, even if the player has declared it
+	Tsumo       int     `json:"tsumo"`        // without riichi, even if the player has declared it
+	RiichiRon   float64 `json:"riichi_ron"`   // expected with riichi over the ura dora, 0 for an open hand
+	RiichiTsumo float64 `json:"riichi_tsumo"` // expected with riichi over the ura dora, 0 for an open hand
+}
+
+func (waitValue *WaitValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		Tile        string  `json:"tile"`
+		Ron         int     `json:"ron"`
+		Tsumo       int     `json:"tsumo"`
+		RiichiRon   float64 `json:"riichi_ron"`
+		RiichiTsumo float64 `json:"riichi_tsumo"`
+	}{
+		Tile:        waitValue.Tile.String(),
+		Ron:         waitValue.Ron,
+		Tsumo:       waitValue.Tsumo,
+		RiichiRon:   waitValue.RiichiRon,
+		RiichiTsumo: waitValue.RiichiTsumo,
+	})
+}
+
+func (waitValue *WaitValue) UnmarshalJSON(data []byte) error {
+	var tmp struct {
+		Tile        string  `json:"tile"`
+		Ron         int     `json:"ron"`
+		Tsumo       int     `json:"tsumo"`
+		RiichiRon   float64 `json:"riichi_ron"`
+		RiichiTsumo float64 `json:"riichi_tsumo"`
+	}
+	err := json.Unmarshal(data, &tmp)
+	if err != nil {
+		return err
+	}
+	waitValue.Tile = MapStringToTile[tmp.Tile]
+	waitValue.Ron = tmp.Ron
+	waitValue.Tsumo = tmp.Tsumo
+	waitValue.RiichiRon = tmp.RiichiRon
+	waitValue.RiichiTsumo = tmp.RiichiTsumo
+	return nil
+}
+
 func (tenpaiInfo *TenpaiInfo) MarshalJSON() ([]byte, error) {
 	return json.Marshal(&struct {
 		TileClassesTenpaiResult map[string]*TenpaiResult `json:"tile_classes_tenpai_result"`
@@ -0,0 +1,261 @@
+package mahjong
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/dnovikoff/tempai-core/hand/shanten"
+)
+
+// GetAcceptTileClasses
+//
+//	@Description: get the shanten of a hand of 13 tiles(3 less for every meld) and the tile classes lowering it,
+//	the waits once tenpai
+//	@param handTiles
+//	@param melds
+//	@return int: shanten
+//	@return TileClasses: accepting tile classes in order
+func GetAcceptTileClasses(handTiles Tiles, melds Calls) (int, TileClasses) {
+	if melds == nil {
+		melds = Calls{}
+	}
+	instances, meldsOpt := TilesCallsToCalc(handTiles, melds)
+	res := shanten.Calculate(instances, meldsOpt).Total
+	var classes TileClasses
+	for _, t := range res.Improves.Tiles() {
+		classes = append(classes, TileClass(int(t)-1))
+	}
+	return res.Value, classes
+}
+
+type UkeireInfos []*UkeireInfo
+
+// UkeireInfo the acceptance of a hand of 13 tiles, the hand after the discard
+type UkeireInfo struct {
+	Discard Tile              `json:"discard"`  // TileDummy for the hand analysed as it is
+	Shanten int               `json:"shanten"`  // shanten after the discard
+	Accepts map[TileClass]int `json:"accepts"`  // unseen numbers of the tile classes lowering the shanten, the waits once tenpai
+	Ukeire  int               `json:"ukeire"`   // unseen tiles of all the accepting classes
+	TwoStep int               `json:"two_step"` // ukeire after the best discard summed over the unseen accepting tiles, 0 once tenpai
+}
+
+func (info *UkeireInfo) MarshalJSON() ([]byte, error) {
+	var discard string
+	if info.Discard != TileDummy {
+		discard = info.Discard.String()
+	}
+	accepts := make(map[string]int, len(info.Accepts))
+	for tileClass, num := range info.Accepts {
+		accepts[tileClass.String()] = num
+	}
+	return json.Marshal(&struct {
+		Discard string         `json:"discard,omitempty"`
+		Shanten int            `json:"shanten"`
+		Accepts map[string]int `json:"accepts"`
+		Ukeire  int            `json:"ukeire"`
+		TwoStep int            `json:"two_step"`
+	}{
+		Discard: discard,
+		Shanten: info.Shanten,
+		Accepts: accepts,
+		Ukeire:  info.Ukeire,
+		TwoStep: info.TwoStep,
+	})
+}
+
+func (info *UkeireInfo) UnmarshalJSON(data []byte) error {
+	var tmp struct {
+		Discard string         `json:"discard,omitempty"`
+		Shanten int            `json:"shanten"`
+		Accepts map[string]int `json:"accepts"`
+		Ukeire  int            `json:"ukeire"`
+		TwoStep int            `json:"two_step"`
+	}
+	err := json.Unmarshal(data, &tmp)
+	if err != nil {
+		return err
+	}
+	info.Discard = TileDummy
+	if tmp.Discard != "" {
+		info.Discard = MapStringToTile[tmp.Discard]
+	}
+	info.Shanten = tmp.Shanten
+	info.Accepts = make(map[TileClass]int, len(tmp.Accepts))
+	for tileClass, num := range tmp.Accepts {
+		info.Accepts[MapStringToTileClass[tileClass]] = num
+	}
+	info.Ukeire = tmp.Ukeire
+	info.TwoStep = tmp.TwoStep
+	return nil
+}
+
+// GetUkeireInfos
+//
+//	@Description: analyse every discard of a hand of 14 tiles(3 less for every meld) by the shanten, the ukeire and
+//	the two-step acceptance, the tiles are unseen from the perspective of the board state
+//	@param handTiles
+//	@param melds
+//	@param state: board state of the player, nil for the tiles out of the hand and the melds all unseen
+//	@return UkeireInfos: infos of every tile of the hand, the best discard first
+func GetUkeireInfos(handTiles Tiles, melds Calls, state *BoardState) UkeireInfos {
+	c := newUkeireCalculator(handTiles, melds, state)
+	infos := make(UkeireInfos, 0, len(handTiles))
+	byClass := make(map[TileClass]*UkeireInfo)
+	for _, tile := range handTiles {
+		info, ok := byClass[tile.Class()]
+		if !ok {
+			rest := handTiles.Copy()
+			rest.Remove(tile)
+			info = c.analyse(rest)
+			byClass[tile.Class()] = info
+		}
+		infoCopy := *info
+		infoCopy.Discard = tile
+		infoCopy.Accepts = make(map[TileClass]int, len(info.Accepts))
+		for tileClass, num := range info.Accepts {
+			infoCopy.Accepts[tileClass] = num
+		}
+		infos = append(infos, &infoCopy)
+	}
+	sort.SliceStable(infos, func(i, j int) bool {
+		if infos[i].Shanten != infos[j].Shanten {
+			return infos[i].Shanten < infos[j].Shanten
+		}
+		if infos[i].Ukeire != infos[j].Ukeire {
+			return infos[i].Ukeire > infos[j].Ukeire
+		}
+		return infos[i].TwoStep > infos[j].TwoStep
+	})
+	return infos
+}
+
+// GetUkeireInfo
+//
+//	@Description: analyse a hand of 13 tiles(3 less for every meld) as it is, see GetUkeireInfos
+//	@param handTiles
+//	@param melds
+//	@param state: board state of the player, nil for the tiles out of the hand and the melds all unseen
+//	@return *UkeireInfo: info with the discard TileDummy
+func GetUkeireInfo(handTiles Tiles, melds Calls, state *BoardState) *UkeireInfo {
+	return newUkeireCalculator(handTiles, melds, state).analyse(handTiles)
+}
+
+// ukeireCalculator caches the shanten and the accepting classes of the hands by the numbers of their classes
+type ukeireCalculator struct {
+	melds   Calls
+	remain  []int
+	accepts map[[Chun + 1]int8]acceptResult
+}
+
+// acceptResult the result of GetAcceptTileClasses
+type acceptResult struct {
+	shanten int
+	classes TileClasses
+}
+
+// newUkeireCalculator counts the unseen tiles, the tiles of the hand are seen even if not in the hand of the state
+func newUkeireCalculator(handTiles Tiles, melds Calls, state *BoardState) *ukeireCalculator {
+	if melds == nil {
+		melds = Calls{}
+	}
+	c := &ukeireCalculator{melds: melds, accepts: make(map[[Chun + 1]int8]acceptResult)}
+	if state != nil {
+		c.remain = state.GetRemainTileClassNums()
+		for _, tile := range handTiles {
+			if state.HandTiles.Index(tile, 0) == -1 && c.remain[tile.Class()] > 0 {
+				c.remain[tile.Class()]--
+			}
+		}
+		return c
+	}
+	c.remain = make([]int, Chun+1)
+	for class := range c.remain {
+		c.remain[class] = 4
+	}
+	for _, tile := range handTiles {
+		c.remain[tile.Class()]--
+	}
+	for _, meld := range melds {
+		for _, tile := range meld.CallTiles {
+			if tile != TileDummy {
+				c.remain[tile.Class()]--
+			}
+		}
+	}
+	return c
+}
+
+// accept returns the shanten and the accepting classes of the hand
+func (c *ukeireCalculator) accept(handTiles Tiles) acceptResult {
+	var key [Chun + 1]int8
+	for _, tile := range handTiles {
+		key[tile.Class()]++
+	}
+	if res, ok := c.accepts[key]; ok {
+		return res
+	}
+	var res acceptResult
+	res.shanten, res.classes = GetAcceptTileClasses(handTiles, c.melds)
+	c.accepts[key] = res
+	return res
+}
+
+// ukeire returns the unseen tiles of the classes
+func (c *ukeireCalculator) ukeire(classes TileClasses) int {
+	n := 0
+	for _, class := range classes {
+		n += c.remain[class]
+	}
+	return n
+}
+
+// analyse returns the info of a hand of 13 tiles, the two-step acceptance draws every unseen accepting tile
+// and discards the tile keeping the lower shanten and then the most ukeire
+func (c *ukeireCalculator) analyse(handTiles Tiles) *UkeireInfo {
+	res := c.accept(handTiles)
+	info := &UkeireInfo{Discard: TileDummy, Shanten: res.shanten, Accepts: make(map[TileClass]int, len(res.classes))}
+	for _, class := range res.classes {
+		info.Accepts[class] = c.remain[class]
+	}
+	info.Ukeire = c.ukeire(res.classes)
+	if res.shanten <= 0 {
+		return info
+	}
+	for _, class := range res.classes {
+		num := c.remain[class]
+		drawn := unheldTile(handTiles, class)
+		if num == 0 || drawn == TileDummy {
+			continue
+		}
+		c.remain[class]--
+		hand := append(handTiles.Copy(), drawn)
+		best := 0
+		discarded := make(map[TileClass]bool)
+		for _, tile := range hand {
+			if discarded[tile.Class()] {
+				continue
+			}
+			discarded[tile.Class()] = true
+			rest := hand.Copy()
+			rest.Remove(tile)
+			if next := c.accept(rest); next.shanten < res.shanten {
+				if ukeire := c.ukeire(next.classes); ukeire > best {
+					best = ukeire
+				}
+			}
+		}
+		c.remain[class]++
+		info.TwoStep += num * best
+	}
+	return info
+}
+
+// unheldTile returns a tile of the class not in the hand, TileDummy if all are held
+func unheldTile(handTiles Tiles, class TileClass) Tile {
+	for _, tile := range class.To4Tiles() {
+		if handTiles.Index(tile, 0) == -1 {
+			return tile
+		}
+	}
+	return TileDummy
+}
@@ -42,7 +42,8 @@ func IndicatorToDora(indicator Tile) Tile {
 	case Chun1, Chun2, Chun3, Chun4:
 		return indicator - 8
 	default:
-		return indicator + 1
+		// the same copy of the next kind
+		return indicator + 4
 	}
 }
 
@@ -106,6 +107,14 @@ func GetTenpaiInfo(game *Game, player *Player) *TenpaiInfo {
 	return tenpaiInfo
 }
 
+// GetTenpaiResult
+//
+//	@Description: get the tenpai result of a waiting tile class, the result of the current context on one tile,
+//	the ron, tsumo and riichi values of every tile of the class only if the game is created WithWaitValues
+//	@param game
+//	@param player: player after the discard
+//	@param tileClass: waiting tile class
+//	@return *TenpaiResult
 func GetTenpaiResult(game *Game, player *Player, tileClass TileClass) *TenpaiResult {
 	remainNum := GetRemainTileClassNumFromPlayerPerspective(game, player, tileClass)
 	var minWinTiles = tileClass.To4Tiles()
@@ -122,7 +131,23 @@ func GetTenpaiResult(game *Game, player *Player, tileClass TileClass) *TenpaiRes
 	}
 
 	result := game.getRonResult(player, minWinTile)
-	return NewTenpaiResult(remainNum, result)
+	tenpaiResult := NewTenpaiResult(remainNum, result)
+	if game.hasWaitValues {
+		tenpaiResult.Waits = GetWaitValues(game, player, tileClass)
+	}
+	return tenpaiResult
+}
+
+// GetWaitValues
+//
+//	@Description: the ron, tsumo and riichi values of every tile of the waiting tile class not in the hand,
+//	a red five is valued apart from the other copies
+//	@param game
+//	@param player: player after the discard
+//	@param tileClass: waiting tile class
+//	@return []*WaitValue
+func GetWaitValues(game *Game, player *Player, tileClass TileClass) []*WaitValue {
+	return game.getWaitValues(player, tileClass)
 }
 
 func GetRemainTileClassNumFromPlayerPerspective(game *Game, player *Player, tileClass TileClass) int {
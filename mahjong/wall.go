@@ -0,0 +1,145 @@
+package mahjong
+
+import (
+	crand "crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"math/rand"
+)
+
+// WallInfo round metadata given to the wall generator
+type WallInfo struct {
+	Seed      int64     // seed of the game
+	NumGame   int       // number of the round in the game, from 0
+	WindRound WindRound // wind round of the round
+	NumHonba  int       // honba of the round
+	IsSanma   bool      // three-player wall
+}
+
+// WallGenerator generates the wall of every round
+type WallGenerator interface {
+	// Wall returns the wall of the round, tiles are all tiles of the game in order
+	Wall(info WallInfo, tiles Tiles) (Tiles, error)
+	// Clone returns a generator in the same state, the clone of a game deals the walls of the next rounds by it
+	Clone() WallGenerator
+}
+
+// GameOption option of NewMahjongGame
+type GameOption func(game *Game)
+
+// WithWallGenerator
+//
+//	@Description: generate the wall of every round by the generator instead of the seed of the game
+//	@param generator: wall generator
+//	@return GameOption
+func WithWallGenerator(generator WallGenerator) GameOption {
+	return func(game *Game) {
+		game.wallGenerator = generator
+	}
+}
+
+// WithWaitValues
+//
+//	@Description: fill the Waits of the tenpai results of the events, every tile of every wait is scored
+//	on every draw and call, so they are left out by default and GetWaitValues gives them on request
+//	@return GameOption
+func WithWaitValues() GameOption {
+	return func(game *Game) {
+		game.hasWaitValues = true
+	}
+}
+
+// RandWallGenerator shuffles the tiles with a math/rand source of the seed, the clones of the game copy the source
+type RandWallGenerator struct {
+	source *seededSource
+	randP  *rand.Rand
+}
+
+// NewRandWallGenerator
+//
+//	@Description: create a wall generator shuffling with a math/rand source
+//	@param seed: seed of the source
+//	@return *RandWallGenerator
+func NewRandWallGenerator(seed int64) *RandWallGenerator {
+	source := newSeededSource(seed)
+	return &RandWallGenerator{source: source, randP: rand.New(source)}
+}
+
+func (g *RandWallGenerator) Wall(_ WallInfo, tiles Tiles) (Tiles, error) {
+	g.randP.Shuffle(len(tiles), func(i, j int) {
+		tiles[i], tiles[j] = tiles[j], tiles[i]
+	})
+	return tiles, nil
+}
+
+func (g *RandWallGenerator) Clone() WallGenerator {
+	source := g.source.clone()
+	return &RandWallGenerator{source: source, randP: rand.New(source)}
+}
+
+// HashWallGenerator shuffles the tiles with a seed hashed from the game seed and the round, so every round can be reproduced on its own
+type HashWallGenerator struct{}
+
+// RoundSeed
+//
+//	@Description: the seed of the round, the first 8 bytes of sha256 of the seed, NumGame, WindRound and NumHonba in big endian
+//	@param info: round metadata
+//	@return int64
+func (g HashWallGenerator) RoundSeed(info WallInfo) int64 {
+	var data [32]byte
+	binary.BigEndian.PutUint64(data[0:], uint64(info.Seed))
+	binary.BigEndian.PutUint64(data[8:], uint64(info.NumGame))
+	binary.BigEndian.PutUint64(data[16:], uint64(info.WindRound))
+	binary.BigEndian.PutUint64(data[24:], uint64(info.NumHonba))
+	sum := sha256.Sum256(data[:])
+	return int64(binary.BigEndian.Uint64(sum[:8]))
+}
+
+func (g HashWallGenerator) Wall(info WallInfo, tiles Tiles) (Tiles, error) {
+	randP := rand.New(rand.NewSource(g.RoundSeed(info)))
+	randP.Shuffle(len(tiles), func(i, j int) {
+		tiles[i], tiles[j] = tiles[j], tiles[i]
+	})
+	return tiles, nil
+}
+
+func (g HashWallGenerator) Clone() WallGenerator {
+	return g
+}
+
+// FixedWallGenerator plays the prepared walls, Walls[NumGame] for every round
+type FixedWallGenerator struct {
+	Walls []Tiles
+}
+
+func (g *FixedWallGenerator) Wall(info WallInfo, _ Tiles) (Tiles, error) {
+	if info.NumGame >= len(g.Walls) {
+		return nil, fmt.Errorf("no wall for round %d, %d walls prepared", info.NumGame, len(g.Walls))
+	}
+	return g.Walls[info.NumGame].Copy(), nil
+}
+
+// Clone shares the prepared walls, they are never modified
+func (g *FixedWallGenerator) Clone() WallGenerator {
+	return g
+}
+
+// CryptoWallGenerator shuffles the tiles with crypto/rand, the walls can't be reproduced
+type CryptoWallGenerator struct{}
+
+func (g CryptoWallGenerator) Wall(_ WallInfo, tiles Tiles) (Tiles, error) {
+	for i := len(tiles) - 1; i > 0; i-- {
+		j, err := crand.Int(crand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return nil, err
+		}
+		tiles[i], tiles[j.Int64()] = tiles[j.Int64()], tiles[i]
+	}
+	return tiles, nil
+}
+
+func (g CryptoWallGenerator) Clone() WallGenerator {
+	return g
+}
@@ -148,11 +148,12 @@ const (
 	YakuHoutei         Yaku = 45
 	YakuRinshan        Yaku = 46
 	YakuChankan        Yaku = 47
+	YakuNukiDora       Yaku = 48
 )
 
 var MapStringToYaku = func() map[string]Yaku {
 	m := make(map[string]Yaku)
-	for i := YakuNone; i <= YakuChankan; i++ {
+	for i := YakuNone; i <= YakuNukiDora; i++ {
 		m[i.String()] = i
 	}
 	return m
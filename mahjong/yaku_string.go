@@ -56,11 +56,12 @@ func _() {
 	_ = x[YakuHoutei-45]
 	_ = x[YakuRinshan-46]
 	_ = x[YakuChankan-47]
+	_ = x[YakuNukiDora-48]
 }
 
-const _Yaku_name = "YakuNoneYakuRiichiYakuDaburiYakuIppatsuYakuTsumoYakuTanyaoYakuChantaYakuJunchanYakuHonroutoYakuYakuhaiYakuHakuYakuHatsuYakuChunYakuWindRoundYakuWindSelfYakuTonYakuNanYakuSjaYakuPeiYakuTonSelfYakuNanSelfYakuSjaSelfYakuPeiSelfYakuTonRoundYakuNanRoundYakuSjaRoundYakuPeiRoundYakuChiitoiYakuToitoiYakuSanankouYakuSankantsuYakuSanshokuYakuShousangenYakuPinfuYakuIppeikoYakuRyanpeikouYakuItsuuYakuSanshokuDoukouYakuHonitsuYakuChinitsuYakuDoraYakuUraDoraYakuAkaDoraYakuRenhouYakuHaiteiYakuHouteiYakuRinshanYakuChankan"
+const _Yaku_name = "YakuNoneYakuRiichiYakuDaburiYakuIppatsuYakuTsumoYakuTanyaoYakuChantaYakuJunchanYakuHonroutoYakuYakuhaiYakuHakuYakuHatsuYakuChunYakuWindRoundYakuWindSelfYakuTonYakuNanYakuSjaYakuPeiYakuTonSelfYakuNanSelfYakuSjaSelfYakuPeiSelfYakuTonRoundYakuNanRoundYakuSjaRoundYakuPeiRoundYakuChiitoiYakuToitoiYakuSanankouYakuSankantsuYakuSanshokuYakuShousangenYakuPinfuYakuIppeikoYakuRyanpeikouYakuItsuuYakuSanshokuDoukouYakuHonitsuYakuChinitsuYakuDoraYakuUraDoraYakuAkaDoraYakuRenhouYakuHaiteiYakuHouteiYakuRinshanYakuChankanYakuNukiDora"
 
-var _Yaku_index = [...]uint16{0, 8, 18, 28, 39, 48, 58, 68, 79, 91, 102, 110, 119, 127, 140, 152, 159, 166, 173, 180, 191, 202, 213, 224, 236, 248, 260, 272, 283, 293, 305, 318, 330, 344, 353, 364, 378, 387, 405, 416, 428, 436, 447, 458, 468, 478, 488, 499, 510}
+var _Yaku_index = [...]uint16{0, 8, 18, 28, 39, 48, 58, 68, 79, 91, 102, 110, 119, 127, 140, 152, 159, 166, 173, 180, 191, 202, 213, 224, 236, 248, 260, 272, 283, 293, 305, 318, 330, 344, 353, 364, 378, 387, 405, 416, 428, 436, 447, 458, 468, 478, 488, 499, 510, 522}
 
 func (i Yaku) String() string {
 	if i < 0 || i >= Yaku(len(_Yaku_index)-1) {
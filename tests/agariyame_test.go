@@ -0,0 +1,232 @@
+package tests
+
+import (
+	"github.com/hphphp123321/mahjong-go/mahjong"
+	"math/rand"
+	"testing"
+)
+
+func hasEvent(events mahjong.Events, eventType mahjong.EventType) bool {
+	for _, event := range events {
+		if event.GetType() == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func hasDiscard(calls mahjong.Calls) bool {
+	for _, call := range calls {
+		if call.CallType == mahjong.Discard {
+			return true
+		}
+	}
+	return false
+}
+
+func TestAgariYame(t *testing.T) {
+	// the dealer wins by tenhou in the only round
+	hand := mahjong.Tiles{
+		mahjong.Man1T1, mahjong.Man2T1, mahjong.Man3T1, mahjong.Man4T1, mahjong.Man5T2, mahjong.Man6T1, mahjong.Man7T1,
+		mahjong.Man8T1, mahjong.Man9T1, mahjong.Pin1T1, mahjong.Pin2T1, mahjong.Pin3T1, mahjong.Pin9T1,
+	}
+	fixed := map[int]mahjong.Tile{52: mahjong.Pin9T2}
+	for i, tile := range hand {
+		fixed[i] = tile
+	}
+
+	for _, c := range []struct {
+		policy    mahjong.AgariYamePolicy
+		agariYame bool
+	}{
+		{mahjong.AgariYameNone, false},
+		{mahjong.AgariYameOptional, true},
+		{mahjong.AgariYameOptional, false},
+		{mahjong.AgariYameAuto, true},
+	} {
+		r := rand.New(rand.NewSource(rand.Int63()))
+		rule := mahjong.GetDefaultRule()
+		rule.GameLength = 1
+		rule.AgariYamePolicy = c.policy
+		game := mahjong.NewMahjongGame(r.Int63(), rule)
+		posCalls := game.Reset(newPlayers(4), prepareWall(r, fixed))
+
+		var tsumoCall *mahjong.Call
+		for _, call := range posCalls[mahjong.East] {
+			if call.CallType == mahjong.Tsumo {
+				tsumoCall = call
+			}
+		}
+		if tsumoCall == nil {
+			t.Fatal("tenhou not offered")
+		}
+		posCalls, flag := game.Step(map[mahjong.Wind]*mahjong.Call{mahjong.East: tsumoCall})
+
+		offered := false
+		for _, call := range posCalls[mahjong.East] {
+			if call.CallType == mahjong.AgariYame {
+				offered = true
+			}
+		}
+		if offered != (c.policy == mahjong.AgariYameOptional) {
+			t.Fatalf("%s: agari yame offered %t", c.policy, offered)
+		}
+		if c.policy != mahjong.AgariYameAuto {
+			posCall := make(map[mahjong.Wind]*mahjong.Call)
+			for wind := range posCalls {
+				posCall[wind] = mahjong.NextCall
+			}
+			if c.agariYame {
+				posCall[mahjong.East] = mahjong.AgariYameCall
+			}
+			posCalls, flag = game.Step(posCall)
+		}
+
+		if c.agariYame != (flag == mahjong.EndTypeGame) {
+			t.Fatalf("%s: game end %t", c.policy, flag == mahjong.EndTypeGame)
+		}
+		if !c.agariYame {
+			if game.WindRound != mahjong.WindRoundEast1 || game.NumHonba != 1 {
+				t.Fatalf("%s: expect East1 with 1 honba, got %s with %d honba", c.policy, game.WindRound, game.NumHonba)
+			}
+			continue
+		}
+		events := game.GetGlobalEvents()
+		if !hasEvent(events, mahjong.EventTypeAgariYame) {
+			t.Fatalf("%s: no agari yame event", c.policy)
+		}
+		cGame := mahjong.ReConstructGame(newPlayers(4), events)
+		if !hasEvent(cGame.GetGlobalEvents(), mahjong.EventTypeAgariYame) {
+			t.Fatalf("%s: reconstruct agari yame failed", c.policy)
+		}
+	}
+}
+
+func TestAgariYameTie(t *testing.T) {
+	// the dealer of the final second round wins by tenhou and ties the player of the earlier starting seat,
+	// the round is rebuilt from its init event
+	tenhou := mahjong.Tiles{
+		mahjong.Man1T1, mahjong.Man2T1, mahjong.Man3T1, mahjong.Man4T1, mahjong.Man5T2, mahjong.Man6T1, mahjong.Man7T1,
+		mahjong.Man8T1, mahjong.Man9T1, mahjong.Pin1T1, mahjong.Pin2T1, mahjong.Pin3T1, mahjong.Pin9T1,
+	}
+	for _, policy := range []mahjong.TieBreakPolicy{mahjong.TieBreakSeat, mahjong.TieBreakSplit} {
+		r := rand.New(rand.NewSource(rand.Int63()))
+		fixed := map[int]mahjong.Tile{52: mahjong.Pin9T2}
+		for i := range tenhou {
+			fixed[i] = tenhou[i]
+		}
+		rule := mahjong.GetDefaultRule()
+		rule.GameLength = 2
+		rule.AgariYamePolicy = mahjong.AgariYameOptional
+		rule.TieBreakPolicy = policy
+		// every other player pays 16000, the first player in the north seat ties the dealer after paying
+		init := &mahjong.EventGlobalInit{
+			AllTiles:  prepareWall(r, fixed),
+			WindRound: mahjong.WindRoundEast2,
+			Seed:      r.Int63(),
+			NumGame:   1,
+			Rule:      rule,
+			InitPoints: map[mahjong.Wind]int{
+				mahjong.East: 25000, mahjong.South: 25000, mahjong.West: 25000, mahjong.North: 25000 + 4*16000,
+			},
+		}
+		players := newPlayers(4)
+		game := mahjong.ReConstructGame(players, mahjong.Events{init})
+		if game.PosPlayer[mahjong.East] != players[1] {
+			t.Fatalf("%s: the second player is not the dealer of the final round", policy)
+		}
+		var tsumoCall *mahjong.Call
+		for _, call := range game.JudgeSelfCalls(players[1]) {
+			if call.CallType == mahjong.Tsumo {
+				tsumoCall = call
+			}
+		}
+		if tsumoCall == nil {
+			t.Fatalf("%s: no tenhou", policy)
+		}
+		posCalls, _ := game.Step(map[mahjong.Wind]*mahjong.Call{mahjong.East: tsumoCall})
+		if players[0].Points != players[1].Points {
+			t.Fatalf("%s: points %d and %d are not tied", policy, players[0].Points, players[1].Points)
+		}
+
+		offered := false
+		for _, call := range posCalls[mahjong.East] {
+			offered = offered || call.CallType == mahjong.AgariYame
+		}
+		if offered != (policy == mahjong.TieBreakSplit) {
+			t.Fatalf("%s: agari yame offered %t", policy, offered)
+		}
+	}
+}
+
+func TestAgariYameSanChaHou(t *testing.T) {
+	// south discards 4m in the only round, east, west and north all wait on it and the triple ron aborts
+	hands := map[mahjong.Wind]mahjong.Tiles{
+		mahjong.East: {mahjong.Man2T1, mahjong.Man3T1, mahjong.Pin2T1, mahjong.Pin3T1, mahjong.Pin4T1, mahjong.Pin5T1, mahjong.Pin6T1,
+			mahjong.Pin7T1, mahjong.Sou2T1, mahjong.Sou3T1, mahjong.Sou4T1, mahjong.Sou8T1, mahjong.Sou8T2},
+		mahjong.West: {mahjong.Man2T2, mahjong.Man3T2, mahjong.Pin2T2, mahjong.Pin3T2, mahjong.Pin4T2, mahjong.Pin5T2, mahjong.Pin6T2,
+			mahjong.Pin7T2, mahjong.Sou2T2, mahjong.Sou3T2, mahjong.Sou4T2, mahjong.Sou8T3, mahjong.Sou8T4},
+		mahjong.North: {mahjong.Man2T3, mahjong.Man3T3, mahjong.Pin2T3, mahjong.Pin3T3, mahjong.Pin4T3, mahjong.Pin5T3, mahjong.Pin6T3,
+			mahjong.Pin7T3, mahjong.Sou2T3, mahjong.Sou3T3, mahjong.Sou4T3, mahjong.Sou6T1, mahjong.Sou6T2},
+	}
+	fixed := map[int]mahjong.Tile{13: mahjong.Man4T1, 52: mahjong.Chun1}
+	for wind, hand := range hands {
+		for j, tile := range hand {
+			fixed[13*int(wind)+j] = tile
+		}
+	}
+
+	for _, policy := range []mahjong.AgariYamePolicy{mahjong.AgariYameOptional, mahjong.AgariYameAuto} {
+		r := rand.New(rand.NewSource(rand.Int63()))
+		rule := mahjong.GetDefaultRule()
+		rule.GameLength = 1
+		rule.AgariYamePolicy = policy
+		rule.TripleRonPolicy = mahjong.TripleRonAbort
+		game := mahjong.NewMahjongGame(r.Int63(), rule)
+		posCalls := game.Reset(newPlayers(4), prepareWall(r, fixed))
+
+		// east discards the drawn chun, nobody calls it
+		for _, call := range posCalls[mahjong.East] {
+			if call.CallType == mahjong.Discard && call.CallTiles[0] == mahjong.Chun1 {
+				posCalls, _ = game.Step(map[mahjong.Wind]*mahjong.Call{mahjong.East: call})
+				break
+			}
+		}
+		if !hasDiscard(posCalls[mahjong.South]) {
+			posCall := make(map[mahjong.Wind]*mahjong.Call)
+			for wind := range posCalls {
+				posCall[wind] = mahjong.SkipCall
+			}
+			posCalls, _ = game.Step(posCall)
+		}
+		for _, call := range posCalls[mahjong.South] {
+			if call.CallType == mahjong.Discard && call.CallTiles[0] == mahjong.Man4T1 {
+				posCalls, _ = game.Step(map[mahjong.Wind]*mahjong.Call{mahjong.South: call})
+				break
+			}
+		}
+		posCall := make(map[mahjong.Wind]*mahjong.Call)
+		for wind, calls := range posCalls {
+			for _, call := range calls {
+				if call.CallType == mahjong.Ron {
+					posCall[wind] = call
+				}
+			}
+		}
+		if len(posCall) != 3 {
+			t.Fatalf("%s: expect 3 ron calls, got %d", policy, len(posCall))
+		}
+
+		// the aborted ron of the dealer is not a win, the dealer on top above the target cannot stop the game
+		game.PosPlayer[mahjong.East].Points = rule.TargetPoints + 10000
+		posCalls, flag := game.Step(posCall)
+		if flag == mahjong.EndTypeGame || hasEvent(game.GetGlobalEvents(), mahjong.EventTypeAgariYame) {
+			t.Fatalf("%s: the game ends after san cha hou", policy)
+		}
+		for _, call := range posCalls[mahjong.East] {
+			if call.CallType == mahjong.AgariYame {
+				t.Fatalf("%s: agari yame offered after san cha hou", policy)
+			}
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/hphphp123321/mahjong-go/mahjong"
 	"math/rand"
+	"reflect"
 	"testing"
 )
 
@@ -50,3 +51,51 @@ func TestBoardState(t *testing.T) {
 		posCall = make(map[mahjong.Wind]*mahjong.Call, 4)
 	}
 }
+
+func TestBoardStateRiichiAndTsumoGiri(t *testing.T) {
+	b := mahjong.NewBoardState()
+	b.PlayerWind = mahjong.East
+	b.NumRiichi = 0
+	b.HandTiles = mahjong.Tiles{mahjong.Man3T1, mahjong.Man1T1, mahjong.Man2T1, mahjong.Pin1T1}
+
+	// the hand is sorted after a tsumogiri as after a discard
+	b.DecodeEvents(mahjong.Events{&mahjong.EventTsumoGiri{Who: mahjong.East, Tile: mahjong.Pin1T1}})
+	if !reflect.DeepEqual(b.HandTiles, mahjong.Tiles{mahjong.Man1T1, mahjong.Man2T1, mahjong.Man3T1}) {
+		t.Fatalf("hand after the tsumogiri: %s", b.HandTiles)
+	}
+
+	// the player is in riichi from the declaration, the stick is deposited once the discard passes
+	b.DecodeEvents(mahjong.Events{&mahjong.EventRiichi{Who: mahjong.South, Step: 1}})
+	if !b.PlayerStates[mahjong.South].IsRiichi || b.NumRiichi != 0 {
+		t.Fatalf("riichi step 1: %v with %d sticks", b.PlayerStates[mahjong.South].IsRiichi, b.NumRiichi)
+	}
+	b.DecodeEvents(mahjong.Events{&mahjong.EventRiichi{Who: mahjong.South, Step: 2}})
+	if !b.PlayerStates[mahjong.South].IsRiichi || b.NumRiichi != 1 {
+		t.Fatalf("riichi step 2: %v with %d sticks", b.PlayerStates[mahjong.South].IsRiichi, b.NumRiichi)
+	}
+}
+
+func TestRemainTileClassNums(t *testing.T) {
+	// the board state counts the unseen tiles as GetRemainTileClassNumFromPlayerPerspective does with the game
+	for i := 0; i < 20; i++ {
+		var seed = rand.Int63()
+		r := rand.New(rand.NewSource(seed))
+		game := mahjong.NewMahjongGame(seed, nil)
+		posCalls := game.Reset(newPlayers(4), nil)
+		for flag := mahjong.EndTypeNone; flag == mahjong.EndTypeNone; {
+			for wind, player := range game.PosPlayer {
+				nums := game.GetPosBoardState(wind, posCalls[wind]).GetRemainTileClassNums()
+				for class := mahjong.TileClass(0); class <= mahjong.Chun; class++ {
+					if expect := mahjong.GetRemainTileClassNumFromPlayerPerspective(game, player, class); nums[class] != expect {
+						t.Fatalf("seed %d: %s sees %d of %s, expect %d", seed, wind, nums[class], class, expect)
+					}
+				}
+			}
+			posCall := make(map[mahjong.Wind]*mahjong.Call, 4)
+			for wind, calls := range posCalls {
+				posCall[wind] = calls[r.Intn(len(calls))]
+			}
+			posCalls, flag = game.Step(posCall)
+		}
+	}
+}
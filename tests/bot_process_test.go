@@ -0,0 +1,181 @@
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hphphp123321/mahjong-go/mahjong"
+)
+
+const helperBotEnv = "MAHJONG_HELPER_BOT"
+
+// TestProcessBotHelper is the bot process of TestProcessBot, run by the mode in the environment
+func TestProcessBotHelper(t *testing.T) {
+	mode := os.Getenv(helperBotEnv)
+	if mode == "" {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "%s bot ready\n", mode)
+	if mode == "crash" {
+		os.Exit(1)
+	}
+	id := -1.0
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		if mode == "hang" {
+			continue
+		}
+		var messages []map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &messages); err != nil || len(messages) == 0 {
+			os.Exit(2)
+		}
+		for _, m := range messages {
+			if m["type"] == "start_game" {
+				id = m["id"].(float64)
+			}
+		}
+		resp := map[string]interface{}{"type": "none"}
+		if last := messages[len(messages)-1]; last["type"] == "tsumo" && last["actor"] == id {
+			resp = map[string]interface{}{"type": "dahai", "actor": id, "pai": last["pai"], "tsumogiri": true}
+		}
+		data, _ := json.Marshal(resp)
+		fmt.Println(string(data))
+	}
+	os.Exit(0)
+}
+
+// randomAgent chooses a random valid call
+type randomAgent struct {
+	r *rand.Rand
+}
+
+func (a *randomAgent) Observe(mahjong.Events) error {
+	return nil
+}
+
+func (a *randomAgent) Choose(_ context.Context, calls mahjong.Calls, _ *mahjong.BoardState) (*mahjong.Call, error) {
+	return calls[a.r.Intn(len(calls))], nil
+}
+
+func TestProcessBot(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.New(&buf, "", 0)
+	var processBots []*mahjong.ProcessBot
+	agents := make([]mahjong.Agent, 0, 4)
+	for _, mode := range []string{"tsumogiri", "hang", "crash"} {
+		t.Setenv(helperBotEnv, mode)
+		bot, err := mahjong.NewProcessBot(len(agents), os.Args[0], []string{"-test.run=^TestProcessBotHelper$"},
+			mahjong.WithBotTimeout(200*time.Millisecond), mahjong.WithBotLogger(logger), mahjong.WithBotNames([]string{"a", "b", "c", "d"}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		processBots = append(processBots, bot)
+		agents = append(agents, bot)
+	}
+	var seed = rand.Int63()
+	agents = append(agents, &randomAgent{r: rand.New(rand.NewSource(seed))})
+
+	table, err := mahjong.NewTable(mahjong.NewMahjongGame(seed, mahjong.GetDefaultRule()), agents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := table.Run(context.Background())
+	if err != nil {
+		t.Fatalf("seed %d: %v", seed, err)
+	}
+	if len(result.Final.Players) != 4 {
+		t.Fatalf("seed %d: %d players in the result", seed, len(result.Final.Players))
+	}
+	for i, bot := range processBots {
+		if err = bot.Close(); err != nil {
+			t.Fatalf("seed %d: close bot %d: %v", seed, i, err)
+		}
+	}
+	output := buf.String()
+	for _, s := range []string{"bot 0: tsumogiri bot ready", "bot 2: crash bot ready", "bot 1 is stopped: " + mahjong.ErrBotTimeout.Error(), "bot 2 is stopped"} {
+		if !strings.Contains(output, s) {
+			t.Fatalf("seed %d: no %q in the log\n%s", seed, s, output)
+		}
+	}
+	if strings.Contains(output, "bot 0 is stopped") {
+		t.Fatalf("seed %d: tsumogiri bot is stopped\n%s", seed, output)
+	}
+
+	if _, err = mahjong.NewTable(mahjong.NewMahjongGame(seed, mahjong.GetDefaultRule()), agents[:3]); err == nil {
+		t.Fatal("3 bots seated at a table of 4")
+	}
+}
+
+func TestDefaultCall(t *testing.T) {
+	discard := func(tile mahjong.Tile) *mahjong.Call {
+		return &mahjong.Call{
+			CallType:         mahjong.Discard,
+			CallTiles:        mahjong.Tiles{tile, mahjong.TileDummy, mahjong.TileDummy, mahjong.TileDummy},
+			CallTilesFromWho: []mahjong.Wind{mahjong.East, mahjong.WindDummy, mahjong.WindDummy, mahjong.WindDummy},
+		}
+	}
+	// the drawn tile is discarded wherever it is in the calls, the last discard without a drawn tile
+	calls := mahjong.Calls{discard(mahjong.Man1T1), discard(mahjong.Pin2T1), discard(mahjong.Sou3T1)}
+	if call := mahjong.DefaultCall(calls, mahjong.Pin2T1); call != calls[1] {
+		t.Fatalf("drawn %s, default call %s", mahjong.Pin2T1, call)
+	}
+	if call := mahjong.DefaultCall(calls, mahjong.TileDummy); call != calls[2] {
+		t.Fatalf("no drawn tile, default call %s", call)
+	}
+	if call := mahjong.DefaultCall(append(calls, mahjong.SkipCall), mahjong.Pin2T1); call != mahjong.SkipCall {
+		t.Fatalf("default call %s, expect skip", call)
+	}
+
+	// the drawn tile of the board state is the tile of the last draw of the player to discard
+	var seed = rand.Int63()
+	r := rand.New(rand.NewSource(seed))
+	game := mahjong.NewMahjongGame(seed, nil)
+	posCalls := game.Reset(newPlayers(4), nil)
+	var flag = mahjong.EndTypeNone
+	for flag != mahjong.EndTypeGame {
+		posCall := make(map[mahjong.Wind]*mahjong.Call, 4)
+		for wind, calls := range posCalls {
+			drawn := mahjong.TileDummy
+			for _, event := range game.GetPosEvents(wind, 0) {
+				switch e := event.(type) {
+				case *mahjong.EventGet:
+					if e.Who == wind {
+						drawn = e.Tile
+					}
+				case *mahjong.EventChi:
+					if e.Who == wind {
+						drawn = mahjong.TileDummy
+					}
+				case *mahjong.EventPon:
+					if e.Who == wind {
+						drawn = mahjong.TileDummy
+					}
+				}
+			}
+			var hasDiscard bool
+			for _, call := range calls {
+				hasDiscard = hasDiscard || call.CallType == mahjong.Discard
+			}
+			state := game.GetPosBoardState(wind, calls)
+			if hasDiscard && state.DrawnTile != drawn {
+				t.Fatalf("seed %d: drawn tile %s, expect %s", seed, state.DrawnTile, drawn)
+			}
+			call := mahjong.DefaultCall(calls, state.DrawnTile)
+			if call.CallType == mahjong.Discard && drawn != mahjong.TileDummy && call.CallTiles[0] != drawn {
+				t.Fatalf("seed %d: default call %s, expect the discard of %s", seed, call, drawn)
+			}
+			posCall[wind] = calls[r.Intn(len(calls))]
+		}
+		posCalls, flag = game.Step(posCall)
+	}
+}
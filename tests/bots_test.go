@@ -0,0 +1,97 @@
+package tests
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hphphp123321/mahjong-go/bots"
+	"github.com/hphphp123321/mahjong-go/mahjong"
+)
+
+func TestBots(t *testing.T) {
+	for _, rule := range []*mahjong.Rule{mahjong.GetDefaultRule(), mahjong.GetDefaultSanmaRule()} {
+		const seed = 20240601
+		run := func() *mahjong.TableResult {
+			agents := []mahjong.Agent{
+				bots.NewGreedy(seed),
+				bots.NewRiichiFold(seed+1, bots.WithNoise(0.05)),
+				bots.NewTsumogiri(seed + 2),
+				bots.NewRandom(seed + 3),
+			}[:rule.NumPlayers()]
+			table, err := mahjong.NewTable(mahjong.NewMahjongGame(seed, rule), agents)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := table.Run(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			return result
+		}
+
+		result := run()
+		for i, n := range result.Fallbacks {
+			if n != 0 {
+				t.Fatalf("bot %d chooses %d invalid calls", i, n)
+			}
+		}
+		wins := make(map[mahjong.Wind]int)
+		seats := splitRounds(result.Events)
+		for _, round := range seats {
+			dealer := int(round[0].(*mahjong.EventGlobalInit).WindRound-mahjong.WindRoundEast1) % 4
+			for _, event := range round {
+				var who mahjong.Wind
+				switch e := event.(type) {
+				case *mahjong.EventTsumo:
+					who = e.Who
+				case *mahjong.EventRon:
+					who = e.Who
+				default:
+					continue
+				}
+				wins[mahjong.Wind((int(who)+dealer)%rule.NumPlayers())]++
+			}
+		}
+		if wins[0] == 0 || (rule.NumPlayers() == 4 && wins[2] != 0) {
+			t.Fatalf("wins of the bots %v", wins)
+		}
+		if again := run(); !reflect.DeepEqual(again.Final, result.Final) || len(again.Events) != len(result.Events) {
+			t.Fatal("the game of the bots is not reproduced")
+		}
+	}
+}
+
+func TestRiichiFold(t *testing.T) {
+	// 1-shanten hand of 14 tiles facing the riichi of south, who discarded 9s
+	hand := mahjong.Tiles{
+		mahjong.Man1T1, mahjong.Man2T1, mahjong.Man3T1, mahjong.Pin4T1, mahjong.Pin5T2, mahjong.Pin6T1,
+		mahjong.Sou2T1, mahjong.Sou3T1, mahjong.Sou9T1, mahjong.Sou9T2, mahjong.Man7T1, mahjong.Man8T1,
+		mahjong.Pin1T1, mahjong.Chun1,
+	}
+	state := mahjong.NewBoardState()
+	state.WindRound = mahjong.WindRoundEast1
+	state.PlayerWind = mahjong.East
+	state.Position = mahjong.East
+	state.HandTiles = hand
+	state.DoraIndicators = mahjong.Tiles{mahjong.Haku1}
+	state.PlayerStates[mahjong.South].IsRiichi = true
+	state.PlayerStates[mahjong.South].DiscardTiles = mahjong.Tiles{mahjong.Sou9T3, mahjong.Ton1}
+	var calls mahjong.Calls
+	for _, tile := range hand {
+		calls = append(calls, &mahjong.Call{
+			CallType:         mahjong.Discard,
+			CallTiles:        mahjong.Tiles{tile, mahjong.TileDummy, mahjong.TileDummy, mahjong.TileDummy},
+			CallTilesFromWho: []mahjong.Wind{mahjong.East, mahjong.WindDummy, mahjong.WindDummy, mahjong.WindDummy},
+		})
+	}
+
+	call, err := bots.NewRiichiFold(1).Choose(context.Background(), calls, state)
+	if err != nil || call.CallTiles[0].Class() != mahjong.Sou9 {
+		t.Fatalf("riichi fold bot discards %v, %v", call, err)
+	}
+	call, err = bots.NewGreedy(1).Choose(context.Background(), calls, state)
+	if err != nil || call.CallTiles[0] != mahjong.Chun1 {
+		t.Fatalf("greedy bot discards %v, %v", call, err)
+	}
+}
@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/hphphp123321/mahjong-go/mahjong"
+	"math/rand"
+	"testing"
+)
+
+func TestChombo(t *testing.T) {
+	for _, policy := range []mahjong.ChomboPolicy{mahjong.ChomboMangan, mahjong.ChomboFixed} {
+		r := rand.New(rand.NewSource(rand.Int63()))
+		// east is far from tenpai and declares a false tsumo
+		wall := prepareWall(r, map[int]mahjong.Tile{
+			0: mahjong.Man2T1, 1: mahjong.Man5T1, 2: mahjong.Man8T1, 3: mahjong.Pin2T1, 4: mahjong.Pin5T1,
+			5: mahjong.Pin8T1, 6: mahjong.Sou2T1, 7: mahjong.Sou5T1, 8: mahjong.Sou8T1, 9: mahjong.Ton1,
+			10: mahjong.Nan1, 11: mahjong.Shaa1, 12: mahjong.Pei1, 52: mahjong.Haku1,
+		})
+		rule := mahjong.GetDefaultRule()
+		rule.IsChombo = true
+		rule.ChomboPolicy = policy
+		game := mahjong.NewMahjongGame(r.Int63(), rule)
+		posCalls := game.Reset(newPlayers(4), wall)
+		// south has declared riichi in this hand, the deposit is returned
+		game.PosPlayer[mahjong.South].IsRiichi = true
+		game.PosPlayer[mahjong.South].RiichiStep = 2
+		game.PosPlayer[mahjong.South].Points -= rule.RiichiDeposit
+		game.NumRiichi = 1
+
+		falseTsumo := &mahjong.Call{
+			CallType:         mahjong.Tsumo,
+			CallTiles:        mahjong.Tiles{mahjong.Haku1, mahjong.TileDummy, mahjong.TileDummy, mahjong.TileDummy},
+			CallTilesFromWho: []mahjong.Wind{mahjong.East, mahjong.WindDummy, mahjong.WindDummy, mahjong.WindDummy},
+		}
+		if calls := posCalls[mahjong.East]; calls.Index(falseTsumo) != -1 {
+			t.Fatal("tsumo should not be valid")
+		}
+		posCalls, flag := game.Step(map[mahjong.Wind]*mahjong.Call{mahjong.East: falseTsumo})
+		if flag != mahjong.EndTypeRound {
+			t.Fatalf("%s: chombo should end the hand", policy)
+		}
+
+		var expects = map[mahjong.Wind]int{
+			mahjong.East: 25000 - 12000, mahjong.South: 25000 + 4000, mahjong.West: 25000 + 4000, mahjong.North: 25000 + 4000,
+		}
+		if policy == mahjong.ChomboFixed {
+			expects = map[mahjong.Wind]int{
+				mahjong.East: 25000 - rule.ChomboPoints, mahjong.South: 25000, mahjong.West: 25000, mahjong.North: 25000,
+			}
+		}
+		for wind, points := range expects {
+			if game.PosPlayer[wind].Points != points {
+				t.Fatalf("%s: expect %s has %d points, got %d", policy, wind, points, game.PosPlayer[wind].Points)
+			}
+		}
+		if game.NumRiichi != 0 {
+			t.Fatalf("%s: riichi deposit not returned", policy)
+		}
+
+		events := game.GetGlobalEvents()
+		var chombo *mahjong.EventChombo
+		for _, event := range events {
+			if e, ok := event.(*mahjong.EventChombo); ok {
+				chombo = e
+			}
+		}
+		if chombo == nil || chombo.Who != mahjong.East || !mahjong.CallEqual(chombo.Call, falseTsumo) || chombo.Policy != policy {
+			t.Fatalf("%s: chombo event not found", policy)
+		}
+
+		// test json and reconstruct
+		b, _ := json.Marshal(&events)
+		var nEvents mahjong.Events
+		if err := json.Unmarshal(b, &nEvents); err != nil {
+			t.Fatal(err)
+		}
+		cGame := mahjong.ReConstructGame(newPlayers(4), nEvents)
+		for wind, points := range expects {
+			if cGame.PosPlayer[wind].Points != points {
+				t.Fatalf("%s: reconstruct chombo failed", policy)
+			}
+		}
+
+		// the hand is redealt without moving the dealer or adding honba
+		posCall := make(map[mahjong.Wind]*mahjong.Call)
+		for wind, calls := range posCalls {
+			posCall[wind] = calls[0]
+		}
+		game.Step(posCall)
+		if game.WindRound != mahjong.WindRoundEast1 || game.NumHonba != 0 {
+			t.Fatalf("%s: expect East1 with 0 honba, got %s with %d honba", policy, game.WindRound, game.NumHonba)
+		}
+	}
+}
+
+func TestChomboMalformedDiscard(t *testing.T) {
+	r := rand.New(rand.NewSource(rand.Int63()))
+	rule := mahjong.GetDefaultRule()
+	rule.IsChombo = true
+	game := mahjong.NewMahjongGame(r.Int63(), rule)
+	game.Reset(newPlayers(4), nil)
+
+	// a discard of a tile not in the hand can not be penalized
+	var tile mahjong.Tile
+	for game.PosPlayer[mahjong.East].HandTiles.Index(tile, 0) != -1 {
+		tile++
+	}
+	discard := mahjong.NewCall(mahjong.Discard, mahjong.Tiles{tile, mahjong.TileDummy, mahjong.TileDummy, mahjong.TileDummy},
+		[]mahjong.Wind{mahjong.East, mahjong.WindDummy, mahjong.WindDummy, mahjong.WindDummy})
+	numEvents := len(game.GetGlobalEvents())
+	if _, _, err := game.StepE(map[mahjong.Wind]*mahjong.Call{mahjong.East: discard}); !errors.Is(err, mahjong.ErrIllegalCall) {
+		t.Fatalf("expect ErrIllegalCall, got %v", err)
+	}
+	if len(game.GetGlobalEvents()) != numEvents || game.PosPlayer[mahjong.East].HandTiles.Index(tile, 0) != -1 {
+		t.Fatal("game changed by the malformed discard")
+	}
+
+	defer func() {
+		if err, ok := recover().(error); !ok || !errors.Is(err, mahjong.ErrIllegalCall) {
+			t.Fatalf("expect Step panics with ErrIllegalCall, got %v", err)
+		}
+	}()
+	game.Step(map[mahjong.Wind]*mahjong.Call{mahjong.East: discard})
+}
@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"github.com/hphphp123321/mahjong-go/mahjong"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestClone(t *testing.T) {
+	var seed = rand.Int63()
+	r := rand.New(rand.NewSource(seed))
+
+	randomCall := func(posCalls map[mahjong.Wind]mahjong.Calls) map[mahjong.Wind]*mahjong.Call {
+		posCall := make(map[mahjong.Wind]*mahjong.Call, 4)
+		for wind := mahjong.East; wind <= mahjong.North; wind++ {
+			if calls, ok := posCalls[wind]; ok {
+				posCall[wind] = calls[r.Intn(len(calls))]
+			}
+		}
+		return posCall
+	}
+
+	game := mahjong.NewMahjongGame(seed, nil)
+	posCalls := game.Reset(newPlayers(4), nil)
+	for i := r.Intn(100); i > 0; i-- {
+		posCalls, _ = game.Step(randomCall(posCalls))
+	}
+
+	// the clone and the original go the same way with the same calls, including the walls of the next rounds
+	clone := game.Clone()
+	clonePosCalls := posCalls
+	var flag = mahjong.EndTypeNone
+	for flag != mahjong.EndTypeGame {
+		posCall := randomCall(posCalls)
+		cloneCall := make(map[mahjong.Wind]*mahjong.Call, 4)
+		for wind, call := range posCall {
+			cloneCall[wind] = call.Copy()
+		}
+		posCalls, flag = game.Step(posCall)
+		clonePosCalls, _ = clone.Step(cloneCall)
+		if !reflect.DeepEqual(posCalls, clonePosCalls) {
+			t.Fatalf("seed %d: valid calls of the clone differ from the original", seed)
+		}
+		for wind := range posCalls {
+			if !game.GetPosBoardState(wind, posCalls[wind]).Equal(clone.GetPosBoardState(wind, clonePosCalls[wind])) {
+				t.Fatalf("seed %d: board state of the clone differs from the original", seed)
+			}
+		}
+	}
+	if !reflect.DeepEqual(game.GetAllGlobalEvents(), clone.GetAllGlobalEvents()) {
+		t.Fatalf("seed %d: events of the clone differ from the original", seed)
+	}
+
+	// stepping the clone never changes the original
+	game = mahjong.NewMahjongGame(seed, nil)
+	posCalls = game.Reset(newPlayers(4), nil)
+	for i := r.Intn(100); i > 0; i-- {
+		posCalls, _ = game.Step(randomCall(posCalls))
+	}
+	numEvents := len(game.GetAllGlobalEvents())
+	boardStates := make(map[mahjong.Wind]*mahjong.BoardState, 4)
+	for wind := mahjong.East; wind <= mahjong.North; wind++ {
+		boardStates[wind] = game.GetPosBoardState(wind, posCalls[wind])
+	}
+	points := make(map[mahjong.Wind]int, 4)
+	for wind, player := range game.PosPlayer {
+		points[wind] = player.Points
+	}
+
+	clone = game.Clone()
+	clonePosCalls = posCalls
+	flag = mahjong.EndTypeNone
+	for flag != mahjong.EndTypeGame {
+		clonePosCalls, flag = clone.Step(randomCall(clonePosCalls))
+	}
+	if len(game.GetAllGlobalEvents()) != numEvents {
+		t.Fatalf("seed %d: events of the original changed by the clone", seed)
+	}
+	for wind, boardState := range boardStates {
+		if !game.GetPosBoardState(wind, posCalls[wind]).Equal(boardState) {
+			t.Fatalf("seed %d: board state of %s changed by the clone", seed, wind)
+		}
+	}
+	for i, player := range []*mahjong.Player{game.P0, game.P1, game.P2, game.P3} {
+		if game.PosPlayer[player.Wind] != player {
+			t.Fatalf("seed %d: seat of player %d changed by the clone", seed, i)
+		}
+	}
+	for wind, player := range game.PosPlayer {
+		if player.Points != points[wind] {
+			t.Fatalf("seed %d: points of the original changed by the clone", seed)
+		}
+	}
+
+	// the original still plays to the end on its own
+	flag = mahjong.EndTypeNone
+	for flag != mahjong.EndTypeGame {
+		posCalls, flag = game.Step(randomCall(posCalls))
+	}
+}
@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"github.com/hphphp123321/go-common"
+	"github.com/hphphp123321/mahjong-go/mahjong"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestDeterminize(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		var seed = rand.Int63()
+		r := rand.New(rand.NewSource(seed))
+		rule := mahjong.GetDefaultRule()
+		if i%4 == 3 {
+			rule = mahjong.GetDefaultSanmaRule()
+		}
+		game := mahjong.NewMahjongGame(seed, rule)
+		posCalls := game.Reset(newPlayers(rule.NumPlayers()), nil)
+		for j := r.Intn(300); j > 0 || len(posCalls) == 0; j-- {
+			posCall := make(map[mahjong.Wind]*mahjong.Call, 4)
+			for wind := mahjong.East; wind <= mahjong.North; wind++ {
+				if calls, ok := posCalls[wind]; ok {
+					posCall[wind] = calls[r.Intn(len(calls))]
+				}
+			}
+			var flag mahjong.EndType
+			posCalls, flag = game.Step(posCall)
+			if flag == mahjong.EndTypeGame {
+				game = mahjong.NewMahjongGame(seed, rule)
+				posCalls = game.Reset(newPlayers(rule.NumPlayers()), nil)
+			}
+		}
+
+		seat := mahjong.Wind(r.Intn(rule.NumPlayers()))
+		boardState := game.GetPosBoardState(seat, posCalls[seat])
+		sample, samplePosCalls, err := mahjong.Determinize(game.GetPosEvents(seat, 0), r, &mahjong.DeterminizeOptions{IsRiichiWaitSafe: true})
+		if err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+
+		// the seat can't tell the sample from the game
+		if !sample.GetPosBoardState(seat, samplePosCalls[seat]).Equal(boardState) {
+			t.Fatalf("seed %d: board state of the sample differs", seed)
+		}
+		if !reflect.DeepEqual(posCalls[seat], samplePosCalls[seat]) {
+			t.Fatalf("seed %d: valid calls of the sample differ", seed)
+		}
+		allTiles := sample.GetGlobalEvents()[0].(*mahjong.EventGlobalInit).AllTiles.Copy()
+		sort.Sort(&allTiles)
+		for j := 1; j < len(allTiles); j++ {
+			if allTiles[j] == allTiles[j-1] {
+				t.Fatalf("seed %d: tile %s appears twice in the wall", seed, allTiles[j])
+			}
+		}
+
+		// riichi players don't wait on their own discards
+		for wind, player := range sample.PosPlayer {
+			if wind == seat || !player.IsRiichi {
+				continue
+			}
+			for _, tile := range player.DiscardTiles {
+				if common.SliceContain(player.TenpaiSlice, tile.Class()) {
+					t.Fatalf("seed %d: riichi player %s waits on the discarded %s", seed, wind, tile)
+				}
+			}
+		}
+
+		// the sample plays on
+		var flag = mahjong.EndTypeNone
+		for flag == mahjong.EndTypeNone {
+			posCall := make(map[mahjong.Wind]*mahjong.Call, 4)
+			for wind, calls := range samplePosCalls {
+				posCall[wind] = calls[r.Intn(len(calls))]
+			}
+			samplePosCalls, flag = sample.Step(posCall)
+		}
+	}
+}
+
+func TestDeterminizeMismatch(t *testing.T) {
+	// east declares riichi with the drawn chun, the events miss the riichi discard and no wall replays them
+	hand := mahjong.Tiles{mahjong.Man2T1, mahjong.Man3T1, mahjong.Pin2T1, mahjong.Pin3T1, mahjong.Pin4T1, mahjong.Pin5T1, mahjong.Pin6T1,
+		mahjong.Pin7T1, mahjong.Sou2T1, mahjong.Sou3T1, mahjong.Sou4T1, mahjong.Sou8T1, mahjong.Sou8T2}
+	fixed := map[int]mahjong.Tile{52: mahjong.Chun1}
+	for i, tile := range hand {
+		fixed[i] = tile
+	}
+	var seed = rand.Int63()
+	r := rand.New(rand.NewSource(seed))
+	game := mahjong.NewMahjongGame(seed, nil)
+	posCalls := game.Reset(newPlayers(4), prepareWall(r, fixed))
+	for _, call := range posCalls[mahjong.East] {
+		if call.CallType == mahjong.Riichi && call.CallTiles[0] == mahjong.Chun1 {
+			game.Step(map[mahjong.Wind]*mahjong.Call{mahjong.East: call})
+			break
+		}
+	}
+
+	events := game.GetPosEvents(mahjong.East, 0)
+	for i, event := range events {
+		if e, ok := event.(*mahjong.EventRiichi); !ok || e.Step != 1 {
+			continue
+		}
+		mismatch := append(append(mahjong.Events{}, events[:i+1]...), events[i+2:]...)
+		if _, _, err := mahjong.Determinize(mismatch, r, nil); err == nil {
+			t.Fatalf("seed %d: determinized the events without the riichi discard", seed)
+		}
+		return
+	}
+	t.Fatalf("seed %d: no riichi declared", seed)
+}
@@ -0,0 +1,56 @@
+package tests
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/hphphp123321/mahjong-go/mahjong"
+)
+
+func TestIndicatorToDora(t *testing.T) {
+	var doras = map[mahjong.Tile]mahjong.Tile{
+		mahjong.Man1T1: mahjong.Man2T1,
+		mahjong.Man3T2: mahjong.Man4T2,
+		mahjong.Man9T3: mahjong.Man1T3,
+		mahjong.Pin4T4: mahjong.Pin5T4,
+		mahjong.Sou9T1: mahjong.Sou1T1,
+		mahjong.Ton2:   mahjong.Nan2,
+		mahjong.Pei4:   mahjong.Ton4,
+		mahjong.Haku3:  mahjong.Hatsu3,
+		mahjong.Chun1:  mahjong.Haku1,
+	}
+	for indicator, dora := range doras {
+		if d := mahjong.IndicatorToDora(indicator); d != dora {
+			t.Fatalf("dora of %s: %s, expect %s", indicator, d, dora)
+		}
+	}
+
+	// 234m34m456p678s99p waiting on 25m, the win on 5m counts the dora in the hand
+	hand := mahjong.Tiles{
+		mahjong.Man2T1, mahjong.Man3T1, mahjong.Man4T1, mahjong.Man3T2, mahjong.Man4T2, mahjong.Pin4T1, mahjong.Pin5T2,
+		mahjong.Pin6T1, mahjong.Sou6T1, mahjong.Sou7T1, mahjong.Sou8T1, mahjong.Pin9T1, mahjong.Pin9T2,
+	}
+	var doraHans = map[mahjong.Tile]int{
+		mahjong.Sou5T2: 1, // 6s
+		mahjong.Pin8T2: 2, // 99p
+		mahjong.Man4T4: 1, // the win tile 5m
+		mahjong.Man9T4: 0, // 1m
+	}
+	for indicator, han := range doraHans {
+		r := rand.New(rand.NewSource(rand.Int63()))
+		rule := mahjong.GetDefaultRule()
+		rule.HasAkaDora = false
+		game := mahjong.NewMahjongGame(r.Int63(), rule)
+		game.Reset(newPlayers(4), prepareWall(r, map[int]mahjong.Tile{mahjong.NumTiles - 6: indicator}))
+		player := game.PosPlayer[mahjong.East].Copy()
+		player.HandTiles = hand.Copy()
+
+		result := mahjong.GetTenpaiResult(game, player, mahjong.Man5).Result
+		if result == nil {
+			t.Fatal("no win on 5m")
+		}
+		if result.YakuResult.Bonuses[mahjong.YakuDora] != han {
+			t.Fatalf("indicator %s: %d dora, expect %d", indicator, result.YakuResult.Bonuses[mahjong.YakuDora], han)
+		}
+	}
+}
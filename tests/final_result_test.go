@@ -0,0 +1,185 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/hphphp123321/mahjong-go/mahjong"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestCalculateFinalResult(t *testing.T) {
+	type expect struct {
+		points int
+		rank   int
+		score  float64
+	}
+	var cases = []struct {
+		name      string
+		modify    func(rule *mahjong.Rule)
+		points    []int
+		numRiichi int
+		expects   []expect
+	}{
+		{
+			name:    "default",
+			modify:  func(rule *mahjong.Rule) {},
+			points:  []int{45000, 30000, 15000, 10000},
+			expects: []expect{{45000, 1, 55}, {30000, 2, 10}, {15000, 3, -25}, {10000, 4, -40}},
+		},
+		{
+			name:      "seat tie break",
+			modify:    func(rule *mahjong.Rule) {},
+			points:    []int{34500, 34500, 20000, 10000},
+			numRiichi: 1,
+			expects:   []expect{{35500, 1, 45.5}, {34500, 2, 14.5}, {20000, 3, -20}, {10000, 4, -40}},
+		},
+		{
+			name: "split tie break",
+			modify: func(rule *mahjong.Rule) {
+				rule.TieBreakPolicy = mahjong.TieBreakSplit
+			},
+			points:    []int{34500, 34500, 20000, 10000},
+			numRiichi: 1,
+			expects:   []expect{{35000, 1, 30}, {35000, 1, 30}, {20000, 3, -20}, {10000, 4, -40}},
+		},
+		{
+			name: "kyoutaku lost",
+			modify: func(rule *mahjong.Rule) {
+				rule.KyoutakuPolicy = mahjong.KyoutakuLost
+			},
+			points:    []int{44000, 30000, 15000, 10000},
+			numRiichi: 1,
+			expects:   []expect{{44000, 1, 54}, {30000, 2, 10}, {15000, 3, -25}, {10000, 4, -40}},
+		},
+		{
+			name: "rounding half up",
+			modify: func(rule *mahjong.Rule) {
+				rule.RoundingPolicy = mahjong.RoundingHalfUp
+			},
+			points:  []int{45300, 30300, 14300, 10100},
+			expects: []expect{{45300, 1, 56}, {30300, 2, 10}, {14300, 3, -26}, {10100, 4, -40}},
+		},
+		{
+			name: "floating uma without oka",
+			modify: func(rule *mahjong.Rule) {
+				rule.IsOka = false
+				rule.FloatingUma = [][]int{
+					nil,
+					{12000, -1000, -3000, -8000},
+					{8000, 4000, -4000, -8000},
+					{8000, 3000, 1000, -12000},
+				}
+			},
+			points:  []int{45000, 28000, 17000, 10000},
+			expects: []expect{{45000, 1, 32}, {28000, 2, 2}, {17000, 3, -11}, {10000, 4, -23}},
+		},
+	}
+
+	for _, c := range cases {
+		rule := mahjong.GetDefaultRule()
+		c.modify(rule)
+		points := make(map[mahjong.Wind]int)
+		for i, p := range c.points {
+			points[mahjong.Wind(i)] = p
+		}
+		result, err := mahjong.CalculateFinalResult(rule, points, c.numRiichi)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		for i, e := range c.expects {
+			player := result.GetPlayerResult(mahjong.Wind(i))
+			if player.Points != e.points || player.Rank != e.rank || math.Abs(player.Score-e.score) > 1e-9 {
+				t.Fatalf("%s: seat %s expect %d points rank %d score %.1f, got %d points rank %d score %.1f",
+					c.name, mahjong.Wind(i), e.points, e.rank, e.score, player.Points, player.Rank, player.Score)
+			}
+		}
+	}
+}
+
+func TestFinalResultInvalidUma(t *testing.T) {
+	points := map[mahjong.Wind]int{mahjong.East: 45000, mahjong.South: 30000, mahjong.West: 15000, mahjong.North: 10000}
+	for _, modify := range []func(rule *mahjong.Rule){
+		func(rule *mahjong.Rule) { rule.Uma = []int{15000, 0, -15000} },
+		func(rule *mahjong.Rule) { rule.FloatingUma = [][]int{nil, {12000, -1000, -11000}} },
+	} {
+		rule := mahjong.GetDefaultRule()
+		modify(rule)
+		if _, err := mahjong.CalculateFinalResult(rule, points, 0); !errors.Is(err, mahjong.ErrInvalidRule) {
+			t.Fatalf("uma %v, floating uma %v: expect ErrInvalidRule, got %v", rule.Uma, rule.FloatingUma, err)
+		}
+		if err := rule.Validate(); !errors.Is(err, mahjong.ErrInvalidRule) {
+			t.Fatalf("uma %v, floating uma %v: rule validated", rule.Uma, rule.FloatingUma)
+		}
+	}
+	if err := mahjong.GetDefaultSanmaRule().Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGameEnd(t *testing.T) {
+	var seed = rand.Int63()
+	r := rand.New(rand.NewSource(seed))
+	players := newPlayers(4)
+	game := mahjong.NewMahjongGame(seed, nil)
+
+	for i := 0; i < 10; i++ {
+		posCalls := game.Reset(players, nil)
+		flag := mahjong.EndTypeNone
+		for flag != mahjong.EndTypeGame {
+			if game.GetFinalResult() != nil {
+				t.Fatal("final result before game end")
+			}
+			posCall := make(map[mahjong.Wind]*mahjong.Call, 4)
+			for wind, calls := range posCalls {
+				posCall[wind] = calls[r.Intn(len(calls))]
+			}
+			posCalls, flag = game.Step(posCall)
+		}
+
+		result := game.GetFinalResult()
+		if result == nil {
+			t.Fatal("no final result after game end")
+		}
+		var totalPoints = 0
+		var totalScore = 0.0
+		for i, player := range result.Players {
+			if i > 0 && player.Points > result.Players[i-1].Points {
+				t.Fatal("players not ordered by rank")
+			}
+			totalPoints += player.Points
+			totalScore += player.Score
+		}
+		if totalPoints != 4*game.Rule.StartingPoints || math.Abs(totalScore) > 1e-9 {
+			t.Fatalf("final result not zero sum, %d points, %.1f score", totalPoints, totalScore)
+		}
+
+		var numGameEnd = 0
+		for _, event := range game.GetAllGlobalEvents() {
+			if event.GetType() == mahjong.EventTypeGameEnd {
+				numGameEnd++
+			}
+		}
+		events := game.GetGlobalEvents()
+		if numGameEnd != 1 || events[len(events)-1].GetType() != mahjong.EventTypeGameEnd {
+			t.Fatal("game end event not at the end of the global events")
+		}
+
+		// test json
+		b, _ := json.Marshal(&events)
+		var nEvents mahjong.Events
+		if err := json.Unmarshal(b, &nEvents); err != nil {
+			t.Fatal(err)
+		}
+		nResult := nEvents[len(nEvents)-1].(*mahjong.EventGameEnd).Result
+		if nResult.Players[0].Seat != result.Players[0].Seat || nResult.Players[0].Score != result.Players[0].Score {
+			t.Fatal("final result json not equal")
+		}
+
+		cGame := mahjong.ReConstructGame(newPlayers(4), events)
+		if cGame.GetFinalResult() == nil || cGame.GetFinalResult().Players[0].Points != result.Players[0].Points {
+			t.Fatal("reconstruct final result failed")
+		}
+	}
+}
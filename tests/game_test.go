@@ -79,3 +79,129 @@ func TestMultiGames(t *testing.T) {
 		}
 	}
 }
+
+func TestGlobalCallEvents(t *testing.T) {
+	var seed = rand.Int63()
+	r := rand.New(rand.NewSource(seed))
+	game := mahjong.NewMahjongGame(seed, nil)
+	posCalls := game.Reset(newPlayers(4), nil)
+
+	// the global chi and pon events are the events of the caller, with the tenpai infos
+	var numCalls int
+	var flag = mahjong.EndTypeNone
+	for flag != mahjong.EndTypeGame {
+		for _, event := range game.GetGlobalEvents() {
+			var who mahjong.Wind
+			switch e := event.(type) {
+			case *mahjong.EventChi:
+				who = e.Who
+			case *mahjong.EventPon:
+				who = e.Who
+			default:
+				continue
+			}
+			var found bool
+			for _, e := range game.GetPosEvents(who, 0) {
+				found = found || e == event
+			}
+			if !found {
+				t.Fatalf("seed %d: global %s event is not the event of the caller %s", seed, event.GetType(), who)
+			}
+			numCalls++
+		}
+
+		posCall := make(map[mahjong.Wind]*mahjong.Call, 4)
+		for wind, calls := range posCalls {
+			// prefer the calls to see more chi and pon
+			posCall[wind] = calls[r.Intn(len(calls))]
+			for _, call := range calls {
+				if call.CallType == mahjong.Chi || call.CallType == mahjong.Pon {
+					posCall[wind] = call
+				}
+			}
+		}
+		posCalls, flag = game.Step(posCall)
+	}
+	if numCalls == 0 {
+		t.Skipf("seed %d: no chi or pon", seed)
+	}
+}
+
+func TestDiscardAfterCall(t *testing.T) {
+	var seed = rand.Int63()
+	r := rand.New(rand.NewSource(seed))
+	game := mahjong.NewMahjongGame(seed, nil)
+	posCalls := game.Reset(newPlayers(4), nil)
+
+	// the discard after chi or pon is never a tsumogiri, even of the last tile of the hand
+	var numCalls int
+	var flag = mahjong.EndTypeNone
+	for flag != mahjong.EndTypeGame {
+		events := game.GetGlobalEvents()
+		var called bool
+		if len(events) > 0 {
+			called = events[len(events)-1].GetType() == mahjong.EventTypeChi || events[len(events)-1].GetType() == mahjong.EventTypePon
+		}
+		posCall := make(map[mahjong.Wind]*mahjong.Call, 4)
+		for wind, calls := range posCalls {
+			posCall[wind] = calls[r.Intn(len(calls))]
+			hand := game.PosPlayer[wind].HandTiles
+			for _, call := range calls {
+				if call.CallType == mahjong.Chi || call.CallType == mahjong.Pon ||
+					called && call.CallType == mahjong.Discard && call.CallTiles[0] == hand[len(hand)-1] {
+					posCall[wind] = call
+				}
+			}
+		}
+		posCalls, flag = game.Step(posCall)
+		if called {
+			numCalls++
+			events = game.GetGlobalEvents()
+			if events[len(events)-1].GetType() == mahjong.EventTypeTsumoGiri {
+				t.Fatalf("seed %d: the discard after a call is a tsumogiri", seed)
+			}
+		}
+	}
+	if numCalls == 0 {
+		t.Skipf("seed %d: no chi or pon", seed)
+	}
+}
+
+func TestGlobalInitRiichiSticks(t *testing.T) {
+	r := rand.New(rand.NewSource(rand.Int63()))
+	// east is tenpai on 25m with 234m34m456p678s99p and declares riichi on the first discard
+	wall := prepareWall(r, map[int]mahjong.Tile{
+		0: mahjong.Man2T1, 1: mahjong.Man3T1, 2: mahjong.Man4T1, 3: mahjong.Man3T2, 4: mahjong.Man4T2, 5: mahjong.Pin4T1,
+		6: mahjong.Pin5T2, 7: mahjong.Pin6T1, 8: mahjong.Sou6T1, 9: mahjong.Sou7T1, 10: mahjong.Sou8T1, 11: mahjong.Pin9T1,
+		12: mahjong.Pin9T2, 52: mahjong.Chun1,
+	})
+	game := mahjong.NewMahjongGame(r.Int63(), nil)
+	posCalls := game.Reset(newPlayers(4), wall)
+	var riichi *mahjong.Call
+	for _, call := range posCalls[mahjong.East] {
+		if call.CallType == mahjong.Riichi {
+			riichi = call
+		}
+	}
+	if riichi == nil {
+		t.Fatal("riichi is not valid")
+	}
+	posCalls, _ = game.Step(map[mahjong.Wind]*mahjong.Call{mahjong.East: riichi})
+	for game.NumRiichi == 0 {
+		posCall := make(map[mahjong.Wind]*mahjong.Call, 4)
+		for wind, calls := range posCalls {
+			// skip the calls, east discards the riichi tile
+			for _, call := range calls {
+				if call.CallType == mahjong.Skip || call.CallType == mahjong.Discard {
+					posCall[wind] = call
+				}
+			}
+		}
+		posCalls, _ = game.Step(posCall)
+	}
+
+	// the global init reports the riichi sticks at the start of the round, not the sticks of the game
+	if init := game.GetGlobalEvents()[0].(*mahjong.EventGlobalInit); init.NumRiichi != 0 {
+		t.Fatalf("%d riichi sticks in the global init, expect 0", init.NumRiichi)
+	}
+}
@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"github.com/hphphp123321/mahjong-go/mahjong"
+	"math/rand"
+	"testing"
+)
+
+func TestKuikae(t *testing.T) {
+	for _, policy := range []mahjong.KuikaePolicy{
+		mahjong.KuikaeForbidAll, mahjong.KuikaeForbidGenbutsu, mahjong.KuikaeAllowed, mahjong.KuikaePenalty,
+	} {
+		r := rand.New(rand.NewSource(rand.Int63()))
+		// east discards 3m, south chi it with 4m5m and holds another 3m and 6m
+		wall := prepareWall(r, map[int]mahjong.Tile{
+			0:  mahjong.Man3T1,
+			13: mahjong.Man4T1, 14: mahjong.Man5T1, 15: mahjong.Man3T2, 16: mahjong.Man6T1,
+			100: mahjong.Man3T3, 101: mahjong.Man3T4,
+		})
+		rule := mahjong.GetDefaultRule()
+		rule.KuikaePolicy = policy
+		game := mahjong.NewMahjongGame(r.Int63(), rule)
+		posCalls := game.Reset(newPlayers(4), wall)
+
+		var posCall = map[mahjong.Wind]*mahjong.Call{}
+		for _, call := range posCalls[mahjong.East] {
+			if call.CallType == mahjong.Discard && call.CallTiles[0] == mahjong.Man3T1 {
+				posCall[mahjong.East] = call
+			}
+		}
+		posCalls, _ = game.Step(posCall)
+		posCall = map[mahjong.Wind]*mahjong.Call{}
+		for wind, calls := range posCalls {
+			posCall[wind] = mahjong.SkipCall
+			for _, call := range calls {
+				if wind == mahjong.South && call.CallType == mahjong.Chi &&
+					call.CallTiles[0] == mahjong.Man4T1 && call.CallTiles[1] == mahjong.Man5T1 {
+					posCall[wind] = call
+				}
+			}
+		}
+		if posCall[mahjong.South].CallType != mahjong.Chi {
+			t.Fatalf("%s: south can't chi", policy)
+		}
+		posCalls, _ = game.Step(posCall)
+
+		var discardable = make(map[mahjong.Tile]bool)
+		for _, call := range posCalls[mahjong.South] {
+			discardable[call.CallTiles[0]] = true
+		}
+		var expects = map[mahjong.KuikaePolicy][2]bool{
+			mahjong.KuikaeForbidAll:      {false, false},
+			mahjong.KuikaeForbidGenbutsu: {false, true},
+			mahjong.KuikaeAllowed:        {true, true},
+			mahjong.KuikaePenalty:        {true, true},
+		}
+		if discardable[mahjong.Man3T2] != expects[policy][0] || discardable[mahjong.Man6T1] != expects[policy][1] {
+			t.Fatalf("%s: unexpected discards 3m %v 6m %v", policy, discardable[mahjong.Man3T2], discardable[mahjong.Man6T1])
+		}
+
+		boardState := game.GetPosBoardState(mahjong.South, posCalls[mahjong.South])
+		// the restricted tiles are exposed even if they can be discarded with the penalty
+		hasGenbutsu := boardState.KuikaeTiles.Index(mahjong.Man3T2, 0) != -1
+		hasSuji := boardState.KuikaeTiles.Index(mahjong.Man6T1, 0) != -1
+		if hasGenbutsu != (policy != mahjong.KuikaeAllowed) ||
+			hasSuji != (policy == mahjong.KuikaeForbidAll || policy == mahjong.KuikaePenalty) {
+			t.Fatalf("%s: unexpected kuikae tiles %v", policy, boardState.KuikaeTiles)
+		}
+		nb := mahjong.NewBoardState()
+		nb.DecodeEvents(game.GetPosEvents(mahjong.South, 0))
+		if !boardState.Equal(nb) {
+			t.Fatalf("%s: board state not equal", policy)
+		}
+
+		if policy != mahjong.KuikaePenalty {
+			continue
+		}
+		for _, call := range posCalls[mahjong.South] {
+			if call.CallTiles[0] == mahjong.Man3T2 {
+				game.Step(map[mahjong.Wind]*mahjong.Call{mahjong.South: call})
+			}
+		}
+		var found = false
+		for _, event := range game.GetGlobalEvents() {
+			if e, ok := event.(*mahjong.EventKuikae); ok && e.Who == mahjong.South && e.Tile == mahjong.Man3T2 {
+				found = true
+			}
+		}
+		if !found || game.PosPlayer[mahjong.South].Points != 25000-3*rule.KuikaePenalty ||
+			game.PosPlayer[mahjong.East].Points != 25000+rule.KuikaePenalty {
+			t.Fatalf("kuikae penalty not paid, south has %d points", game.PosPlayer[mahjong.South].Points)
+		}
+		nb = mahjong.NewBoardState()
+		nb.DecodeEvents(game.GetPosEvents(mahjong.East, 0))
+		if nb.PlayerStates[mahjong.South].Points != game.PosPlayer[mahjong.South].Points {
+			t.Fatal("kuikae penalty not decoded in board state")
+		}
+	}
+}
@@ -0,0 +1,314 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hphphp123321/mahjong-go/mahjong"
+)
+
+// majsoulTile is the Mahjong Soul name of the tile, 0 for the red five
+func majsoulTile(tile mahjong.Tile, hasAkaDora bool) string {
+	class := int(tile.Class())
+	if class >= 27 {
+		return fmt.Sprintf("%dz", class-26)
+	}
+	n := class%9 + 1
+	if hasAkaDora && n == 5 && tile%4 == 0 {
+		n = 0
+	}
+	return fmt.Sprintf("%d%c", n, "mps"[class/9])
+}
+
+// majsoulRecords writes the global events of a game as the records of Mahjong Soul,
+// the dora indicators of a kan are given by the next record as the client receives them
+func majsoulRecords(events mahjong.Events) []map[string]interface{} {
+	var records []map[string]interface{}
+	var rule *mahjong.Rule
+	var init *mahjong.EventGlobalInit
+	var newRound map[string]interface{}
+	var doras []string
+	pendingDoras := false
+	riichi := make(map[mahjong.Wind]bool)
+	drawn := make(map[mahjong.Wind]mahjong.Tile)
+	var hules []map[string]interface{}
+	isNagashi := false
+	reason := mahjong.RyuuKyokuNormal
+
+	tile := func(t mahjong.Tile) string { return majsoulTile(t, rule.HasAkaDora) }
+	seat := func(w mahjong.Wind) int {
+		return (int(w) + int(init.WindRound-mahjong.WindRoundEast1)%4) % rule.NumPlayers()
+	}
+	deltas := func(pointsChange map[mahjong.Wind]int) []int {
+		d := make([]int, rule.NumPlayers())
+		for w, p := range pointsChange {
+			d[seat(w)] = p
+		}
+		return d
+	}
+	add := func(name string, data map[string]interface{}) {
+		if pendingDoras && name != "RecordDealTile" {
+			data["doras"] = append([]string{}, doras...)
+			pendingDoras = false
+		}
+		records = append(records, map[string]interface{}{"name": ".lq." + name, "data": data})
+	}
+	for _, event := range events {
+		switch e := event.(type) {
+		case *mahjong.EventGlobalInit:
+			init, rule = e, e.Rule
+			doras = []string{tile(e.AllTiles[len(e.AllTiles)-6])}
+			scores := make([]int, rule.NumPlayers())
+			for w, p := range e.InitPoints {
+				scores[seat(w)] = p
+			}
+			round := int(e.WindRound - mahjong.WindRoundEast1)
+			newRound = map[string]interface{}{"chang": round / 4, "ju": round % 4, "ben": e.NumHonba, "liqibang": e.NumRiichi,
+				"scores": scores, "dora": doras[0], "doras": doras}
+			for w := 0; w < rule.NumPlayers(); w++ {
+				var hand []string
+				for _, t := range e.AllTiles[13*w : 13*w+13] {
+					hand = append(hand, tile(t))
+				}
+				newRound[fmt.Sprintf("tiles%d", seat(mahjong.Wind(w)))] = hand
+			}
+			records = append(records, map[string]interface{}{"name": ".lq.RecordNewRound", "data": newRound})
+			drawn = make(map[mahjong.Wind]mahjong.Tile)
+			hules, isNagashi, reason = nil, false, mahjong.RyuuKyokuNormal
+		case *mahjong.EventGet:
+			drawn[e.Who] = e.Tile
+			if newRound != nil {
+				key := fmt.Sprintf("tiles%d", seat(e.Who))
+				newRound[key] = append(newRound[key].([]string), tile(e.Tile))
+				newRound = nil
+				continue
+			}
+			add("RecordDealTile", map[string]interface{}{"seat": seat(e.Who), "tile": tile(e.Tile)})
+		case *mahjong.EventDiscard:
+			add("RecordDiscardTile", map[string]interface{}{"seat": seat(e.Who), "tile": tile(e.Tile), "is_liqi": riichi[e.Who]})
+			riichi[e.Who] = false
+			delete(drawn, e.Who)
+		case *mahjong.EventTsumoGiri:
+			add("RecordDiscardTile", map[string]interface{}{"seat": seat(e.Who), "tile": tile(e.Tile), "is_liqi": riichi[e.Who],
+				"moqie": drawn[e.Who] == e.Tile})
+			riichi[e.Who] = false
+			delete(drawn, e.Who)
+		case *mahjong.EventRiichi:
+			riichi[e.Who] = e.Step == 1
+		case *mahjong.EventChi, *mahjong.EventPon, *mahjong.EventDaiMinKan:
+			var call *mahjong.Call
+			var who mahjong.Wind
+			callType := 0
+			switch c := e.(type) {
+			case *mahjong.EventChi:
+				call, who = c.Call, c.Who
+			case *mahjong.EventPon:
+				call, who, callType = c.Call, c.Who, 1
+			case *mahjong.EventDaiMinKan:
+				call, who, callType = c.Call, c.Who, 2
+			}
+			var tiles []string
+			var froms []int
+			for i, t := range call.CallTiles {
+				if t != mahjong.TileDummy {
+					tiles = append(tiles, tile(t))
+					froms = append(froms, seat(call.CallTilesFromWho[i]))
+				}
+			}
+			add("RecordChiPengGang", map[string]interface{}{"seat": seat(who), "type": callType, "tiles": tiles, "froms": froms})
+		case *mahjong.EventShouMinKan:
+			add("RecordAnGangAddGang", map[string]interface{}{"seat": seat(e.Who), "type": 2, "tiles": tile(e.Call.CallTiles[3])})
+		case *mahjong.EventAnKan:
+			add("RecordAnGangAddGang", map[string]interface{}{"seat": seat(e.Who), "type": 3, "tiles": tile(e.Call.CallTiles[0])})
+		case *mahjong.EventKita:
+			add("RecordBaBei", map[string]interface{}{"seat": seat(e.Who)})
+		case *mahjong.EventNewIndicator:
+			doras = append(doras, tile(e.Tile))
+			last := records[len(records)-1]
+			if data := last["data"].(map[string]interface{}); last["name"] == ".lq.RecordAnGangAddGang" && data["type"] == 3 {
+				data["doras"] = append([]string{}, doras...)
+			} else {
+				pendingDoras = true
+			}
+		case *mahjong.EventRon, *mahjong.EventTsumo, *mahjong.EventChanKan:
+			var who mahjong.Wind
+			var result *mahjong.Result
+			zimo := false
+			switch w := e.(type) {
+			case *mahjong.EventRon:
+				who, result = w.Who, w.Result
+			case *mahjong.EventChanKan:
+				who, result = w.Who, w.Result
+			case *mahjong.EventTsumo:
+				who, result, zimo = w.Who, w.Result, true
+			}
+			hule := map[string]interface{}{"seat": seat(who), "zimo": zimo}
+			_, isRiichi := result.YakuResult.Yaku[mahjong.YakuRiichi]
+			_, isDaburi := result.YakuResult.Yaku[mahjong.YakuDaburi]
+			if isRiichi || isDaburi {
+				var ura []string
+				for i := range doras {
+					ura = append(ura, tile(init.AllTiles[len(init.AllTiles)-5-2*i]))
+				}
+				hule["li_doras"] = ura
+			}
+			hules = append(hules, hule)
+		case *mahjong.EventNagashiMangan:
+			isNagashi = true
+		case *mahjong.EventRyuuKyoku:
+			reason = e.Reason
+		case *mahjong.EventEnd:
+			switch {
+			case len(hules) > 0:
+				add("RecordHule", map[string]interface{}{"hules": hules, "delta_scores": deltas(e.PointsChange)})
+			case reason == mahjong.RyuuKyokuNormal:
+				add("RecordNoTile", map[string]interface{}{"liujumanguan": isNagashi,
+					"scores": []map[string]interface{}{{"delta_scores": deltas(e.PointsChange)}}})
+			default:
+				types := map[mahjong.RyuuKyokuReason]int{mahjong.RyuuKyokuKyuuShuKyuuHai: 1, mahjong.RyuuKyokuSuufonRenda: 2,
+					mahjong.RyuuKyokuSuuKaiKan: 3, mahjong.RyuuKyokuSuuChaRiichi: 4, mahjong.RyuuKyokuSanChaHou: 5}
+				add("RecordLiuJu", map[string]interface{}{"type": types[reason]})
+			}
+			pendingDoras = false
+		}
+	}
+	return records
+}
+
+func TestImportMajsoul(t *testing.T) {
+	names := []string{"Alice", "Bob", "Carol", "雀魂"}
+	for i := 0; i < 6; i++ {
+		var seed = rand.Int63()
+		r := rand.New(rand.NewSource(seed))
+		mode, detail := 2, map[string]interface{}{"dora_count": 3, "shiduan": 1}
+		rule := mahjong.GetDefaultRule()
+		rule.Uma = []int{15000, 5000, -5000, -15000}
+		switch i % 3 {
+		case 1:
+			detail = map[string]interface{}{"shiduan": 1, "init_point": 30000, "fandian": 30000}
+			rule.HasAkaDora = false
+			rule.StartingPoints = 30000
+		case 2:
+			mode, detail = 11, nil
+			rule = mahjong.GetDefaultSanmaRule()
+			rule.GameLength = 4
+			rule.IsSanmaTsumoLoss = false
+		}
+		game := mahjong.NewMahjongGame(seed, rule)
+		posCalls := game.Reset(newPlayers(rule.NumPlayers()), nil)
+		var flag = mahjong.EndTypeNone
+		for flag != mahjong.EndTypeGame {
+			posCall := make(map[mahjong.Wind]*mahjong.Call, 4)
+			for wind, calls := range posCalls {
+				posCall[wind] = calls[r.Intn(len(calls))]
+			}
+			posCalls, flag = game.Step(posCall)
+		}
+
+		modeConfig := map[string]interface{}{"mode": mode}
+		if detail != nil {
+			modeConfig["detail_rule"] = detail
+		}
+		var accounts []map[string]interface{}
+		for s := 0; s < rule.NumPlayers(); s++ {
+			accounts = append(accounts, map[string]interface{}{"seat": s, "nickname": names[s]})
+		}
+		data, err := json.Marshal(map[string]interface{}{
+			"head":    map[string]interface{}{"uuid": "test", "config": map[string]interface{}{"mode": modeConfig}, "accounts": accounts},
+			"records": majsoulRecords(game.GetAllGlobalEvents()),
+		})
+		if err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+
+		record, err := mahjong.ImportMajsoul(data)
+		if err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+		if !reflect.DeepEqual(record.Rule, rule) {
+			t.Fatalf("seed %d: rule of the record differs", seed)
+		}
+		if record.UUID != "test" || !reflect.DeepEqual(record.Names, names[:rule.NumPlayers()]) {
+			t.Fatalf("seed %d: uuid %q, names %q", seed, record.UUID, record.Names)
+		}
+		if len(record.Rounds) != len(splitRounds(game.GetAllGlobalEvents())) {
+			t.Fatalf("seed %d: %d rounds imported", seed, len(record.Rounds))
+		}
+
+		// the rounds rebuilt from the record are exported the same, copies of a tile may change
+		var rebuilt mahjong.Events
+		for j, events := range record.Rounds {
+			rounds := splitRounds(mahjong.ReConstructGame(newPlayers(rule.NumPlayers()), events).GetAllGlobalEvents())
+			if rounds[0][len(rounds[0])-1].GetType() != mahjong.EventTypeEnd && j < len(record.Rounds)-1 {
+				t.Fatalf("seed %d: round %d is not rebuilt to the end", seed, j)
+			}
+			rebuilt = append(rebuilt, rounds[0]...)
+		}
+		expect, err := mahjong.ExportTenhou6(game.GetAllGlobalEvents(), names)
+		if err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+		got, err := mahjong.ExportTenhou6(rebuilt, names)
+		if err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+		if !bytes.Equal(got, expect) {
+			t.Fatalf("seed %d: rebuilt game\n%s\nexpect\n%s", seed, got, expect)
+		}
+	}
+
+	for _, data := range []string{
+		`{"head": {"config": {"mode": {"mode": 3}}}, "records": []}`,
+		`{"head": {"config": {"mode": {"mode": 2}}}, "records": [{"name": ".lq.RecordDealTile", "data": {"seat": 0, "tile": "1m"}}]}`,
+		`{"head": {"config": {"mode": {"mode": 2}}}, "records": [{"name": ".lq.RecordNewRound", "data": {"scores": [0, 0, 0, 0], "dora": "8z"}}]}`,
+	} {
+		if _, err := mahjong.ImportMajsoul([]byte(data)); err == nil {
+			t.Fatalf("%s is imported", data)
+		}
+	}
+}
+
+// TestMajsoulFixtures replays the Mahjong Soul records in testdata/majsoul, see testdata/README.md,
+// the points of every round with the riichi sticks add up to the starting points of the rule read from the head
+func TestMajsoulFixtures(t *testing.T) {
+	files, _ := filepath.Glob(filepath.Join("testdata", "majsoul", "*.json"))
+	if len(files) == 0 {
+		t.Skip("no Mahjong Soul records in testdata/majsoul")
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		record, err := mahjong.ImportMajsoul(data)
+		if err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		if len(record.Rounds) == 0 {
+			t.Fatalf("%s: no round imported", file)
+		}
+		for j, events := range record.Rounds {
+			init := events[0].(*mahjong.EventGlobalInit)
+			sum := init.NumRiichi * 1000
+			for _, points := range init.InitPoints {
+				sum += points
+			}
+			if sum != record.Rule.StartingPoints*record.Rule.NumPlayers() {
+				t.Fatalf("%s: round %d has %d points, the rule starts with %d", file, j, sum, record.Rule.StartingPoints)
+			}
+			if j < len(record.Rounds)-1 {
+				rounds := splitRounds(mahjong.ReConstructGame(newPlayers(record.Rule.NumPlayers()), events).GetAllGlobalEvents())
+				if rounds[0][len(rounds[0])-1].GetType() != mahjong.EventTypeEnd {
+					t.Fatalf("%s: round %d is not rebuilt to the end", file, j)
+				}
+			}
+		}
+		replayRounds(t, file, record.Rule, record.Rounds)
+	}
+}
@@ -0,0 +1,220 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/hphphp123321/mahjong-go/mahjong"
+)
+
+// mjaiResponse is the mjai response of a bot choosing the call
+func mjaiResponse(call *mahjong.Call, who mahjong.Wind, drawn mahjong.Tile, seat func(mahjong.Wind) int, hasAkaDora bool) map[string]interface{} {
+	tile := func(t mahjong.Tile) string { return mahjong.MjaiTile(t, hasAkaDora) }
+	switch call.CallType {
+	case mahjong.Discard, mahjong.Riichi:
+		return map[string]interface{}{"type": "dahai", "actor": seat(who), "pai": tile(call.CallTiles[0]), "tsumogiri": call.CallTiles[0] == drawn}
+	case mahjong.Chi, mahjong.Pon, mahjong.DaiMinKan:
+		names := map[mahjong.CallType]string{mahjong.Chi: "chi", mahjong.Pon: "pon", mahjong.DaiMinKan: "daiminkan"}
+		m := map[string]interface{}{"type": names[call.CallType], "actor": seat(who)}
+		var consumed []string
+		for i, t := range call.CallTiles {
+			switch {
+			case t == mahjong.TileDummy:
+			case call.CallTilesFromWho[i] != who:
+				m["target"], m["pai"] = seat(call.CallTilesFromWho[i]), tile(t)
+			default:
+				consumed = append(consumed, tile(t))
+			}
+		}
+		m["consumed"] = consumed
+		return m
+	case mahjong.ShouMinKan:
+		return map[string]interface{}{"type": "kakan", "actor": seat(who), "pai": tile(call.CallTiles[3])}
+	case mahjong.AnKan:
+		var consumed []string
+		for _, t := range call.CallTiles {
+			consumed = append(consumed, tile(t))
+		}
+		return map[string]interface{}{"type": "ankan", "actor": seat(who), "consumed": consumed}
+	case mahjong.Ron, mahjong.Tsumo, mahjong.ChanKan:
+		return map[string]interface{}{"type": "hora", "actor": seat(who)}
+	case mahjong.KyuuShuKyuuHai:
+		return map[string]interface{}{"type": "ryukyoku", "actor": seat(who)}
+	case mahjong.Kita:
+		return map[string]interface{}{"type": "nukidora", "actor": seat(who), "pai": "N"}
+	}
+	return map[string]interface{}{"type": "none"}
+}
+
+func TestMjaiAdapter(t *testing.T) {
+	for i := 0; i < 6; i++ {
+		var seed = rand.Int63()
+		r := rand.New(rand.NewSource(seed))
+		rule := mahjong.GetDefaultRule()
+		if i%2 == 1 {
+			rule = mahjong.GetDefaultSanmaRule()
+		}
+		n := rule.NumPlayers()
+		game := mahjong.NewMahjongGame(seed, rule)
+		adapters := make([]*mahjong.MjaiAdapter, n)
+		streams := make([][]mahjong.MjaiMessage, n)
+		starts := make([]mahjong.Event, n)
+		read := make([]int, n)
+		drawn := make([]mahjong.Tile, n)
+		for s := range adapters {
+			adapters[s] = mahjong.NewMjaiAdapter(s, []string{"A", "B", "C", "D"}[:n])
+			streams[s] = append(streams[s], adapters[s].StartGame())
+		}
+		wind := func(s int) mahjong.Wind {
+			return mahjong.Wind((s - int(game.WindRound-mahjong.WindRoundEast1)%4 + n) % n)
+		}
+		seat := func(w mahjong.Wind) int {
+			return (int(w) + int(game.WindRound-mahjong.WindRoundEast1)%4) % n
+		}
+		// feed the new events of every seat to its adapter
+		feed := func() {
+			for s, adapter := range adapters {
+				events := game.GetPosEvents(wind(s), 0)
+				if len(events) > 0 && events[0] != starts[s] {
+					starts[s], read[s] = events[0], 0
+				}
+				for _, event := range events[read[s]:] {
+					if get, ok := event.(*mahjong.EventGet); ok && get.Who == wind(s) {
+						drawn[s] = get.Tile
+					}
+				}
+				streams[s] = append(streams[s], adapter.Messages(events[read[s]:])...)
+				read[s] = len(events)
+			}
+		}
+
+		posCalls := game.Reset(newPlayers(n), nil)
+		var flag = mahjong.EndTypeNone
+		for flag != mahjong.EndTypeGame {
+			feed()
+			posCall := make(map[mahjong.Wind]*mahjong.Call, 4)
+			for w, calls := range posCalls {
+				s := seat(w)
+				call := calls[r.Intn(len(calls))]
+				if call.CallType == mahjong.AgariYame {
+					posCall[w] = call
+					continue
+				}
+				if call.CallType == mahjong.Riichi {
+					data, _ := json.Marshal(map[string]interface{}{"type": "reach", "actor": s})
+					got, reply, err := adapters[s].ToCall(data, calls)
+					if err != nil || got != nil || reply.Type() != "reach" {
+						t.Fatalf("seed %d: reach answered by %v %v %v", seed, got, reply, err)
+					}
+					streams[s] = append(streams[s], reply)
+				}
+				data, _ := json.Marshal(mjaiResponse(call, w, drawn[s], seat, rule.HasAkaDora))
+				got, reply, err := adapters[s].ToCall(data, calls)
+				if err != nil || reply != nil {
+					t.Fatalf("seed %d: %s: %v %v", seed, data, reply, err)
+				}
+				if got.CallType != call.CallType || !equalMjaiTiles(got.CallTiles, call.CallTiles, rule.HasAkaDora) {
+					t.Fatalf("seed %d: %s is translated to %s, expect %s", seed, data, got, call)
+				}
+				posCall[w] = got
+			}
+			posCalls, flag = game.Step(posCall)
+		}
+		feed()
+
+		for s, stream := range streams {
+			if _, err := json.Marshal(stream); err != nil {
+				t.Fatalf("seed %d: %v", seed, err)
+			}
+			if stream[0].Type() != "start_game" || stream[len(stream)-1].Type() != "end_game" {
+				t.Fatalf("seed %d: stream of seat %d from %s to %s", seed, s, stream[0].Type(), stream[len(stream)-1].Type())
+			}
+			reaching := false
+			for _, m := range stream {
+				switch m.Type() {
+				case "start_kyoku":
+					for j, tehai := range m["tehais"].([][]string) {
+						if (tehai[0] == "?") == (j == s) {
+							t.Fatalf("seed %d: tehai %v of seat %d sent to seat %d", seed, tehai, j, s)
+						}
+					}
+				case "tsumo":
+					if (m["pai"] == "?") == (m["actor"] == s) {
+						t.Fatalf("seed %d: tsumo %v sent to seat %d", seed, m, s)
+					}
+				case "reach":
+					if m["actor"] == s && reaching {
+						t.Fatalf("seed %d: reach of seat %d sent twice", seed, s)
+					}
+					reaching = m["actor"] == s
+				case "dahai":
+					reaching = false
+				}
+			}
+		}
+	}
+
+	adapter := mahjong.NewMjaiAdapter(0, nil)
+	if _, _, err := adapter.ToCall([]byte(`{"type":"hora","actor":0}`), mahjong.Calls{mahjong.SkipCall}); !errors.Is(err, mahjong.ErrIllegalCall) {
+		t.Fatalf("hora without a ron call: %v", err)
+	}
+
+	// a response other than the matching dahai cancels the reach
+	calls := mahjong.Calls{
+		mahjong.NewCall(mahjong.Riichi, mahjong.Tiles{mahjong.Chun1}, nil),
+		mahjong.NewCall(mahjong.Discard, mahjong.Tiles{mahjong.Chun1}, nil),
+		mahjong.NewCall(mahjong.Discard, mahjong.Tiles{mahjong.Man1T1}, nil),
+	}
+	for _, resp := range []string{`{"type":"dahai","actor":0,"pai":"9s"}`, `{"type":"none"}`} {
+		if _, _, err := adapter.ToCall([]byte(`{"type":"reach","actor":0}`), calls); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := adapter.ToCall([]byte(resp), calls); !errors.Is(err, mahjong.ErrIllegalCall) {
+			t.Fatalf("%s after the reach: %v", resp, err)
+		}
+		got, _, err := adapter.ToCall([]byte(`{"type":"dahai","actor":0,"pai":"C"}`), calls)
+		if err != nil || got.CallType != mahjong.Discard {
+			t.Fatalf("dahai after %s is translated to %v %v", resp, got, err)
+		}
+	}
+	for tile, name := range map[mahjong.Tile]string{16: "5mr", 17: "5m", 88: "5sr", 0: "1m", 135: "C", mahjong.TileDummy: "?"} {
+		if got := mahjong.MjaiTile(tile, true); got != name {
+			t.Fatalf("tile %d is %s, expect %s", tile, got, name)
+		}
+	}
+	if got := mahjong.MjaiTile(52, false); got != "5p" {
+		t.Fatalf("tile 52 without aka dora is %s", got)
+	}
+}
+
+func TestMjaiRyuuKyoku(t *testing.T) {
+	// the reasons are the names of mjai
+	for reason, name := range map[mahjong.RyuuKyokuReason]string{
+		mahjong.RyuuKyokuNormal:         "fanpai",
+		mahjong.RyuuKyokuKyuuShuKyuuHai: "kyushukyuhai",
+		mahjong.RyuuKyokuSuuChaRiichi:   "suchareach",
+		mahjong.RyuuKyokuSuuKaiKan:      "sukaikan",
+		mahjong.RyuuKyokuSuufonRenda:    "sufonrenta",
+		mahjong.RyuuKyokuSanChaHou:      "sanchaho",
+	} {
+		adapter := mahjong.NewMjaiAdapter(0, nil)
+		messages := adapter.Messages(mahjong.Events{&mahjong.EventRyuuKyoku{Who: mahjong.East, Reason: reason}})
+		if len(messages) != 1 || messages[0].Type() != "ryukyoku" || messages[0]["reason"] != name {
+			t.Fatalf("ryuu kyoku %s is sent as %v, expect the reason %s", reason, messages, name)
+		}
+	}
+}
+
+func equalMjaiTiles(tiles1, tiles2 mahjong.Tiles, hasAkaDora bool) bool {
+	if len(tiles1) != len(tiles2) {
+		return false
+	}
+	for i := range tiles1 {
+		if mahjong.MjaiTile(tiles1[i], hasAkaDora) != mahjong.MjaiTile(tiles2[i], hasAkaDora) {
+			return false
+		}
+	}
+	return true
+}
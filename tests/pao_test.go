@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"github.com/hphphp123321/mahjong-go/mahjong"
+	"math/rand"
+	"testing"
+)
+
+func TestPaoDaisangen(t *testing.T) {
+	for _, isTsumo := range []bool{false, true} {
+		r := rand.New(rand.NewSource(rand.Int63()))
+		// east feeds the three dragons to south, south waits on 9p with daisangen
+		fixed := map[int]mahjong.Tile{
+			0: mahjong.Haku3, 1: mahjong.Hatsu3, 2: mahjong.Chun3,
+			13: mahjong.Haku1, 14: mahjong.Haku2, 15: mahjong.Hatsu1, 16: mahjong.Hatsu2, 17: mahjong.Chun1,
+			18: mahjong.Chun2, 19: mahjong.Man1T1, 20: mahjong.Man2T1, 21: mahjong.Man3T1, 22: mahjong.Pin9T2,
+			23: mahjong.Sou1T1, 24: mahjong.Sou5T1, 25: mahjong.Pei1,
+			100: mahjong.Haku4, 101: mahjong.Hatsu4, 102: mahjong.Chun4, 103: mahjong.Pin9T3, 104: mahjong.Pin9T4,
+		}
+		if isTsumo {
+			// south draws it after the third pon
+			fixed[62] = mahjong.Pin9T1
+		} else {
+			// west discards it after the third pon
+			fixed[26] = mahjong.Pin9T1
+		}
+		game := mahjong.NewMahjongGame(r.Int63(), nil)
+		posCalls := game.Reset(newPlayers(4), prepareWall(r, fixed))
+
+		var discards = map[mahjong.Wind]mahjong.Tiles{
+			mahjong.East:  {mahjong.Haku3, mahjong.Hatsu3, mahjong.Chun3},
+			mahjong.South: {mahjong.Sou1T1, mahjong.Sou5T1, mahjong.Pei1},
+			mahjong.West:  {mahjong.Pin9T1},
+		}
+		flag := mahjong.EndTypeNone
+		for flag == mahjong.EndTypeNone {
+			posCall := make(map[mahjong.Wind]*mahjong.Call)
+			for wind, calls := range posCalls {
+				posCall[wind] = choosePaoCall(game, wind, calls, discards[wind])
+			}
+			posCalls, flag = game.Step(posCall)
+		}
+
+		var paoWho = mahjong.WindDummy
+		for _, event := range game.GetGlobalEvents() {
+			switch e := event.(type) {
+			case *mahjong.EventRon:
+				paoWho = e.PaoWho
+			case *mahjong.EventTsumo:
+				paoWho = e.PaoWho
+			}
+		}
+		if paoWho != mahjong.East {
+			t.Fatalf("expect east is liable, got %s", paoWho)
+		}
+		var expects = map[mahjong.Wind]int{
+			mahjong.East: 25000 - 16000, mahjong.South: 25000 + 32000, mahjong.West: 25000 - 16000, mahjong.North: 25000,
+		}
+		if isTsumo {
+			expects[mahjong.East] = 25000 - 32000
+			expects[mahjong.West] = 25000
+		}
+		for wind, points := range expects {
+			if game.PosPlayer[wind].Points != points {
+				t.Fatalf("tsumo %v: expect %s has %d points, got %d", isTsumo, wind, points, game.PosPlayer[wind].Points)
+			}
+		}
+	}
+}
+
+// choosePaoCall south pons the dragons and wins, everyone else skips and discards the scripted tiles first
+func choosePaoCall(game *mahjong.Game, wind mahjong.Wind, calls mahjong.Calls, discards mahjong.Tiles) *mahjong.Call {
+	for _, call := range calls {
+		switch call.CallType {
+		case mahjong.Ron, mahjong.Tsumo:
+			if wind == mahjong.South {
+				return call
+			}
+		case mahjong.Pon:
+			if wind == mahjong.South && call.CallTiles[0].Class() >= mahjong.Haku {
+				return call
+			}
+		}
+	}
+	for _, call := range calls {
+		if call.CallType == mahjong.Skip {
+			return call
+		}
+	}
+	for _, tile := range discards {
+		// west keeps 9p until south is tenpai
+		if wind == mahjong.West && len(game.PosPlayer[mahjong.South].Melds) < 3 {
+			break
+		}
+		for _, call := range calls {
+			if call.CallType == mahjong.Discard && call.CallTiles[0] == tile {
+				return call
+			}
+		}
+	}
+	handTiles := game.PosPlayer[wind].HandTiles
+	for _, call := range calls {
+		if call.CallType == mahjong.Discard && call.CallTiles[0] == handTiles[len(handTiles)-1] {
+			return call
+		}
+	}
+	return calls[0]
+}
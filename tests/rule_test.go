@@ -1,6 +1,8 @@
 package tests
 
 import (
+	"encoding/json"
+	"errors"
 	"github.com/hphphp123321/mahjong-go/mahjong"
 	"math/rand"
 	"testing"
@@ -24,6 +26,9 @@ func TestRule(t *testing.T) {
 		IsDoubleYakumans:     false,
 		IsYakumanSum:         false,
 		HonbaValue:           100,
+		RiichiDeposit:        1000,
+		NotenPenalty:         3000,
+		IsTenpaiRenchan:      true,
 		IsSanChaHou:          false,
 		IsNagashiMangan:      false,
 	}
@@ -46,3 +51,196 @@ func TestRule(t *testing.T) {
 		posCall = make(map[mahjong.Wind]*mahjong.Call, 4)
 	}
 }
+
+func TestGameEndRule(t *testing.T) {
+	var seed = rand.Int63()
+	r := rand.New(rand.NewSource(seed))
+
+	playGame := func(rule *mahjong.Rule) *mahjong.Game {
+		players := make([]*mahjong.Player, 4)
+		for i := 0; i < 4; i++ {
+			players[i] = mahjong.NewMahjongPlayer()
+		}
+		game := mahjong.NewMahjongGame(r.Int63(), rule)
+		posCalls := game.Reset(players, nil)
+		for _, event := range game.GetPosEvents(mahjong.East, 0) {
+			if event.GetType() != mahjong.EventTypeStart {
+				continue
+			}
+			for _, points := range event.(*mahjong.EventStart).PlayersPoints {
+				if points != rule.StartingPoints {
+					t.Fatalf("starting points %d, expect %d", points, rule.StartingPoints)
+				}
+			}
+		}
+		var flag = mahjong.EndTypeNone
+		for flag != mahjong.EndTypeGame {
+			posCall := make(map[mahjong.Wind]*mahjong.Call, 4)
+			for wind, calls := range posCalls {
+				posCall[wind] = calls[r.Intn(len(calls))]
+			}
+			posCalls, flag = game.Step(posCall)
+		}
+		return game
+	}
+
+	// nobody can reach the target points, the game ends at the last round of each policy
+	for _, c := range []struct {
+		policy      mahjong.ExtensionPolicy
+		extraRounds int
+		lastRound   mahjong.WindRound
+	}{
+		{mahjong.ExtensionNone, 0, mahjong.WindRoundSouth4},
+		{mahjong.ExtensionSuddenDeath, 0, mahjong.WindRoundWest4},
+		{mahjong.ExtensionFixedRounds, 2, mahjong.WindRoundWest2},
+	} {
+		rule := mahjong.GetDefaultRule()
+		rule.StartingPoints = 32000
+		rule.TargetPoints = 1000000
+		rule.IsTobi = false
+		rule.ExtensionPolicy = c.policy
+		rule.ExtraRounds = c.extraRounds
+		game := playGame(rule)
+		if game.WindRound != c.lastRound {
+			t.Fatalf("%s game ends at %s, expect %s", c.policy, game.WindRound, c.lastRound)
+		}
+		if game.GetGlobalEvents()[0].(*mahjong.EventGlobalInit).Rule.StartingPoints != rule.StartingPoints {
+			t.Fatal("rule of global init event not match")
+		}
+	}
+
+	// bankruptcy at zero points, the game ends at the first round end leaving a player at zero or below
+	rule := mahjong.GetDefaultRule()
+	rule.StartingPoints = 1000
+	rule.IsTobiAtZero = true
+	game := mahjong.NewMahjongGame(r.Int63(), rule)
+	posCalls := game.Reset(newPlayers(4), nil)
+	var flag = mahjong.EndTypeNone
+	var bankrupt mahjong.Wind = mahjong.WindDummy
+	for flag != mahjong.EndTypeGame {
+		posCall := make(map[mahjong.Wind]*mahjong.Call, 4)
+		for wind, calls := range posCalls {
+			posCall[wind] = calls[r.Intn(len(calls))]
+		}
+		posCalls, flag = game.Step(posCall)
+		if flag != mahjong.EndTypeRound && flag != mahjong.EndTypeGame {
+			continue
+		}
+		for wind, player := range game.PosPlayer {
+			if player.Points <= 0 {
+				bankrupt = wind
+			}
+		}
+		if bankrupt != mahjong.WindDummy && flag != mahjong.EndTypeGame {
+			t.Fatalf("seed %d: %s has %d points, the round ends with end type %d", seed, bankrupt, game.PosPlayer[bankrupt].Points, flag)
+		}
+	}
+	if bankrupt == mahjong.WindDummy && game.WindRound < mahjong.WindRoundSouth4 {
+		t.Fatalf("seed %d: game ends at %s without bankruptcy", seed, game.WindRound)
+	}
+	final := game.GetFinalResult()
+	totalPoints := final.NumRiichi * rule.RiichiDeposit
+	for _, result := range final.Players {
+		totalPoints += result.Points
+	}
+	if totalPoints != 4*rule.StartingPoints {
+		t.Fatalf("seed %d: final points %d, expect %d", seed, totalPoints, 4*rule.StartingPoints)
+	}
+	if last := final.Players[len(final.Players)-1]; bankrupt != mahjong.WindDummy && last.Points > 0 {
+		t.Fatalf("seed %d: last player %s ends with %d points after bankruptcy", seed, last.Seat, last.Points)
+	}
+}
+
+func TestPaymentRule(t *testing.T) {
+	var seed = rand.Int63()
+	r := rand.New(rand.NewSource(seed))
+
+	rule := mahjong.GetDefaultRule()
+	rule.RiichiDeposit = 2000
+	rule.NotenPenalty = 6000
+	rule.NagashiManganLimit = mahjong.LimitHaneman
+	rule.IsTenpaiRenchan = false
+	rule.IsTobi = false
+	rule.ExtensionPolicy = mahjong.ExtensionNone
+	game := mahjong.NewMahjongGame(seed, rule)
+	posCalls := game.Reset(newPlayers(4), nil)
+	var flag = mahjong.EndTypeNone
+	for flag != mahjong.EndTypeGame {
+		posCall := make(map[mahjong.Wind]*mahjong.Call, 4)
+		for wind, calls := range posCalls {
+			posCall[wind] = calls[r.Intn(len(calls))]
+		}
+		posCalls, flag = game.Step(posCall)
+	}
+
+	// riichi sticks on the table are counted with the deposit
+	var totalPoints = game.NumRiichi * rule.RiichiDeposit
+	for _, player := range game.PosPlayer {
+		totalPoints += player.Points
+	}
+	if totalPoints != 4*rule.StartingPoints {
+		t.Fatalf("seed %d: total points %d, expect %d", seed, totalPoints, 4*rule.StartingPoints)
+	}
+
+	var windRound = mahjong.WindRound(-1)
+	var isDraw = false
+	var tenpaiWinds []mahjong.Wind
+	var isNagashi = false
+	for _, event := range game.GetAllGlobalEvents() {
+		switch e := event.(type) {
+		case *mahjong.EventGlobalInit:
+			// agari renchan, the seat moves after every draw
+			if isDraw && e.WindRound == windRound {
+				t.Fatalf("seed %d: dealer keeps the seat at %s after ryuu kyoku", seed, windRound)
+			}
+			windRound = e.WindRound
+			isDraw = false
+			tenpaiWinds = nil
+			isNagashi = false
+		case *mahjong.EventTenpaiEnd:
+			tenpaiWinds = append(tenpaiWinds, e.Who)
+		case *mahjong.EventNagashiMangan:
+			isNagashi = true
+		case *mahjong.EventRyuuKyoku:
+			isDraw = e.Reason == mahjong.RyuuKyokuNormal
+		case *mahjong.EventEnd:
+			if !isDraw || isNagashi || len(tenpaiWinds) == 0 || len(tenpaiWinds) == 4 {
+				continue
+			}
+			for _, wind := range tenpaiWinds {
+				if e.PointsChange[wind] != rule.NotenPenalty/len(tenpaiWinds) {
+					t.Fatalf("seed %d: tenpai %s gets %d points", seed, wind, e.PointsChange[wind])
+				}
+			}
+		}
+	}
+
+	// the noten penalty of sanma defaults to 2000
+	var sanmaRule mahjong.Rule
+	if err := json.Unmarshal([]byte(`{"is_sanma": true}`), &sanmaRule); err != nil {
+		t.Fatal(err)
+	}
+	if sanmaRule.NotenPenalty != 2000 || sanmaRule.RiichiDeposit != 1000 || !sanmaRule.IsTenpaiRenchan {
+		t.Fatalf("unexpected sanma payment rule %d %d %v", sanmaRule.NotenPenalty, sanmaRule.RiichiDeposit, sanmaRule.IsTenpaiRenchan)
+	}
+}
+
+func TestNotenPenaltyRule(t *testing.T) {
+	// the pool must split evenly among 1, 2 or 3 tenpai players, and among 1 or 2 in sanma
+	for _, c := range []struct {
+		rule  *mahjong.Rule
+		valid bool
+	}{
+		{mahjong.GetDefaultRule(), true},
+		{mahjong.GetDefaultSanmaRule(), true},
+		{&mahjong.Rule{NotenPenalty: 1000}, false},
+		{&mahjong.Rule{NotenPenalty: 1000, IsSanma: true}, true},
+		{&mahjong.Rule{NotenPenalty: 1500}, true},
+		{&mahjong.Rule{NotenPenalty: 1001, IsSanma: true}, false},
+	} {
+		err := c.rule.Validate()
+		if (err == nil) != c.valid || (err != nil && !errors.Is(err, mahjong.ErrInvalidRule)) {
+			t.Fatalf("noten penalty %d, sanma %v: %v", c.rule.NotenPenalty, c.rule.IsSanma, err)
+		}
+	}
+}
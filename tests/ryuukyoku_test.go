@@ -0,0 +1,366 @@
+package tests
+
+import (
+	"encoding/json"
+	"github.com/dnovikoff/tempai-core/score"
+	"github.com/dnovikoff/tempai-core/yaku"
+	"github.com/hphphp123321/mahjong-go/mahjong"
+	"math/rand"
+	"testing"
+)
+
+// prepareWall returns a shuffled wall of 136 tiles with the given tiles placed at the given indexes
+func prepareWall(r *rand.Rand, fixed map[int]mahjong.Tile) mahjong.Tiles {
+	var rest mahjong.Tiles
+	var used = make(map[mahjong.Tile]bool)
+	for _, tile := range fixed {
+		used[tile] = true
+	}
+	for tile := mahjong.Man1T1; tile <= mahjong.Chun4; tile++ {
+		if !used[tile] {
+			rest = append(rest, tile)
+		}
+	}
+	r.Shuffle(len(rest), func(i, j int) {
+		rest[i], rest[j] = rest[j], rest[i]
+	})
+	var wall = make(mahjong.Tiles, 0, 136)
+	for i := 0; i < 136; i++ {
+		if tile, ok := fixed[i]; ok {
+			wall = append(wall, tile)
+		} else {
+			wall = append(wall, rest[0])
+			rest = rest[1:]
+		}
+	}
+	return wall
+}
+
+func newPlayers(n int) []*mahjong.Player {
+	players := make([]*mahjong.Player, n)
+	for i := 0; i < n; i++ {
+		players[i] = mahjong.NewMahjongPlayer()
+	}
+	return players
+}
+
+func findRyuuKyoku(events mahjong.Events) mahjong.RyuuKyokuReason {
+	for _, event := range events {
+		if event.GetType() == mahjong.EventTypeRyuuKyoku {
+			return event.(*mahjong.EventRyuuKyoku).Reason
+		}
+	}
+	return mahjong.NoRyuuKyoku
+}
+
+func TestSuuFonRenDa(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		r := rand.New(rand.NewSource(rand.Int63()))
+		wall := prepareWall(r, map[int]mahjong.Tile{
+			0: mahjong.Ton1, 13: mahjong.Ton2, 26: mahjong.Ton3, 39: mahjong.Ton4,
+		})
+		rule := mahjong.GetDefaultRule()
+		rule.IsSuuFonRenda = enabled
+		game := mahjong.NewMahjongGame(r.Int63(), rule)
+		posCalls := game.Reset(newPlayers(4), wall)
+
+		// every player discards the east wind in the first turn
+		for i := 0; i < 4; i++ {
+			posCall := make(map[mahjong.Wind]*mahjong.Call)
+			for wind, calls := range posCalls {
+				for _, call := range calls {
+					if call.CallType == mahjong.Discard && call.CallTiles[0].Class() == mahjong.Ton {
+						posCall[wind] = call
+					}
+				}
+			}
+			if len(posCall) != 1 {
+				t.Fatal("can't discard east wind")
+			}
+			posCalls, _ = game.Step(posCall)
+			for len(posCalls) > 0 && len(posCalls[game.Position]) == 0 {
+				posCall = make(map[mahjong.Wind]*mahjong.Call)
+				for wind := range posCalls {
+					posCall[wind] = mahjong.SkipCall
+				}
+				posCalls, _ = game.Step(posCall)
+			}
+		}
+
+		reason := findRyuuKyoku(game.GetGlobalEvents())
+		if enabled && reason != mahjong.RyuuKyokuSuufonRenda {
+			t.Fatalf("expect suufon renda, got %s", reason)
+		}
+		if !enabled && reason != mahjong.NoRyuuKyoku {
+			t.Fatalf("expect no ryuu kyoku, got %s", reason)
+		}
+		if enabled {
+			cGame := mahjong.ReConstructGame(newPlayers(4), game.GetGlobalEvents())
+			if findRyuuKyoku(cGame.GetGlobalEvents()) != mahjong.RyuuKyokuSuufonRenda {
+				t.Fatal("reconstruct suufon renda failed")
+			}
+			// dealer keeps and honba plus one
+			game.Step(map[mahjong.Wind]*mahjong.Call{
+				mahjong.East: mahjong.NextCall, mahjong.South: mahjong.NextCall,
+				mahjong.West: mahjong.NextCall, mahjong.North: mahjong.NextCall,
+			})
+			if game.WindRound != mahjong.WindRoundEast1 || game.NumHonba != 1 {
+				t.Fatalf("expect East1 with 1 honba, got %s with %d honba", game.WindRound, game.NumHonba)
+			}
+		}
+	}
+}
+
+func TestKyuuShuKyuuHai(t *testing.T) {
+	terminals := mahjong.Tiles{
+		mahjong.Man1T1, mahjong.Man9T1, mahjong.Pin1T1, mahjong.Pin9T1, mahjong.Sou1T1,
+		mahjong.Sou9T1, mahjong.Ton1, mahjong.Nan1, mahjong.Shaa1,
+	}
+	for _, enabled := range []bool{true, false} {
+		r := rand.New(rand.NewSource(rand.Int63()))
+		fixed := make(map[int]mahjong.Tile)
+		for i, tile := range terminals {
+			fixed[i] = tile
+		}
+		rule := mahjong.GetDefaultRule()
+		rule.IsKyuuShuKyuuHai = enabled
+		game := mahjong.NewMahjongGame(r.Int63(), rule)
+		posCalls := game.Reset(newPlayers(4), prepareWall(r, fixed))
+
+		var kyuuShuCall *mahjong.Call
+		for _, call := range posCalls[mahjong.East] {
+			if call.CallType == mahjong.KyuuShuKyuuHai {
+				kyuuShuCall = call
+			}
+		}
+		if !enabled {
+			if kyuuShuCall != nil {
+				t.Fatal("kyuu shu kyuu hai offered when disabled")
+			}
+			continue
+		}
+		if kyuuShuCall == nil {
+			t.Fatal("kyuu shu kyuu hai not offered")
+		}
+		_, flag := game.Step(map[mahjong.Wind]*mahjong.Call{mahjong.East: kyuuShuCall})
+		if flag != mahjong.EndTypeRound {
+			t.Fatal("round not end after kyuu shu kyuu hai")
+		}
+		cGame := mahjong.ReConstructGame(newPlayers(4), game.GetGlobalEvents())
+		if findRyuuKyoku(cGame.GetGlobalEvents()) != mahjong.RyuuKyokuKyuuShuKyuuHai {
+			t.Fatal("reconstruct kyuu shu kyuu hai failed")
+		}
+	}
+}
+
+func TestAbortiveDraws(t *testing.T) {
+	var seed = rand.Int63()
+	r := rand.New(rand.NewSource(seed))
+	game := mahjong.NewMahjongGame(seed, nil)
+	players := newPlayers(4)
+	var reasons = make(map[mahjong.RyuuKyokuReason]int)
+
+	for i := 0; i < 20; i++ {
+		posCalls := game.Reset(players, nil)
+		flag := mahjong.EndTypeNone
+		for flag != mahjong.EndTypeGame {
+			posCall := make(map[mahjong.Wind]*mahjong.Call, 4)
+			for wind, calls := range posCalls {
+				posCall[wind] = calls[r.Intn(len(calls))]
+				// prefer the calls leading to abortive draws
+				for _, call := range calls {
+					switch call.CallType {
+					case mahjong.Riichi, mahjong.KyuuShuKyuuHai, mahjong.AnKan, mahjong.DaiMinKan, mahjong.ShouMinKan:
+						posCall[wind] = call
+					}
+				}
+			}
+			posCalls, flag = game.Step(posCall)
+			if flag == mahjong.EndTypeNone {
+				continue
+			}
+			events := game.GetGlobalEvents()
+			reason := findRyuuKyoku(events)
+			reasons[reason]++
+			cGame := mahjong.ReConstructGame(newPlayers(4), events)
+			if findRyuuKyoku(cGame.GetGlobalEvents()) != reason {
+				t.Fatalf("reconstruct %s failed", reason)
+			}
+		}
+	}
+	t.Log(reasons)
+}
+
+// threeWaitsWall returns a wall where east discards 4m first, and the hands of the players in waits wait on it
+func threeWaitsWall(r *rand.Rand, waits []mahjong.Wind) mahjong.Tiles {
+	// south, west and north all wait on 1m-4m with tanyao
+	hands := map[mahjong.Wind]mahjong.Tiles{
+		mahjong.South: {mahjong.Man2T1, mahjong.Man3T1, mahjong.Pin2T1, mahjong.Pin3T1, mahjong.Pin4T1, mahjong.Pin5T1, mahjong.Pin6T1,
+			mahjong.Pin7T1, mahjong.Sou2T1, mahjong.Sou3T1, mahjong.Sou4T1, mahjong.Sou8T1, mahjong.Sou8T2},
+		mahjong.West: {mahjong.Man2T2, mahjong.Man3T2, mahjong.Pin2T2, mahjong.Pin3T2, mahjong.Pin4T2, mahjong.Pin5T2, mahjong.Pin6T2,
+			mahjong.Pin7T2, mahjong.Sou2T2, mahjong.Sou3T2, mahjong.Sou4T2, mahjong.Sou8T3, mahjong.Sou8T4},
+		mahjong.North: {mahjong.Man2T3, mahjong.Man3T3, mahjong.Pin2T3, mahjong.Pin3T3, mahjong.Pin4T3, mahjong.Pin5T3, mahjong.Pin6T3,
+			mahjong.Pin7T3, mahjong.Sou2T3, mahjong.Sou3T3, mahjong.Sou4T3, mahjong.Sou6T1, mahjong.Sou6T2},
+	}
+	fixed := map[int]mahjong.Tile{0: mahjong.Man4T1}
+	for _, wind := range waits {
+		for j, tile := range hands[wind] {
+			fixed[13*int(wind)+j] = tile
+		}
+	}
+	return prepareWall(r, fixed)
+}
+
+// ronFirstDiscard east discards 4m, every player who can ron chooses ron
+func ronFirstDiscard(t *testing.T, game *mahjong.Game, posCalls map[mahjong.Wind]mahjong.Calls, numRon int) {
+	for _, call := range posCalls[mahjong.East] {
+		if call.CallType == mahjong.Discard && call.CallTiles[0] == mahjong.Man4T1 {
+			posCalls, _ = game.Step(map[mahjong.Wind]*mahjong.Call{mahjong.East: call})
+			break
+		}
+	}
+	posCall := make(map[mahjong.Wind]*mahjong.Call)
+	for wind, calls := range posCalls {
+		for _, call := range calls {
+			if call.CallType == mahjong.Ron {
+				posCall[wind] = call
+			}
+		}
+	}
+	if len(posCall) != numRon {
+		t.Fatalf("expect %d ron calls, got %d", numRon, len(posCall))
+	}
+	game.Step(posCall)
+}
+
+func findRonWinds(events mahjong.Events) []mahjong.Wind {
+	var winds []mahjong.Wind
+	for _, event := range events {
+		if event.GetType() == mahjong.EventTypeRon {
+			winds = append(winds, event.(*mahjong.EventRon).Who)
+		}
+	}
+	return winds
+}
+
+func TestSanChaHou(t *testing.T) {
+	var expects = map[mahjong.TripleRonPolicy][]mahjong.Wind{
+		mahjong.TripleRonAbort:    nil,
+		mahjong.TripleRonAllowed:  {mahjong.South, mahjong.West, mahjong.North},
+		mahjong.TripleRonHeadBump: {mahjong.South},
+	}
+	for policy, expect := range expects {
+		r := rand.New(rand.NewSource(rand.Int63()))
+		rule := mahjong.GetDefaultRule()
+		rule.TripleRonPolicy = policy
+		game := mahjong.NewMahjongGame(r.Int63(), rule)
+		posCalls := game.Reset(newPlayers(4), threeWaitsWall(r, []mahjong.Wind{mahjong.South, mahjong.West, mahjong.North}))
+		ronFirstDiscard(t, game, posCalls, 3)
+
+		events := game.GetGlobalEvents()
+		reason := findRyuuKyoku(events)
+		if policy == mahjong.TripleRonAbort && reason != mahjong.RyuuKyokuSanChaHou {
+			t.Fatalf("expect san cha hou, got %s", reason)
+		}
+		if policy != mahjong.TripleRonAbort && reason != mahjong.NoRyuuKyoku {
+			t.Fatalf("expect ron, got %s", reason)
+		}
+		if winds := findRonWinds(events); len(winds) != len(expect) || (len(winds) > 0 && winds[0] != expect[0]) {
+			t.Fatalf("%s: expect winners %v, got %v", policy, expect, winds)
+		}
+		cGame := mahjong.ReConstructGame(newPlayers(4), events)
+		cEvents := cGame.GetGlobalEvents()
+		if findRyuuKyoku(cEvents) != reason || len(findRonWinds(cEvents)) != len(expect) {
+			t.Fatalf("reconstruct %s failed", policy)
+		}
+	}
+}
+
+func TestSanChaHouDeprecated(t *testing.T) {
+	r := rand.New(rand.NewSource(rand.Int63()))
+	rule := mahjong.GetDefaultRule()
+	rule.IsSanChaHou = true
+	game := mahjong.NewMahjongGame(r.Int63(), rule)
+	posCalls := game.Reset(newPlayers(4), threeWaitsWall(r, []mahjong.Wind{mahjong.South, mahjong.West, mahjong.North}))
+	ronFirstDiscard(t, game, posCalls, 3)
+
+	events := game.GetGlobalEvents()
+	if reason := findRyuuKyoku(events); reason != mahjong.NoRyuuKyoku {
+		t.Fatalf("expect ron, got %s", reason)
+	}
+	if winds := findRonWinds(events); len(winds) != 3 {
+		t.Fatalf("expect 3 winners, got %v", winds)
+	}
+
+	var legacy mahjong.Rule
+	if err := json.Unmarshal([]byte(`{"is_san_cha_hou": true}`), &legacy); err != nil {
+		t.Fatal(err)
+	}
+	if !legacy.IsSanChaHou || legacy.TripleRonPolicy != mahjong.TripleRonAbort {
+		t.Fatalf("legacy rule: %v, %s", legacy.IsSanChaHou, legacy.TripleRonPolicy)
+	}
+}
+
+func TestHonbaReset(t *testing.T) {
+	r := rand.New(rand.NewSource(rand.Int63()))
+	game := mahjong.NewMahjongGame(r.Int63(), mahjong.GetDefaultRule())
+	posCalls := game.Reset(newPlayers(4), threeWaitsWall(r, []mahjong.Wind{mahjong.West}))
+	game.NumHonba = 2
+	ronFirstDiscard(t, game, posCalls, 1)
+	game.Step(map[mahjong.Wind]*mahjong.Call{
+		mahjong.East: mahjong.NextCall, mahjong.South: mahjong.NextCall,
+		mahjong.West: mahjong.NextCall, mahjong.North: mahjong.NextCall,
+	})
+
+	// the honba is cleared after a non-dealer win
+	if game.WindRound != mahjong.WindRoundEast2 || game.NumHonba != 0 {
+		t.Fatalf("after the non-dealer win: %s with %d honba", game.WindRound, game.NumHonba)
+	}
+}
+
+func TestHeadBump(t *testing.T) {
+	for _, isHeadBump := range []bool{false, true} {
+		r := rand.New(rand.NewSource(rand.Int63()))
+		rule := mahjong.GetDefaultRule()
+		rule.IsHeadBump = isHeadBump
+		game := mahjong.NewMahjongGame(r.Int63(), rule)
+		posCalls := game.Reset(newPlayers(4), threeWaitsWall(r, []mahjong.Wind{mahjong.West, mahjong.North}))
+		game.NumHonba = 2
+		game.NumRiichi = 1
+		ronFirstDiscard(t, game, posCalls, 2)
+
+		events := game.GetGlobalEvents()
+		winds := findRonWinds(events)
+		if (isHeadBump && len(winds) != 1) || (!isHeadBump && len(winds) != 2) || winds[0] != mahjong.West {
+			t.Fatalf("head bump %v: unexpected winners %v", isHeadBump, winds)
+		}
+		// west is nearer to east, gets the riichi stick and the honba
+		for _, event := range events {
+			e, ok := event.(*mahjong.EventRon)
+			if !ok {
+				continue
+			}
+			scoreResult := e.Result.ScoreResult
+			var honba, sticks = 0, 0
+			if e.Who == mahjong.West {
+				honba, sticks = 2, 1000
+			}
+			expect := score.GetScore(rule.ScoreRule(), yaku.HanPoints(scoreResult.Han), yaku.FuPoints(scoreResult.Fu), score.Honba(honba))
+			if scoreResult.PayRon != int(expect.PayRon) || game.PosPlayer[e.Who].Points != 25000+scoreResult.PayRon+sticks {
+				t.Fatalf("%s gets wrong points %d", e.Who, game.PosPlayer[e.Who].Points)
+			}
+		}
+		cGame := mahjong.ReConstructGame(newPlayers(4), events)
+		if len(findRonWinds(cGame.GetGlobalEvents())) != len(winds) {
+			t.Fatal("reconstruct head bump failed")
+		}
+		// honba is cleared after the non-dealer win
+		game.Step(map[mahjong.Wind]*mahjong.Call{
+			mahjong.East: mahjong.NextCall, mahjong.South: mahjong.NextCall,
+			mahjong.West: mahjong.NextCall, mahjong.North: mahjong.NextCall,
+		})
+		if game.WindRound != mahjong.WindRoundEast2 || game.NumHonba != 0 {
+			t.Fatalf("expect East2 with 0 honba, got %s with %d honba", game.WindRound, game.NumHonba)
+		}
+	}
+}
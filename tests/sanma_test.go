@@ -0,0 +1,150 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/hphphp123321/mahjong-go/mahjong"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestSanmaGame(t *testing.T) {
+	var seed = rand.Int63()
+	players := make([]*mahjong.Player, 3)
+	posCall := make(map[mahjong.Wind]*mahjong.Call, 3)
+	for i := 0; i < 3; i++ {
+		players[i] = mahjong.NewMahjongPlayer()
+	}
+	for _, tsumoLoss := range []bool{true, false} {
+		rule := mahjong.GetDefaultSanmaRule()
+		rule.IsSanmaTsumoLoss = tsumoLoss
+		game := mahjong.NewMahjongGame(seed, rule)
+		r := rand.New(rand.NewSource(seed))
+
+		for i := 0; i < 20; i++ {
+			posCalls := game.Reset(players, nil)
+			totalPoints := 0
+			for _, player := range players {
+				totalPoints += player.Points
+			}
+			flag := mahjong.EndTypeNone
+			for flag != mahjong.EndTypeGame {
+				for wind, calls := range posCalls {
+					if wind == mahjong.North {
+						t.Fatal("north seat in sanma")
+					}
+					posCall[wind] = calls[r.Intn(len(calls))]
+				}
+				posCalls, flag = game.Step(posCall)
+				posCall = make(map[mahjong.Wind]*mahjong.Call, 3)
+
+				if flag == mahjong.EndTypeNone {
+					continue
+				}
+				points := game.NumRiichi * 1000
+				for _, player := range players {
+					points += player.Points
+				}
+				if points != totalPoints {
+					t.Fatalf("points not conserved, %d != %d", points, totalPoints)
+				}
+			}
+			fmt.Println("sanma game end:", game.WindRound, players[0].Points, players[1].Points, players[2].Points)
+		}
+	}
+}
+
+func TestSanmaBoardState(t *testing.T) {
+	var seed = rand.Int63()
+	players := make([]*mahjong.Player, 3)
+	posCall := make(map[mahjong.Wind]*mahjong.Call, 3)
+	for i := 0; i < 3; i++ {
+		players[i] = mahjong.NewMahjongPlayer()
+	}
+	game := mahjong.NewMahjongGame(seed, mahjong.GetDefaultSanmaRule())
+
+	posCalls := game.Reset(players, nil)
+	var flag = mahjong.EndTypeNone
+	for flag != mahjong.EndTypeGame {
+		for wind, calls := range posCalls {
+			posCall[wind] = calls[rand.Intn(len(calls))]
+		}
+		for _, wind := range []mahjong.Wind{mahjong.East, mahjong.South, mahjong.West} {
+			if flag == mahjong.EndTypeRound {
+				break
+			}
+			boardState := game.GetPosBoardState(wind, posCalls[wind])
+			nb := mahjong.NewBoardState()
+			nb.DecodeEvents(game.GetPosEvents(wind, 0))
+			if !boardState.Equal(nb) {
+				t.Fatal("boardState not equal")
+			}
+		}
+		posCalls, flag = game.Step(posCall)
+		posCall = make(map[mahjong.Wind]*mahjong.Call, 3)
+	}
+}
+
+func TestSanmaReConstruct(t *testing.T) {
+	var seed = rand.Int63()
+	players := make([]*mahjong.Player, 3)
+	posCall := make(map[mahjong.Wind]*mahjong.Call, 3)
+	for i := 0; i < 3; i++ {
+		players[i] = mahjong.NewMahjongPlayer()
+	}
+	game := mahjong.NewMahjongGame(seed, mahjong.GetDefaultSanmaRule())
+	r := rand.New(rand.NewSource(seed))
+
+	posCalls := game.Reset(players, nil)
+	flag := mahjong.EndTypeNone
+	for flag != mahjong.EndTypeGame {
+		for wind, calls := range posCalls {
+			posCall[wind] = calls[r.Intn(len(calls))]
+		}
+		posCalls, flag = game.Step(posCall)
+		posCall = make(map[mahjong.Wind]*mahjong.Call, 3)
+
+		events := game.GetGlobalEvents()
+		if len(events) > 1 {
+			pSlice := make([]*mahjong.Player, 3)
+			for i := 0; i < 3; i++ {
+				pSlice[i] = mahjong.NewMahjongPlayer()
+			}
+			cGame := mahjong.ReConstructGame(pSlice, events)
+			if cGame.GetNumRemainTiles() != game.GetNumRemainTiles() {
+				t.Fatal("num remain tiles not equal")
+			}
+		}
+	}
+}
+
+func TestSanmaRuleJson(t *testing.T) {
+	// the fields not specified keep the default sanma rule
+	var rule mahjong.Rule
+	if err := json.Unmarshal([]byte(`{"is_sanma": true, "game_length": 8}`), &rule); err != nil {
+		t.Fatal(err)
+	}
+	expected := mahjong.GetDefaultSanmaRule()
+	if rule.StartingPoints != expected.StartingPoints || rule.TargetPoints != expected.TargetPoints {
+		t.Fatalf("sanma points %d %d, expected %d %d", rule.StartingPoints, rule.TargetPoints, expected.StartingPoints, expected.TargetPoints)
+	}
+	if !reflect.DeepEqual(rule.Uma, expected.Uma) {
+		t.Fatalf("sanma uma %v, expected %v", rule.Uma, expected.Uma)
+	}
+	if !rule.IsSanmaTsumoLoss || rule.NotenPenalty != expected.NotenPenalty {
+		t.Fatalf("sanma tsumo loss %v, noten penalty %d", rule.IsSanmaTsumoLoss, rule.NotenPenalty)
+	}
+	// the sanma rule survives a round trip
+	data, err := json.Marshal(expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded mahjong.Rule
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&decoded, expected) {
+		t.Fatalf("sanma rule changed after a round trip:\n%+v\n%+v", &decoded, expected)
+	}
+}
@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"errors"
+	"github.com/hphphp123321/go-common"
+	"github.com/hphphp123321/mahjong-go/mahjong"
+	"math/rand"
+	"testing"
+)
+
+func TestStepE(t *testing.T) {
+	var seed = rand.Int63()
+	r := rand.New(rand.NewSource(seed))
+	game := mahjong.NewMahjongGame(seed, nil)
+	posCalls := game.Reset(newPlayers(4), nil)
+
+	var flag = mahjong.EndTypeNone
+	var err error
+	for flag != mahjong.EndTypeGame {
+		posCall := make(map[mahjong.Wind]*mahjong.Call, 4)
+		for wind, calls := range posCalls {
+			posCall[wind] = calls[r.Intn(len(calls))]
+		}
+		numEvents := len(game.GetPosEvents(mahjong.East, 0))
+		boardState := game.GetPosBoardState(mahjong.East, posCalls[mahjong.East])
+
+		for wind := mahjong.East; wind <= mahjong.North; wind++ {
+			if _, ok := posCalls[wind]; ok {
+				// missing response
+				badCall := make(map[mahjong.Wind]*mahjong.Call, 4)
+				for w, call := range posCall {
+					if w != wind {
+						badCall[w] = call
+					}
+				}
+				if _, _, err = game.StepE(badCall); !errors.Is(err, mahjong.ErrMissingResponse) {
+					t.Fatalf("expect ErrMissingResponse, got %v", err)
+				}
+
+				// discard a tile from the wall
+				badCall[wind] = &mahjong.Call{
+					CallType:         mahjong.Discard,
+					CallTiles:        mahjong.Tiles{game.Tiles.DoraIndicators()[0], -1, -1, -1},
+					CallTilesFromWho: []mahjong.Wind{wind, mahjong.WindDummy, mahjong.WindDummy, mahjong.WindDummy},
+				}
+				if _, _, err = game.StepE(badCall); !errors.Is(err, mahjong.ErrIllegalCall) {
+					t.Fatalf("expect ErrIllegalCall, got %v", err)
+				}
+			} else {
+				badCall := make(map[mahjong.Wind]*mahjong.Call, 4)
+				for w, call := range posCall {
+					badCall[w] = call
+				}
+				badCall[wind] = mahjong.SkipCall
+				if _, _, err = game.StepE(badCall); !errors.Is(err, mahjong.ErrUnexpectedSeat) {
+					t.Fatalf("expect ErrUnexpectedSeat, got %v", err)
+				}
+			}
+		}
+
+		// the game is not changed by the rejected calls
+		nb := game.GetPosBoardState(mahjong.East, posCalls[mahjong.East])
+		if len(game.GetPosEvents(mahjong.East, 0)) != numEvents || !boardState.Equal(nb) {
+			t.Fatal("game changed by the rejected calls")
+		}
+
+		posCalls, flag, err = game.StepE(posCall)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestStepEChombo(t *testing.T) {
+	var seed = rand.Int63()
+	r := rand.New(rand.NewSource(seed))
+	rule := mahjong.GetDefaultRule()
+	rule.IsChombo = true
+	game := mahjong.NewMahjongGame(seed, rule)
+	posCalls := game.Reset(newPlayers(4), nil)
+
+	var flag = mahjong.EndTypeNone
+	var err error
+	var checked bool
+	for flag != mahjong.EndTypeGame {
+		posCall := make(map[mahjong.Wind]*mahjong.Call, 4)
+		for wind, calls := range posCalls {
+			posCall[wind] = calls[r.Intn(len(calls))]
+		}
+		if len(posCalls) > 1 {
+			// a false tsumo of one seat does not excuse the other seats from responding
+			winds := common.SortMapByKey(posCalls)
+			wind := winds[0]
+			falseTsumo := &mahjong.Call{
+				CallType:         mahjong.Tsumo,
+				CallTiles:        mahjong.Tiles{game.PosPlayer[wind].HandTiles[0], -1, -1, -1},
+				CallTilesFromWho: []mahjong.Wind{wind, mahjong.WindDummy, mahjong.WindDummy, mahjong.WindDummy},
+			}
+			numEvents := len(game.GetPosEvents(mahjong.East, 0))
+			if _, _, err = game.StepE(map[mahjong.Wind]*mahjong.Call{wind: falseTsumo}); !errors.Is(err, mahjong.ErrMissingResponse) {
+				t.Fatalf("expect ErrMissingResponse, got %v", err)
+			}
+			if len(game.GetPosEvents(mahjong.East, 0)) != numEvents {
+				t.Fatal("game changed by the rejected calls")
+			}
+			checked = true
+		}
+
+		posCalls, flag, err = game.StepE(posCall)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !checked {
+		t.Skip("no seats responded together")
+	}
+}
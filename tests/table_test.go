@@ -0,0 +1,159 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/hphphp123321/mahjong-go/mahjong"
+)
+
+// seatAgent chooses a random call and checks that it only sees the events and the board of its own wind
+type seatAgent struct {
+	r         *rand.Rand
+	wind      mahjong.Wind
+	numRounds int
+	failEvery int // every failEvery-th choice returns an error, 0 for never
+	numChoose int
+	err       error
+}
+
+func (a *seatAgent) Observe(events mahjong.Events) error {
+	for _, event := range events {
+		if start, ok := event.(*mahjong.EventStart); ok {
+			a.wind = start.InitWind
+			a.numRounds++
+		}
+	}
+	return nil
+}
+
+func (a *seatAgent) Choose(_ context.Context, calls mahjong.Calls, state *mahjong.BoardState) (*mahjong.Call, error) {
+	a.numChoose++
+	if state.PlayerWind != a.wind && a.err == nil {
+		a.err = fmt.Errorf("agent of %s is asked to choose for %s", a.wind, state.PlayerWind)
+	}
+	if a.failEvery > 0 && a.numChoose%a.failEvery == 0 {
+		return nil, errors.New("fail")
+	}
+	return calls[a.r.Intn(len(calls))], nil
+}
+
+// cancelAgent cancels the game at its n-th choice
+type cancelAgent struct {
+	n      int
+	cancel context.CancelFunc
+}
+
+func (a *cancelAgent) Observe(mahjong.Events) error {
+	return nil
+}
+
+func (a *cancelAgent) Choose(ctx context.Context, calls mahjong.Calls, state *mahjong.BoardState) (*mahjong.Call, error) {
+	if a.n--; a.n == 0 {
+		a.cancel()
+		<-ctx.Done()
+	}
+	return mahjong.DefaultCall(calls, state.DrawnTile), nil
+}
+
+// lateAgent cancels the game at its first choice and answers an invalid call once released, ignoring ctx
+type lateAgent struct {
+	cancel   context.CancelFunc
+	release  chan struct{}
+	returned chan struct{}
+}
+
+func (a *lateAgent) Observe(mahjong.Events) error {
+	return nil
+}
+
+func (a *lateAgent) Choose(context.Context, mahjong.Calls, *mahjong.BoardState) (*mahjong.Call, error) {
+	a.cancel()
+	<-a.release
+	defer close(a.returned)
+	return nil, nil
+}
+
+func TestTableLateAgent(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	late := &lateAgent{cancel: cancel, release: make(chan struct{}), returned: make(chan struct{})}
+	agents := []mahjong.Agent{late, &cancelAgent{n: -1}, &cancelAgent{n: -1}, &cancelAgent{n: -1}}
+	table, err := mahjong.NewTable(mahjong.NewMahjongGame(rand.Int63(), nil), agents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the game stops without the late agent and its answer is dropped
+	if _, err = table.Run(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled game returns %v", err)
+	}
+	close(late.release)
+	<-late.returned
+}
+
+func TestTable(t *testing.T) {
+	for _, rule := range []*mahjong.Rule{mahjong.GetDefaultRule(), mahjong.GetDefaultSanmaRule()} {
+		var seed = rand.Int63()
+		run := func() (*mahjong.TableResult, []*seatAgent) {
+			agents := make([]mahjong.Agent, rule.NumPlayers())
+			seatAgents := make([]*seatAgent, rule.NumPlayers())
+			for i := range agents {
+				seatAgents[i] = &seatAgent{r: rand.New(rand.NewSource(seed + int64(i))), failEvery: 7 * i}
+				agents[i] = seatAgents[i]
+			}
+			table, err := mahjong.NewTable(mahjong.NewMahjongGame(seed, rule), agents)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := table.Run(context.Background())
+			if err != nil {
+				t.Fatalf("seed %d: %v", seed, err)
+			}
+			return result, seatAgents
+		}
+
+		result, agents := run()
+		for i, agent := range agents {
+			if agent.err != nil {
+				t.Fatalf("seed %d: %v", seed, agent.err)
+			}
+			if agent.numRounds != result.NumRounds {
+				t.Fatalf("seed %d: agent %d observes %d rounds of %d", seed, i, agent.numRounds, result.NumRounds)
+			}
+			if result.Players[i].Seat != mahjong.Wind(i) {
+				t.Fatalf("seed %d: agent %d starts at %s", seed, i, result.Players[i].Seat)
+			}
+			if (result.Fallbacks[i] == 0) != (agent.failEvery == 0 || agent.numChoose < agent.failEvery) {
+				t.Fatalf("seed %d: agent %d has %d fallbacks", seed, i, result.Fallbacks[i])
+			}
+		}
+		if len(splitRounds(result.Events)) != result.NumRounds || result.NumSteps == 0 {
+			t.Fatalf("seed %d: %d rounds of events, %d rounds in %d steps", seed, len(splitRounds(result.Events)), result.NumRounds, result.NumSteps)
+		}
+		again, _ := run()
+		if !reflect.DeepEqual(again.Final, result.Final) || again.NumSteps != result.NumSteps {
+			t.Fatalf("seed %d: the game is not reproduced", seed)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		agents2 := make([]mahjong.Agent, rule.NumPlayers())
+		for i := range agents2 {
+			agents2[i] = &cancelAgent{n: 20, cancel: cancel}
+		}
+		table, err := mahjong.NewTable(mahjong.NewMahjongGame(seed, rule), agents2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = table.Run(ctx); !errors.Is(err, context.Canceled) {
+			t.Fatalf("seed %d: cancelled game returns %v", seed, err)
+		}
+		cancel()
+		if _, err = mahjong.NewTable(mahjong.NewMahjongGame(seed, rule), agents2[1:]); err == nil {
+			t.Fatalf("table of %d players is made for %d agents", rule.NumPlayers(), len(agents2)-1)
+		}
+	}
+}
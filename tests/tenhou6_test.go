@@ -0,0 +1,145 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hphphp123321/mahjong-go/mahjong"
+)
+
+func TestTenhou6(t *testing.T) {
+	names := []string{"Alice", "Bob", "天鳳"}
+	for i := 0; i < 6; i++ {
+		var seed = rand.Int63()
+		if i == 0 {
+			// a chan kan robbed from an added kan
+			seed = 7873460484229344891
+		}
+		r := rand.New(rand.NewSource(seed))
+		rule := mahjong.GetDefaultRule()
+		if i%3 == 2 {
+			rule = mahjong.GetDefaultSanmaRule()
+		}
+		if i%2 == 1 {
+			rule.HasAkaDora = false
+		}
+		game := mahjong.NewMahjongGame(seed, rule)
+		posCalls := game.Reset(newPlayers(rule.NumPlayers()), nil)
+		var flag = mahjong.EndTypeNone
+		for flag != mahjong.EndTypeGame {
+			posCall := make(map[mahjong.Wind]*mahjong.Call, 4)
+			for wind, calls := range posCalls {
+				posCall[wind] = calls[r.Intn(len(calls))]
+			}
+			posCalls, flag = game.Step(posCall)
+		}
+
+		data, err := mahjong.ExportTenhou6(game.GetAllGlobalEvents(), names)
+		if err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+		var raw map[string]interface{}
+		if err = json.Unmarshal(data, &raw); err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+		for _, key := range []string{"title", "name", "rule", "log", "sc"} {
+			if _, ok := raw[key]; !ok {
+				t.Fatalf("seed %d: no %s in the log", seed, key)
+			}
+		}
+
+		log, err := mahjong.ImportTenhou6(data)
+		if err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+		if !reflect.DeepEqual(log.Rule, rule) {
+			t.Fatalf("seed %d: rule of the log differs", seed)
+		}
+		if !reflect.DeepEqual(log.Names, append(names, "")[:rule.NumPlayers()]) {
+			t.Fatalf("seed %d: names %q", seed, log.Names)
+		}
+		if len(log.Rounds) != len(splitRounds(game.GetAllGlobalEvents())) {
+			t.Fatalf("seed %d: %d rounds imported", seed, len(log.Rounds))
+		}
+
+		// the rounds rebuilt from the log are exported the same, copies of a tile may change
+		var rebuilt mahjong.Events
+		for j, events := range log.Rounds {
+			rounds := splitRounds(mahjong.ReConstructGame(newPlayers(rule.NumPlayers()), events).GetAllGlobalEvents())
+			if rounds[0][len(rounds[0])-1].GetType() != mahjong.EventTypeEnd && j < len(log.Rounds)-1 {
+				t.Fatalf("seed %d: round %d is not rebuilt to the end", seed, j)
+			}
+			rebuilt = append(rebuilt, rounds[0]...)
+		}
+		again, err := mahjong.ExportTenhou6(rebuilt, names)
+		if err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+		if !bytes.Equal(again, data) {
+			t.Fatalf("seed %d: rebuilt log\n%s\nexpect\n%s", seed, again, data)
+		}
+	}
+
+	if _, err := mahjong.ImportTenhou6([]byte(`{"rule":{"disp":"般南喰赤","aka51":1,"aka52":2,"aka53":1},"log":[]}`)); err == nil {
+		t.Fatal("two red fives of a suit are accepted")
+	}
+	if _, err := mahjong.ImportTenhou6([]byte(`{"rule":{"disp":"般南喰赤","aka":1},"log":[[[0,0,0],[25000,25000,25000,25000],[11],[],[],[],[],["和了"]]]}`)); err == nil {
+		t.Fatal("round without hands is accepted")
+	}
+}
+
+// TestTenhou6Fixtures replays the json logs of tenhou.net/6 in testdata/tenhou6, see testdata/README.md,
+// every round but the last is rebuilt to the end and the rebuilt rounds are exported as in the log
+func TestTenhou6Fixtures(t *testing.T) {
+	files, _ := filepath.Glob(filepath.Join("testdata", "tenhou6", "*.json"))
+	if len(files) == 0 {
+		t.Skip("no tenhou.net/6 logs in testdata/tenhou6")
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		log, err := mahjong.ImportTenhou6(data)
+		if err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		if len(log.Rounds) == 0 {
+			t.Fatalf("%s: no round imported", file)
+		}
+		replayRounds(t, file, log.Rule, log.Rounds)
+		var rebuilt mahjong.Events
+		for j, events := range log.Rounds {
+			rounds := splitRounds(mahjong.ReConstructGame(newPlayers(log.Rule.NumPlayers()), events).GetAllGlobalEvents())
+			if rounds[0][len(rounds[0])-1].GetType() != mahjong.EventTypeEnd && j < len(log.Rounds)-1 {
+				t.Fatalf("%s: round %d is not rebuilt to the end", file, j)
+			}
+			rebuilt = append(rebuilt, rounds[0]...)
+		}
+
+		// the rounds of the rebuilt game are exported as in the file
+		again, err := mahjong.ExportTenhou6(rebuilt, log.Names)
+		if err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		var expect, got struct{ Log json.RawMessage }
+		if err = json.Unmarshal(data, &expect); err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		if err = json.Unmarshal(again, &got); err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		var compact bytes.Buffer
+		if err = json.Compact(&compact, expect.Log); err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		if !bytes.Equal(got.Log, compact.Bytes()) {
+			t.Fatalf("%s: exported rounds\n%s\nexpect\n%s", file, got.Log, compact.Bytes())
+		}
+	}
+}
@@ -0,0 +1,460 @@
+package tests
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"github.com/hphphp123321/mahjong-go/mahjong"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// mjlogWriter writes the global events of a game as a Tenhou mjlog
+type mjlogWriter struct {
+	b          strings.Builder
+	numPlayers int
+	dealer     int
+	allTiles   mahjong.Tiles
+	numDora    int
+	agari      []string
+	ryuukyoku  string
+	hands      map[int]mahjong.Tiles
+}
+
+func (w *mjlogWriter) seat(wind mahjong.Wind) int {
+	return (int(wind) + w.dealer) % w.numPlayers
+}
+
+func joinTiles(tiles mahjong.Tiles) string {
+	var s []string
+	for _, tile := range tiles {
+		s = append(s, fmt.Sprint(int(tile)))
+	}
+	return strings.Join(s, ",")
+}
+
+func (w *mjlogWriter) sc(pointsChange map[mahjong.Wind]int) string {
+	var s []string
+	for seat := 0; seat < 4; seat++ {
+		change := 0
+		if seat < w.numPlayers {
+			change = pointsChange[mahjong.Wind((seat-w.dealer+w.numPlayers)%w.numPlayers)] / 100
+		}
+		s = append(s, "0", fmt.Sprint(change))
+	}
+	return strings.Join(s, ",")
+}
+
+// meld encodes the m attribute of the N tag
+func (w *mjlogWriter) meld(who mahjong.Wind, call *mahjong.Call) int {
+	sorted := func(tiles mahjong.Tiles) mahjong.Tiles {
+		tiles = tiles.Copy()
+		sort.Sort(&tiles)
+		return tiles
+	}
+	index := func(tiles mahjong.Tiles, tile mahjong.Tile) int {
+		for i, t := range tiles {
+			if t == tile {
+				return i
+			}
+		}
+		panic("no tile")
+	}
+	kui := 0
+	if from := call.CallTilesFromWho[2]; from != who && call.CallType != mahjong.DaiMinKan {
+		kui = (w.seat(from) - w.seat(who) + w.numPlayers) % w.numPlayers
+	}
+	switch call.CallType {
+	case mahjong.Chi:
+		tiles := sorted(call.CallTiles[:3])
+		c := int(tiles[0].Class())
+		t := c/9*7 + c%9
+		return (t*3+index(tiles, call.CallTiles[2]))<<10 | int(tiles[0]%4)<<3 | int(tiles[1]%4)<<5 | int(tiles[2]%4)<<7 | 0x4 | kui
+	case mahjong.Pon, mahjong.ShouMinKan:
+		tiles := sorted(call.CallTiles[:3])
+		var other = 0
+		for copyIndex := 0; copyIndex < 4; copyIndex++ {
+			if tiles[0].Class().To4Tiles()[copyIndex] != tiles[0] && tiles[0].Class().To4Tiles()[copyIndex] != tiles[1] &&
+				tiles[0].Class().To4Tiles()[copyIndex] != tiles[2] {
+				other = copyIndex
+			}
+		}
+		flag := 0x8
+		if call.CallType == mahjong.ShouMinKan {
+			flag = 0x10
+		}
+		return (int(tiles[0].Class())*3+index(tiles, call.CallTiles[2]))<<9 | other<<5 | flag | kui
+	case mahjong.DaiMinKan:
+		return int(call.CallTiles[3])<<8 | (w.seat(call.CallTilesFromWho[3])-w.seat(who)+w.numPlayers)%w.numPlayers
+	default:
+		return int(call.CallTiles[0]) << 8
+	}
+}
+
+func (w *mjlogWriter) write(events mahjong.Events) {
+	for _, event := range events {
+		switch e := event.(type) {
+		case *mahjong.EventGlobalInit:
+			w.dealer = int(e.WindRound-mahjong.WindRoundEast1) % 4
+			w.allTiles = e.AllTiles
+			w.numDora = 1
+			w.hands = make(map[int]mahjong.Tiles)
+			var ten []string
+			var hai string
+			for seat := 0; seat < 4; seat++ {
+				if seat >= w.numPlayers {
+					ten = append(ten, "0")
+					hai += fmt.Sprintf(` hai%d=""`, seat)
+					continue
+				}
+				wind := (seat - w.dealer + w.numPlayers) % w.numPlayers
+				ten = append(ten, fmt.Sprint(e.InitPoints[mahjong.Wind(wind)]/100))
+				hai += fmt.Sprintf(` hai%d="%s"`, seat, joinTiles(e.AllTiles[13*wind:13*wind+13]))
+			}
+			fmt.Fprintf(&w.b, `<INIT seed="%d,%d,%d,1,2,%d" ten="%s" oya="%d"%s/>`, e.WindRound-mahjong.WindRoundEast1, e.NumHonba, e.NumRiichi,
+				e.AllTiles[len(e.AllTiles)-6], strings.Join(ten, ","), w.dealer, hai)
+		case *mahjong.EventGet:
+			fmt.Fprintf(&w.b, "<%c%d/>", "TUVW"[w.seat(e.Who)], e.Tile)
+		case *mahjong.EventDiscard:
+			fmt.Fprintf(&w.b, "<%c%d/>", "DEFG"[w.seat(e.Who)], e.Tile)
+		case *mahjong.EventTsumoGiri:
+			fmt.Fprintf(&w.b, "<%c%d/>", "DEFG"[w.seat(e.Who)], e.Tile)
+		case *mahjong.EventChi:
+			fmt.Fprintf(&w.b, `<N who="%d" m="%d"/>`, w.seat(e.Who), w.meld(e.Who, e.Call))
+		case *mahjong.EventPon:
+			fmt.Fprintf(&w.b, `<N who="%d" m="%d"/>`, w.seat(e.Who), w.meld(e.Who, e.Call))
+		case *mahjong.EventDaiMinKan:
+			fmt.Fprintf(&w.b, `<N who="%d" m="%d"/>`, w.seat(e.Who), w.meld(e.Who, e.Call))
+		case *mahjong.EventShouMinKan:
+			fmt.Fprintf(&w.b, `<N who="%d" m="%d"/>`, w.seat(e.Who), w.meld(e.Who, e.Call))
+		case *mahjong.EventAnKan:
+			fmt.Fprintf(&w.b, `<N who="%d" m="%d"/>`, w.seat(e.Who), w.meld(e.Who, e.Call))
+		case *mahjong.EventKita:
+			fmt.Fprintf(&w.b, `<N who="%d" m="%d"/>`, w.seat(e.Who), int(e.Tile)<<8|0x20)
+		case *mahjong.EventRiichi:
+			fmt.Fprintf(&w.b, `<REACH who="%d" step="%d"/>`, w.seat(e.Who), e.Step)
+		case *mahjong.EventNewIndicator:
+			fmt.Fprintf(&w.b, `<DORA hai="%d"/>`, e.Tile)
+			w.numDora++
+		case *mahjong.EventRon:
+			w.addAgari(e.Who, e.FromWho, append(e.HandTiles.Copy(), e.WinTile), e.WinTile)
+		case *mahjong.EventChanKan:
+			w.addAgari(e.Who, e.FromWho, append(e.HandTiles.Copy(), e.WinTile), e.WinTile)
+		case *mahjong.EventTsumo:
+			w.addAgari(e.Who, e.Who, e.HandTiles, e.WinTile)
+		case *mahjong.EventNagashiMangan:
+			w.ryuukyoku = ` type="nm"`
+		case *mahjong.EventTenpaiEnd:
+			w.hands[w.seat(e.Who)] = e.HandTiles
+		case *mahjong.EventRyuuKyoku:
+			switch e.Reason {
+			case mahjong.RyuuKyokuKyuuShuKyuuHai:
+				w.ryuukyoku = ` type="yao9"`
+				w.hands[w.seat(e.Who)] = e.HandTiles
+			case mahjong.RyuuKyokuSuuChaRiichi:
+				w.ryuukyoku = ` type="reach4"`
+			case mahjong.RyuuKyokuSuuKaiKan:
+				w.ryuukyoku = ` type="kan4"`
+			case mahjong.RyuuKyokuSuufonRenda:
+				w.ryuukyoku = ` type="kaze4"`
+			case mahjong.RyuuKyokuSanChaHou:
+				w.ryuukyoku = ` type="ron3"`
+			default:
+				if w.ryuukyoku == "" {
+					w.ryuukyoku = " "
+				}
+			}
+		case *mahjong.EventEnd:
+			for i, agari := range w.agari {
+				sc := w.sc(nil)
+				if i == len(w.agari)-1 {
+					sc = w.sc(e.PointsChange)
+				}
+				fmt.Fprintf(&w.b, `<AGARI %s sc="%s"/>`, agari, sc)
+			}
+			if w.ryuukyoku != "" {
+				var hai string
+				for seat, hand := range w.hands {
+					hai += fmt.Sprintf(` hai%d="%s"`, seat, joinTiles(hand))
+				}
+				fmt.Fprintf(&w.b, `<RYUUKYOKU%s%s sc="%s"/>`, strings.TrimRight(w.ryuukyoku, " "), hai, w.sc(e.PointsChange))
+			}
+			w.agari, w.ryuukyoku = nil, ""
+		}
+	}
+}
+
+func (w *mjlogWriter) addAgari(who, from mahjong.Wind, hand mahjong.Tiles, winTile mahjong.Tile) {
+	hand = hand.Copy()
+	sort.Sort(&hand)
+	var dora, ura mahjong.Tiles
+	for i := 0; i < w.numDora; i++ {
+		dora = append(dora, w.allTiles[len(w.allTiles)-6-2*i])
+		ura = append(ura, w.allTiles[len(w.allTiles)-5-2*i])
+	}
+	w.agari = append(w.agari, fmt.Sprintf(`who="%d" fromWho="%d" hai="%s" machi="%d" doraHai="%s" doraHaiUra="%s"`,
+		w.seat(who), w.seat(from), joinTiles(hand), winTile, joinTiles(dora), joinTiles(ura)))
+}
+
+// writeMjlog writes a game played by the engine as a Tenhou mjlog
+func writeMjlog(game *mahjong.Game, gameType int) string {
+	w := &mjlogWriter{numPlayers: game.Rule.NumPlayers()}
+	fmt.Fprintf(&w.b, `<mjloggm ver="2.3"><SHUFFLE seed="" ref=""/><GO type="%d" lobby="0"/>`, gameType)
+	w.b.WriteString(`<UN n0="%41%6C%69%63%65" n1="Bob" n2="%E5%A4%A9%E9%B3%B3" n3="" dan="" rate="" sx=""/><TAIKYOKU oya="0"/>`)
+	w.write(game.GetAllGlobalEvents())
+	w.b.WriteString(`</mjloggm>`)
+	return w.b.String()
+}
+
+// withoutTenpaiInfos returns a copy of the event without the tenpai infos, they count the tiles hidden in the wall
+func withoutTenpaiInfos(event mahjong.Event) mahjong.Event {
+	switch e := event.(type) {
+	case *mahjong.EventGet:
+		c := *e
+		c.TenpaiInfos = nil
+		return &c
+	case *mahjong.EventDiscard:
+		c := *e
+		c.TenpaiInfo = nil
+		return &c
+	case *mahjong.EventTsumoGiri:
+		c := *e
+		c.TenpaiInfo = nil
+		return &c
+	case *mahjong.EventChi:
+		c := *e
+		c.TenpaiInfos = nil
+		return &c
+	case *mahjong.EventPon:
+		c := *e
+		c.TenpaiInfos = nil
+		return &c
+	}
+	return event
+}
+
+// splitRounds splits the global events of a game by EventGlobalInit
+func splitRounds(events mahjong.Events) []mahjong.Events {
+	var rounds []mahjong.Events
+	for _, event := range events {
+		if event.GetType() == mahjong.EventTypeGlobalInit {
+			rounds = append(rounds, nil)
+		}
+		rounds[len(rounds)-1] = append(rounds[len(rounds)-1], event)
+	}
+	return rounds
+}
+
+func TestImportMjlog(t *testing.T) {
+	for i := 0; i < 6; i++ {
+		var seed = rand.Int63()
+		r := rand.New(rand.NewSource(seed))
+		rule, gameType := mahjong.GetDefaultRule(), 0xa9
+		if i%3 == 2 {
+			rule, gameType = mahjong.GetDefaultSanmaRule(), 0xb9
+		}
+		game := mahjong.NewMahjongGame(seed, rule)
+		posCalls := game.Reset(newPlayers(rule.NumPlayers()), nil)
+		var flag = mahjong.EndTypeNone
+		for flag != mahjong.EndTypeGame {
+			posCall := make(map[mahjong.Wind]*mahjong.Call, 4)
+			for wind, calls := range posCalls {
+				posCall[wind] = calls[r.Intn(len(calls))]
+			}
+			posCalls, flag = game.Step(posCall)
+		}
+
+		data := []byte(writeMjlog(game, gameType))
+		if i%2 == 1 {
+			var buf bytes.Buffer
+			zw := gzip.NewWriter(&buf)
+			zw.Write(data)
+			zw.Close()
+			data = buf.Bytes()
+		}
+		log, err := mahjong.ImportMjlog(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+		if !reflect.DeepEqual(log.Rule, rule) {
+			t.Fatalf("seed %d: rule of the log differs", seed)
+		}
+		if !reflect.DeepEqual(log.Names, []string{"Alice", "Bob", "天鳳", ""}) {
+			t.Fatalf("seed %d: names %q", seed, log.Names)
+		}
+
+		// every round is rebuilt with the same events
+		rounds := splitRounds(game.GetAllGlobalEvents())
+		if len(log.Rounds) != len(rounds) {
+			t.Fatalf("seed %d: %d rounds imported, expect %d", seed, len(log.Rounds), len(rounds))
+		}
+		for j, events := range log.Rounds {
+			rebuilt := mahjong.ReConstructGame(newPlayers(rule.NumPlayers()), events)
+			expect := rounds[j]
+			got := splitRounds(rebuilt.GetAllGlobalEvents())[0]
+			if len(got) < len(expect)-1 {
+				t.Fatalf("seed %d: round %d rebuilt %d events, expect %d", seed, j, len(got), len(expect))
+			}
+			for k := 1; k < len(expect); k++ {
+				if expect[k].GetType() == mahjong.EventTypeGameEnd || expect[k].GetType() == mahjong.EventTypeAgariYame {
+					continue
+				}
+				if !reflect.DeepEqual(withoutTenpaiInfos(got[k]), withoutTenpaiInfos(expect[k])) {
+					t.Fatalf("seed %d: round %d event %d is %+v, expect %+v", seed, j, k, got[k], expect[k])
+				}
+			}
+		}
+	}
+
+	if _, err := mahjong.ImportMjlog(strings.NewReader(`<mjloggm><GO type="1536"/></mjloggm>`)); err == nil {
+		t.Fatal("unsupported rule flags are accepted")
+	}
+	if _, err := mahjong.ImportMjlog(strings.NewReader(`<mjloggm><GO type="169"/><T12/></mjloggm>`)); err == nil {
+		t.Fatal("draw before INIT is accepted")
+	}
+}
+
+// withoutResult copies a win event without its result and a tenpai hand with the tiles sorted,
+// the results are not in the logs and the logs may reveal the hands in another order
+func withoutResult(event mahjong.Event) mahjong.Event {
+	switch e := event.(type) {
+	case *mahjong.EventRon:
+		c := *e
+		c.Result = nil
+		return &c
+	case *mahjong.EventTsumo:
+		c := *e
+		c.Result = nil
+		return &c
+	case *mahjong.EventTenpaiEnd:
+		c := *e
+		c.HandTiles = append(mahjong.Tiles(nil), e.HandTiles...)
+		sort.Sort(&c.HandTiles)
+		return &c
+	}
+	return event
+}
+
+// replayRounds rebuilds every round of an imported log and checks the events of the rebuilt rounds,
+// the new dora indicators are compared apart since the logs may reveal them later than the game
+func replayRounds(t *testing.T, name string, rule *mahjong.Rule, rounds []mahjong.Events) {
+	split := func(events mahjong.Events) (mahjong.Events, mahjong.Tiles) {
+		var others mahjong.Events
+		var indicators mahjong.Tiles
+		for _, event := range events[1:] {
+			switch e := event.(type) {
+			case *mahjong.EventNewIndicator:
+				indicators = append(indicators, e.Tile)
+			case *mahjong.EventGameEnd, *mahjong.EventAgariYame:
+			default:
+				others = append(others, event)
+			}
+		}
+		return others, indicators
+	}
+	for j, events := range rounds {
+		rebuilt := mahjong.ReConstructGame(newPlayers(rule.NumPlayers()), events)
+		got, gotIndicators := split(splitRounds(rebuilt.GetAllGlobalEvents())[0])
+		expect, indicators := split(events)
+		if len(got) < len(expect) {
+			t.Fatalf("%s: round %d rebuilt %d events, expect %d", name, j, len(got), len(expect))
+		}
+		for k := range expect {
+			// the points of the wins are checked by EventEnd
+			if !reflect.DeepEqual(withoutResult(withoutTenpaiInfos(got[k])), withoutResult(withoutTenpaiInfos(expect[k]))) {
+				t.Fatalf("%s: round %d event %d is %+v, expect %+v", name, j, k, got[k], expect[k])
+			}
+		}
+		if !reflect.DeepEqual(gotIndicators, indicators) {
+			t.Fatalf("%s: round %d dora indicators %s, expect %s", name, j, gotIndicators, indicators)
+		}
+	}
+}
+
+// checkTenhouSeed checks the hands, dora indicator and first draw of every round against the walls of the seed
+func checkTenhouSeed(t *testing.T, name string, log *mahjong.TenhouLog) {
+	generator, err := mahjong.NewTenhouWallGenerator(log.Seed)
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	for j, events := range log.Rounds {
+		init := events[0].(*mahjong.EventGlobalInit)
+		yama, _ := generator.Yama(init.NumGame)
+		wall := mahjong.TenhouYamaToWall(yama)
+		for wind := mahjong.East; wind <= mahjong.North; wind++ {
+			hand := append(mahjong.Tiles(nil), init.AllTiles[13*int(wind):13*int(wind)+13]...)
+			expect := append(mahjong.Tiles(nil), wall[13*int(wind):13*int(wind)+13]...)
+			sort.Sort(&hand)
+			sort.Sort(&expect)
+			if !reflect.DeepEqual(hand, expect) {
+				t.Fatalf("%s: round %d hand of %s is %s, the seed deals %s", name, j, wind, hand, expect)
+			}
+		}
+		if indicator := init.AllTiles[len(init.AllTiles)-6]; indicator != yama[5] {
+			t.Fatalf("%s: round %d dora indicator %s, the seed deals %s", name, j, indicator, yama[5])
+		}
+		if init.AllTiles[52] != wall[52] {
+			t.Fatalf("%s: round %d first draw %s, the seed deals %s", name, j, init.AllTiles[52], wall[52])
+		}
+	}
+}
+
+func TestMjlogSeed(t *testing.T) {
+	var seed = rand.Int63()
+	r := rand.New(rand.NewSource(seed))
+	generator, _ := mahjong.NewTenhouWallGenerator(tenhouReferences[0].seed)
+	game := mahjong.NewMahjongGame(seed, nil, mahjong.WithWallGenerator(generator))
+	posCalls := game.Reset(newPlayers(4), nil)
+	var flag = mahjong.EndTypeNone
+	for flag != mahjong.EndTypeGame {
+		posCall := make(map[mahjong.Wind]*mahjong.Call, 4)
+		for wind, calls := range posCalls {
+			posCall[wind] = calls[r.Intn(len(calls))]
+		}
+		posCalls, flag = game.Step(posCall)
+	}
+
+	data := strings.Replace(writeMjlog(game, 0xa9), `seed=""`, `seed="`+mahjong.TenhouSeedPrefix+tenhouReferences[0].seed+`"`, 1)
+	log, err := mahjong.ImportMjlog(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("seed %d: %v", seed, err)
+	}
+	if log.Seed != mahjong.TenhouSeedPrefix+tenhouReferences[0].seed {
+		t.Fatalf("seed %d: seed of the log is %q", seed, log.Seed)
+	}
+	checkTenhouSeed(t, fmt.Sprintf("seed %d", seed), log)
+	replayRounds(t, fmt.Sprintf("seed %d", seed), log.Rule, log.Rounds)
+
+	log, _ = mahjong.ImportMjlog(strings.NewReader(`<mjloggm><GO type="169"/></mjloggm>`))
+	if log.Seed != "" {
+		t.Fatalf("seed %q of a log without SHUFFLE", log.Seed)
+	}
+}
+
+// TestMjlogFixtures replays the Tenhou logs in testdata/mjlog, see testdata/README.md,
+// the hands of the logs with a seed are checked against the walls of the seed
+func TestMjlogFixtures(t *testing.T) {
+	files, _ := filepath.Glob(filepath.Join("testdata", "mjlog", "*"))
+	if len(files) == 0 {
+		t.Skip("no Tenhou logs in testdata/mjlog")
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		log, err := mahjong.ImportMjlog(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		replayRounds(t, file, log.Rule, log.Rounds)
+		if log.Seed != "" && !log.Rule.IsSanma {
+			checkTenhouSeed(t, file, log)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package tests
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/hex"
+	"encoding/xml"
+	"github.com/hphphp123321/go-common"
+	"github.com/hphphp123321/mahjong-go/mahjong"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type tenhouReferenceWall struct {
+	numGame int
+	dice    [2]int
+	yama    string // hex of the tile ids, yama[0] first
+}
+
+// walls of two arbitrary seeds printed by testdata/tenhou/reference_walls.py, a separate implementation
+// of the shuffle of Tenhou on Python's random (mt19937ar init_by_array) and hashlib.sha512, e.g.
+//
+//	python3 testdata/tenhou/reference_walls.py SEED 0 1 7
+var tenhouReferences = []struct {
+	seed  string
+	walls []tenhouReferenceWall
+}{
+	{
+		seed: "" +
+			"IpHYzcMQQR5+wnN4pmHJNRh8B+TVY26bw8QAsnJEuM06l/Ea5lEHBQamigLw4WGvN/hsuQeHOMNw8H6NO1g7rTjCdfNK7QVq1uqO" +
+			"7KQZL6H+udxLHr5V5bj5toDv92yB1OmrME1Ilvnhf9jwgWSW2gh6Pr7MZ2qqLF2M4bPGrLxfFnCpghvHKYXXZF59uwd4C0602fud" +
+			"l5RkpSsrgDr7A8UziuvcjDtng1jz2JNadehEqIyb9boBYsjb0vTi8L2DzyGEx480bfMOe95dkY0z8IFpfNBbalgAiYqfyZxUdZkH" +
+			"zTqiLYyVLtwXzI3M2dHuQQjX8awSFd4EcwPBwUc/RBzMny9YShEqKEGH8yuoRaW2S3SzUn95HQZPYldryzBCG0DmuoL6NfebbtH5" +
+			"BTkEZSUJuPUpcrSBrW2L1Tj6+aHMsYRzOYamB2Wsk81SqKFtD7xMIPc24AxOEtsTT+rwTL4oapBAIQKP4NkJl9E39uaRdSvT3t75" +
+			"x7SfgglgM1gZNJKs5W6XMX4a8KpjS4F/BFOc32bmSAQoM9tTz/yQyCJWbTZErBjWYe6MWOrh1q+IfMT8iDwQuQoVIisq6Yk2RMJV" +
+			"mYHXQV5WVx1KPN7xmsf0t+N9IpSNxRpSCmgSYd39ySXUIFcdnZbI7WATkow5kBTzRF3kS5CI7B115UYbyQvTSwOdqwMXaR3T4soK" +
+			"MD3J/JZrKR1zKq49KL7YGm/p9mDO+Iro0UuMQLZ6UBk1plEKBgLJ++xLuZhRc2RQZhAQ6VH4mfh0HEA3yJ7H+uSK3rB4qVtCLoo1" +
+			"TjI/XBTRRxb7wHIXppOkVvA6Y/dOClMvUcrYlOTrTT5VGYuclM6YFz44Bc4+ZhJEjd4SuhMFogJKwMpbfnjc2ycZgMfLUxOC86os" +
+			"LcYm/CTS3VFOG7WD1euaSyDkNCSL6bgIx1DS55/NrOiN1/G//LA0LUxuiSgMttyqP0DHEK72cs5ujECKcNmJdAJl1lYrQnwGy6Xu" +
+			"avmSBA+xWpQjlyAjQvvURmWQZiycFjt8AS2HUYDkputw7q+juzk9UH6vevQ5tmlWj5zouuqnRvilOAzrEsOCpeBeKILEyuI0T0yx" +
+			"TNmNXyqzs7x2mBXbH+Wb9YOSYC0nQG038ZG4wcgNfq5kt6NZYoPYKou6/gqG+xfOQaAZRLzpFfX5I/jGndf3qK+zFHHZ7D342WHw" +
+			"zeduZSroU3Agn+h89TYebpmIaOgeqUtHP2C/jwH1MIdwlAUHoPmbPtVCNCxIJYozRU+VwUDVrnLK3M/a+SuLW31r2x/ENZLhYjRI" +
+			"zxvnzgYekb8Di0v3rMK5+aYiE4Bfks5Pb4CtW8KHUgAfcbdzWU6KZlbIu66Sfhyl6mBhNI4A/keimbjhvdS6gjL87HaZ1YRo7762" +
+			"/PxOsytznquHMlyGAK1jlG34Z1bcn5X5u7Pl978Rfvy+P6P3pkqhBWi4oSeix+9lyEXYLcQS0MaaAlnpQ8y1ad+vi00mdtVCfCt3" +
+			"ggtFghm+l2wRWhGocQUqgbXyKbAXZqKwRppNNYc1POJVRBETstTphahed4KOvAwrTKe8tv/QjkVbnL07ZI9mLHvKQt2cVLc4Qvac" +
+			"tD7YqQfa5t6fZ1Htbu7CP8lEMBKguyre+ZRxlOnuuiWb8kN1hikjxyPkt3BcT8BmPR23NLeuThEbOmVSfu0Z9C8LDs+YBePAN64I" +
+			"frSH0Ln245xxV6nWRh6csSwYOGY7fnNgwCv5OzzRSHaMlGM2c7dCVH+XHOg2/hQLA8wB23pR42LZlEnrMmYo4dPCpSbL6QcDYyXg" +
+			"qooOkGFBIRR2ptdN5wMJiQ+G1yEK7kbHHm4XMAd/oyG+R6/R2DGpcmNUoUT4QqSiPj4Plu/JlyxZbZqyj6OF+A/nWoxpiTO24Yls" +
+			"66kRtkS+nLj4wBJALfkYJg/rNNpt2gsNoxfp0IN4gF4Z/FAKIIgIcaog5WXDtebhcga8hkUXQMxTFU0I3GIOu0JQvCFCy2HOHdut" +
+			"TRhs1z6AjjRU7FaCyGT05ZV7GiGn0HKG/I+42NWUs4WJB+X61P1KvigzXmOFUxhoWCCTEAtM0MymiFBqTFFaRVO/v4WAAoYfJlHq" +
+			"ulPIU5IRc/pHenTpXe29+GHQ4+wU7JTNDiIMhn2T2v5AyD6zkr9WXP3xzKReZ052mfpXiIEqByVArziQIugcL8Rp8LqeDM8Z+ouu" +
+			"RLYbNEIRoZKGpBTaEsvZN6TWLILcbgWXXubYfLXOSDjkM5l+3ebkPGxzrF2L6fEwzHu5EtDX//lBaDMCv4jFYYPgfBNnneGCy5SV" +
+			"bApa2fx1ATD1TLKwpAGKHtJNg+P+v1D4xoulkv6NSIZpivDR7fSEaJqhlE5zTSGBcZYjjMX6+SlAogL+bLypkAlea2ZI76jlwKsE" +
+			"5hfsF9gBYkR2RcvIX6K/2nvEVmN0zR17WiVqJQT+LNBCXtsglslJ8/9pQvCDSb1rsEZuVcbpfDe31H3z+Ga3bBcQITT3Jjq6BhpA" +
+			"J3rG8xlmprkv1QAWbZz0/g2MN4hsWAzypvjtGryNrWvVq70e/kOvRy16zsu02wzJNq2kFt1jH6tyS66Cf+dkHZvaehsmYp3nszMq" +
+			"hUFqvuPv/YlJ3n6i5c+L6TbJwp9W3HwaAsH9uqhY7eL3tUQOiqBwTMLn1xk6gkZFtD9pJSFBMWiPoZnn9Q6I1ZuCJvJpRUd6sk5E" +
+			"fTZ/Xpl4PVYtm8IuveGUsXOIJg6BU4ewIqXCz/3kNlCffnpUHiDjI7JBORaiidSzDJAsrx05kDOAkajiTmxTAcYF0k7SnTgVvjlH" +
+			"rqD83FdEmbiEYQUfVFgjHUDmxSSukgpYExe5/xpMUT9EhwxcBxQj7GZf77ijsD0YrVRGAoPjUvXyHFrszcqkudcgm+3eRWcXrZOe" +
+			"uYd5kGuJ72RN5TihTYwiDZmCHCw9N+VvRosFQIlF8YdDeSBntRq+XxGn+otci47YzbmBr5QHnk5yriEnE+mUJK3h0zd7183ZxFVd" +
+			"40ooJ9nLYdVwZx76mSVFS6qvzKOa8wKJ8wLr0KQhYb+P8eEZdQfHbpmtbEbuXmhnm3YNGXjHCaW0sgDPCtQcliOHgsNbjUXI+5Ho" +
+			"96dbzXnRsj7tzp89G4/zW98oHcYK6rRQbOG6WECooP7lxeoOnW9qYFtLwdBXcMyzPKKchCQOV6wd5IMsi6SgfORXwbUf+ZUFeuU1" +
+			"YqHV8yxltzoZP1X5+FSoPsitdr54Xn6mxam57zFucGaKHpJ87UTWICYDYGobzAanE/AudcRgqoDM0EnqJyf4htMb8kEEdmXPorS8" +
+			"yuk6ibJk/QGLzT/7bOgoqS1XqT0T",
+		walls: []tenhouReferenceWall{
+			{numGame: 0, dice: [2]int{4, 5},
+				yama: "0a4e3f431706831526384647123b7787166b497924716958221a807b2574723001810f1f855e78183c6f445c704166107c147d20641b543e2d48575060313d526e2e685963860d020e515a1e1c750019366c335b2f112a2c84616a5f82407e4a0b287f35030c4b5539674f2356374c215d4d2b736d6562053a274234077604087a45530929321d13"},
+			{numGame: 1, dice: [2]int{0, 1},
+				yama: "591c192c5a422123368343075c662a7c3f7d3068170c085657611d0d840b2f406460584d4509281a7622482925671f701b534f69181e10155d396f130f3a6d865244023105475f85807b244b2d3b3c0a8720340400756e515472465b49126241386b6533114e792e1671630e273d35017e2b6c145526783e734c4a32773774505e0306827a7f6a81"},
+			{numGame: 7, dice: [2]int{5, 0},
+				yama: "1a652b6d84485b223512317f4d3d052c0d77343e28323a701f4b14083c5a575f516b6806614f584029825d11766956877d6413370e7a8015735507300a105301450279781d661609435e17496242263b2f0c4a857e54210b181e6e83387c1b2d8171635c037b6a504772740f4c0023674e2a393f256f27416c522e20756046043324364419861c59"},
+		},
+	},
+	{
+		seed: "" +
+			"9Nzy2Q4XFVzVK7zPq9pOQJs2mwmUrij/bqNkzbnc/oLzX4vvcYBE5gneB1137lHoYWzk4oYqjy08OwYtUywigoJc/4Osjy7+5HLL" +
+			"aryG6OjDXcqXWlz72/ZyKfTBZre9dqeHP31H7H+Ag9TLWqnidObndlmRueuOuXR8qDjwU9Cz1Srg6J1Exel6T031zLTUgY+Egaad" +
+			"lmhPuzV9g13vr5/hE8jSV7kC6NAw/74bD5OnDEWXOq7g6hvBhSLaRD7TNfHhD2zlt8IIDlxcLD+sBhUd9BEGCrrrBV9BINDvKLwv" +
+			"hbEAYpYLy/0/JvgJAVjwnaC+vxxJVn0HTnKNxJq9C+ZDwWbcn7QnefU5F6mvUNYaBnLJ3/IghJXHZHyDUyTf9VdCQ5v4a6cEs471" +
+			"I6sOQAghKSsYdKI7gur7te8I/j87tnESQBSXOp/KzJ+1XEGvbEeGwAEmCWJoKRyDuRY9GhkFLsA7GjcGhat2dE+JpGE2r+jC9jW6" +
+			"zm9sggWUlw3ha+2GlC7rGKnNel0EhPXrHpxdSrD77l9OBN+vaRkaTjLXxqzTBM9zD2mjfHY145adEgFIBl9O77kTOMF9MR2SX2S3" +
+			"diPAWGXjH0EfHxSd2VWkZPU2sRoGnqh4xgu5tH9KW/V1JMxfRHuG3Xq49LrNa+591q5LZTsofZhCjG2yrbLrFZW60ZMYElst/4sl" +
+			"zmrlEcwW6eWu/c+lCSD5S2M7tavirlRwLIZJHCeK/PP4wWwYVIQ/t4NBK+UodvK0PGff/lvIw5K6JXdwuAfPmGLhvC5kgg17RmdA" +
+			"tbvvabSleFz2jFS2vumoFMLR2rk5iJ8wZ9CqYfrhouoCUHaGtujid6Yt0BgEZ/E3upGbYuc34vgZY9COxM0zRr7rlpQxfc2cIwKc" +
+			"rW97QIOQLHe2NPj3whJZAOd8iNarqBDBl3zsrOxVdUTggHUH/BSdwVgswsDwyP9nQaygydXduCINKX9h/nasSycCSI539wBdCInZ" +
+			"YZBxNN6tTn+mInuwibbqTRNC01BNVaXyy0+npGSE1+sXgqI1ZJiH2dkmzIGgFk4KO/N1jzuFRw/1HBys0clh2102UVsTVXVcKn9x" +
+			"3kp25SLrt3H9ozftRVMoGeM8eDDArdhfL1sjyyI7RM6MomBmztK/V0fguO2YgJSwu/JSvmbAtt6076HA+Pq3Soifo6sSXk5leyxC" +
+			"9eZacHoW5OwvUPVhIPcHGlkqWxPq4OK7wqZvAopSPNPVmGOKSHij5iZcUDPtfxj4JMg0VEAka1xAFlcwP7U9upwLVvBfpcScD9wk" +
+			"LdkQbnHHRSFShZPYHValxLWc9WU6/w1kxHl9nt9Ri9afmBeWgomq/H79ZtexdCtpYoZzC+Abc/6XIB7v666A6iwTZE51zLUCQBur" +
+			"WTgsBiVtq/sXVvjRpncM2+Z5PRB7I48HI7KAig8MM4vrAdLQhVauh+A9I199ACGKHT8bdzbMDZ03oGFWn6XbZOfvt4aBx+qsKYIa" +
+			"1dEmoPU1LGAzTFduJG0hZVDMTM8Zjxl5RUiHw338RzprsyOyjKgaB5qNwDM2MWSUCqUjoAa+Q7O4eYoMvMXc0jnVJJlQCbAy/xsj" +
+			"orGL6i/D9Bf6r+bedqFK9jUo0lOz0UbZ54SREWnlaqq4CHRMqNwforO7RfHpBDZrVUKJumSXhr/s5DNuxSCxK8nfct90+FhieZxB" +
+			"nTCUeXExwXjZklZPEitemqDceDjEnaeok+Igru9O3jWJ1tJMGQPJ3gcy/VAOUYhByrmqV9JwEmt41bYESJSTITYmKZvFYLgQopZy" +
+			"6kelFX56zcnqPCaQTNn22+06M5607N9Vl52zZIZoPKQ2jw9Cqj8j8Z+7ZNNvHvh0ZGV59mFINz05DoiG0eIXmvKLrAANY7VuZjuD" +
+			"RRldglyF8ch+lBG0dbyzOEcGB3oKIaYkNFI9iQydJaVL8cMapI6KFq2rIm+1IwhPgqlEeQzxjlrDV6/sGJtcG5vIWV3L8vCiRs56" +
+			"5UiCmfcmBgtXb9aiAlms+f+ItwzcqROwioGcxW9taz3KLimdCwSXwve401qsLksF594K9z+Qye/lOGcQ6Fwc7ZjpET47jDAaAbFn" +
+			"FIDaR5SmOA2GhP+H62dt6sggJ20hdbxfDfn1ki6E0XDyb5nz0szepXIpfpj9INpZJQVAtC/6JqNpkaFA+3F4djBsb0TGOFrAofMI" +
+			"0mWf1QdtTe7XBvnojHqRQq9FP3e2dF2F2J526Kk/jtiIKHVJ8+/BXGscga/lPr/4p6j1Yx1umXafhXQX9r331WNznfHEtrK9XOnM" +
+			"jlkqJTuq5aYsaHN/tfy4zixoQ+lQkupnTKW2yPjlQ6tQqgJmlwo0dBkdAvHWXFKaUbtnLb/V7FPIFIaZemae6zxx4hiaBFgHTX4j" +
+			"trUNAlRneqblnfgB4Huj6JM0Ob2cUypVTcfUZJGZuXx3w0cVgDaSXT1c2F/7zS7lPYrPprifOTWWdzxl3ESWNILcKQBnxHm9XKYu" +
+			"rTK6s5vsLth8nQHKuyLqNf60NwCfFXTHozK9L0VlndYFAlzgHU4Jkl14YR8T9XYtJM7h4cV06fWkEEQl3nuJE+nO5Ya1zEpJ6gaM" +
+			"4481E2rpIC+WTnbsMtsKslZ1yA8n+zranlmiTs2zpRvZMCfQ7DgHUh5FGl/GEoHP69r59fO3nSJZIfVsu74yT43CBcAEV6MmuBLc" +
+			"i8AMI/i8fvtqrVt7qUtMEOOgJva9HQHSF+UkWuPYtifyTMaRreHsiSEW/e46krnHvjrOVf0X4C+qbzVppqgJ1fhVbex/p49Y7C+G" +
+			"9B6o3Aogr/NgJWvEw7w5okSdirPnHxaD1ZK4ni0yqfqfrLUSifJQIYA8PK10rGd0G1A7DFgbZASQHb/PQlqNPVZsOgsjKvUh7rrU" +
+			"8CtkJv4apUzDbXYBHGwAsBjVCDBvADHL22HRUNgnLETaRM9BkTv3hPLQT0+Qan973ddixEv8ZCynzmtLypN1hmyTsFKKEpACQe1p" +
+			"4pV1uRbk1tmAiniGo0co9zde0Bu8OPs2biD4guMwgWPk0CndEk7QdCTAgxe8X60vOOXJjQpvjwjlZIvDTTv/Kbpmyjjl3e8zkvvT" +
+			"OoDlQY1Hm0MoBqWe9WaD6bcNDsh9MY1S8Eq79eelC3dVL3IMNV5B+b5xQ6yA4UAMuFICaqc5LmKwrnsibLH/SxKo+SzDyg1r5dUQ" +
+			"94iUJwUHXTb5rqaQwol5bvCr6MacsJZUGtYBEzQFuIfY9cXU44YHJwdLQR4EpJYF7VnmRhtjqueroqgvaQI8N+Ip5563b4veCJNG" +
+			"Gj2VPgw30as8rtMtn+bQACv0H5Uz",
+		walls: []tenhouReferenceWall{
+			{numGame: 0, dice: [2]int{2, 2},
+				yama: "87248411410c2f2a1e08767a46866e453a3d1a281d1f274c214f295719835c64477b0e79336f015935497306187f2b045a15614b3c7037551032305d387400204a56444368781b12395425665b7d145e620d1771606c03750b806b31360a3f2e6a69073e7c580542851c13404d34817e2d6d2682500f524867225309653b1677722363024e2c515f"},
+			{numGame: 1, dice: [2]int{5, 2},
+				yama: "00311a3b78861507344b230a2a065b723628803024841b3c601e87402f4c053f825e71013d080c8158421c75320f5a1f10736e047c0d0b856c500e6337536f413e627e206945616a295f028316526670272e13033957642b7d6b442255565c3311477654144e434f6d5d6517517b49353a2174791248467a09671d592d4d4a18257f772619682c38"},
+			{numGame: 7, dice: [2]int{1, 1},
+				yama: "834f8516843c1a5a274113246186736a176c2259773215497d14356072811b582c1e434744096b2a1165214d66312d4806197608375c2e7b0a56707936828040632b3a68544a2f3e0d0c5b0e5e7a0523621d2530673b385045696d284b524e557157642078514c8742533d7c26346e02395f0b751f7f5d3f29331203180f740010011c467e04076f"},
+		},
+	},
+}
+
+func TestTenhouWall(t *testing.T) {
+	for _, reference := range tenhouReferences {
+		generator, err := mahjong.NewTenhouWallGenerator(mahjong.TenhouSeedPrefix + reference.seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, ref := range reference.walls {
+			yama, dice := generator.Yama(ref.numGame)
+			ids := make([]byte, len(yama))
+			for i, tile := range yama {
+				ids[i] = byte(tile)
+			}
+			if hex.EncodeToString(ids) != ref.yama {
+				t.Fatalf("wall of round %d differs from the reference", ref.numGame)
+			}
+			if dice != ref.dice {
+				t.Fatalf("dice of round %d: %v, expect %v", ref.numGame, dice, ref.dice)
+			}
+		}
+	}
+
+	// the game deals the hands, dora indicator and draws of Tenhou
+	var seed = rand.Int63()
+	r := rand.New(rand.NewSource(seed))
+	generator, _ := mahjong.NewTenhouWallGenerator(tenhouReferences[0].seed)
+	game := mahjong.NewMahjongGame(seed, nil, mahjong.WithWallGenerator(generator))
+	posCalls := game.Reset(newPlayers(4), nil)
+	var flag = mahjong.EndTypeNone
+	for flag == mahjong.EndTypeNone {
+		posCall := make(map[mahjong.Wind]*mahjong.Call, 4)
+		for wind, calls := range posCalls {
+			posCall[wind] = calls[r.Intn(len(calls))]
+		}
+		posCalls, flag = game.Step(posCall)
+	}
+	yama, _ := generator.Yama(0)
+	if !common.SliceEqual(game.GetGlobalEvents()[0].(*mahjong.EventGlobalInit).AllTiles, mahjong.TenhouYamaToWall(yama)) {
+		t.Fatalf("seed %d: wall of the game is not the tenhou wall", seed)
+	}
+	for wind := mahjong.East; wind <= mahjong.North; wind++ {
+		hand := tenhouDeal(yama, wind)
+		start := game.GetPosEvents(wind, 0)[0].(*mahjong.EventStart)
+		initTiles := start.InitTiles.Copy()
+		sort.Sort(&initTiles)
+		if !common.SliceEqual(initTiles, hand) {
+			t.Fatalf("seed %d: hand of %s is %s, expect %s", seed, wind, initTiles, hand)
+		}
+		if start.InitDoraIndicator != yama[5] {
+			t.Fatalf("seed %d: dora indicator %s, expect %s", seed, start.InitDoraIndicator, yama[5])
+		}
+	}
+	for _, event := range game.GetPosEvents(mahjong.East, 0) {
+		if e, ok := event.(*mahjong.EventGet); ok {
+			if e.Tile != yama[83] {
+				t.Fatalf("seed %d: first draw %s, expect %s", seed, e.Tile, yama[83])
+			}
+			break
+		}
+	}
+
+	if _, err := mahjong.NewTenhouWallGenerator("AAAA"); err == nil {
+		t.Fatal("short seed is accepted")
+	}
+	if _, err := generator.Wall(mahjong.WallInfo{IsSanma: true}, nil); err == nil {
+		t.Fatal("three-player tenhou wall is accepted")
+	}
+}
+
+// tenhouDeal deals the hand of the seat wind from the yama of Tenhou, 4, 4, 4 and 1 tiles from the dealer
+func tenhouDeal(yama mahjong.Tiles, wind mahjong.Wind) mahjong.Tiles {
+	var hand mahjong.Tiles
+	for i := 0; i < 3; i++ {
+		p := 135 - 16*i - 4*int(wind)
+		hand = append(hand, yama[p], yama[p-1], yama[p-2], yama[p-3])
+	}
+	hand = append(hand, yama[87-int(wind)])
+	sort.Sort(&hand)
+	return hand
+}
+
+// TestTenhouWallFixtures checks the shuffle against the Tenhou logs in testdata/mjlog,
+// the hands, dice and dora indicator of every INIT tag are dealt by the seed of the SHUFFLE tag
+func TestTenhouWallFixtures(t *testing.T) {
+	files, _ := filepath.Glob(filepath.Join("testdata", "mjlog", "*"))
+	if len(files) == 0 {
+		t.Skip("no Tenhou logs in testdata/mjlog")
+	}
+	var numChecked int
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(data) > 2 && data[0] == 0x1f && data[1] == 0x8b {
+			zr, err := gzip.NewReader(bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if data, err = io.ReadAll(zr); err != nil {
+				t.Fatal(err)
+			}
+		}
+		var generator *mahjong.TenhouWallGenerator
+		var numGame int
+		decoder := xml.NewDecoder(bytes.NewReader(data))
+		for {
+			token, err := decoder.Token()
+			if err != nil {
+				break
+			}
+			element, ok := token.(xml.StartElement)
+			if !ok {
+				continue
+			}
+			attrs := make(map[string]string)
+			for _, attr := range element.Attr {
+				attrs[attr.Name.Local] = attr.Value
+			}
+			switch element.Name.Local {
+			case "SHUFFLE":
+				if generator, err = mahjong.NewTenhouWallGenerator(attrs["seed"]); err != nil {
+					t.Logf("%s: %v, the wall is not checked", file, err)
+				}
+			case "GO":
+				// three-player walls are not dealt by the generator
+				if gameType, _ := strconv.Atoi(attrs["type"]); gameType&0x10 != 0 {
+					generator = nil
+				}
+			case "INIT":
+				if generator == nil {
+					continue
+				}
+				yama, dice := generator.Yama(numGame)
+				var seed []int
+				for _, v := range strings.Split(attrs["seed"], ",") {
+					n, _ := strconv.Atoi(v)
+					seed = append(seed, n)
+				}
+				if dice != [2]int{seed[3], seed[4]} {
+					t.Fatalf("%s: round %d dice %v, expect %v", file, numGame, dice, seed[3:5])
+				}
+				if mahjong.Tile(seed[5]) != yama[5] {
+					t.Fatalf("%s: round %d dora indicator %s, expect %s", file, numGame, yama[5], mahjong.Tile(seed[5]))
+				}
+				dealer, _ := strconv.Atoi(attrs["oya"])
+				for seat := 0; seat < 4; seat++ {
+					var hand mahjong.Tiles
+					for _, v := range strings.Split(attrs["hai"+strconv.Itoa(seat)], ",") {
+						n, _ := strconv.Atoi(v)
+						hand = append(hand, mahjong.Tile(n))
+					}
+					sort.Sort(&hand)
+					if dealt := tenhouDeal(yama, mahjong.Wind((seat-dealer+4)%4)); !common.SliceEqual(dealt, hand) {
+						t.Fatalf("%s: round %d hand of seat %d is %s, expect %s", file, numGame, seat, dealt, hand)
+					}
+				}
+				numGame++
+				numChecked++
+			}
+		}
+	}
+	if numChecked == 0 {
+		t.Skip("no four-player Tenhou logs with a seed in testdata/mjlog")
+	}
+}
@@ -0,0 +1,103 @@
+package tests
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/hphphp123321/mahjong-go/mahjong"
+)
+
+func TestTenpaiWaits(t *testing.T) {
+	// 234m34m456p678s99p waits on 25m with pinfu, the dealer wins with no honba
+	hand := mahjong.Tiles{
+		mahjong.Man2T1, mahjong.Man3T1, mahjong.Man4T1, mahjong.Man3T2, mahjong.Man4T2, mahjong.Pin4T1, mahjong.Pin5T2,
+		mahjong.Pin6T1, mahjong.Sou6T1, mahjong.Sou7T1, mahjong.Sou8T1, mahjong.Pin9T1, mahjong.Pin9T2,
+	}
+	for _, isUra := range []bool{false, true} {
+		r := rand.New(rand.NewSource(rand.Int63()))
+		rule := mahjong.GetDefaultRule()
+		rule.IsUra = isUra
+		var game *mahjong.Game
+		for game == nil {
+			game = mahjong.NewMahjongGame(r.Int63(), rule)
+			game.Reset(newPlayers(4), nil)
+			// no dora in the hand or the waits
+			dora := mahjong.IndicatorToDora(game.Tiles.DoraIndicators()[0]).Class()
+			if dora == mahjong.Man2 || dora == mahjong.Man5 || (&hand).Classes().Count(dora) > 0 {
+				game = nil
+			}
+		}
+		player := game.PosPlayer[mahjong.East].Copy()
+		player.HandTiles = hand.Copy()
+
+		if waits := mahjong.GetTenpaiResult(game, player, mahjong.Man5).Waits; waits != nil {
+			t.Fatalf("waits of a game without wait values: %d", len(waits))
+		}
+		waits := mahjong.GetWaitValues(game, player, mahjong.Man5)
+		if len(waits) != 4 {
+			t.Fatalf("waits of 5m: %d", len(waits))
+		}
+		for _, wait := range waits {
+			ron, tsumo := 1500, 2100
+			riichiRon, riichiTsumo := 2900.0, 3900.0
+			if wait.Tile == mahjong.Man5T1 {
+				// the red five is one more han
+				ron, tsumo = 2900, 3900
+				riichiRon, riichiTsumo = 5800, 7800
+			}
+			if wait.Ron != ron || wait.Tsumo != tsumo {
+				t.Fatalf("dama values of %s: %d, %d", wait.Tile, wait.Ron, wait.Tsumo)
+			}
+			if (!isUra && (wait.RiichiRon != riichiRon || wait.RiichiTsumo != riichiTsumo)) ||
+				(isUra && (wait.RiichiRon <= riichiRon || wait.RiichiTsumo <= riichiTsumo)) {
+				t.Fatalf("riichi values of %s with ura %v: %f, %f", wait.Tile, isUra, wait.RiichiRon, wait.RiichiTsumo)
+			}
+		}
+
+		// an open hand of 234m34m456p99p with chun has no riichi
+		player.HandTiles = append(hand[:8:8], mahjong.Pin9T1, mahjong.Pin9T2)
+		player.Melds = mahjong.Calls{{
+			CallType:         mahjong.Pon,
+			CallTiles:        mahjong.Tiles{mahjong.Chun1, mahjong.Chun2, mahjong.Chun3, mahjong.TileDummy},
+			CallTilesFromWho: []mahjong.Wind{mahjong.East, mahjong.East, mahjong.North, mahjong.WindDummy},
+		}}
+		for _, wait := range mahjong.GetWaitValues(game, player, mahjong.Man5) {
+			if wait.Ron == 0 || wait.RiichiRon != 0 || wait.RiichiTsumo != 0 {
+				t.Fatalf("values of the open hand on %s: %+v", wait.Tile, wait)
+			}
+		}
+	}
+}
+
+func TestWaitValuesOfEvents(t *testing.T) {
+	for _, hasWaitValues := range []bool{false, true} {
+		var seed = rand.Int63()
+		r := rand.New(rand.NewSource(seed))
+		var options []mahjong.GameOption
+		if hasWaitValues {
+			options = append(options, mahjong.WithWaitValues())
+		}
+		game := mahjong.NewMahjongGame(seed, nil, options...)
+		posCalls := game.Reset(newPlayers(4), threeWaitsWall(r, []mahjong.Wind{mahjong.South}))
+
+		// south is tenpai from the start and discards the tiles drawn
+		var info *mahjong.TenpaiInfo
+		for info == nil {
+			posCall := make(map[mahjong.Wind]*mahjong.Call, 4)
+			for wind, calls := range posCalls {
+				posCall[wind] = mahjong.DefaultCall(calls, game.GetPosBoardState(wind, calls).DrawnTile)
+			}
+			posCalls, _ = game.Step(posCall)
+			for _, event := range game.GetPosEvents(mahjong.South, 0) {
+				if e, ok := event.(*mahjong.EventTsumoGiri); ok && e.Who == mahjong.South {
+					info = e.TenpaiInfo
+				}
+			}
+		}
+		for class, result := range info.TileClassesTenpaiResult {
+			if (len(result.Waits) > 0) != hasWaitValues {
+				t.Fatalf("seed %d: %d wait values of %s, game with wait values %v", seed, len(result.Waits), class, hasWaitValues)
+			}
+		}
+	}
+}
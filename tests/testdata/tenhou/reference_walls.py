@@ -0,0 +1,55 @@
+#!/usr/bin/env python3
+"""Print the walls of Tenhou shuffled by a seed, the references of TestTenhouWall.
+
+The shuffle follows the one published by Tenhou: mt19937ar seeded by init_by_array
+with the 624 little-endian words of the seed, 288 words drawn for every round and
+hashed by SHA-512 in blocks of 128 bytes, the wall shuffled by the hashes and the
+dice taken from the 136th and 137th words. The Mersenne Twister is the one of
+Python's random module, independent of the Go implementation under test.
+
+usage: reference_walls.py SEED ROUND...
+prints the round, the dice from 0 to 5 and the hex of the tile ids of the yama
+"""
+import base64
+import hashlib
+import random
+import struct
+import sys
+
+PREFIX = "mt19937ar-sha512-n288-base64,"
+
+
+def yama(seed, num_game):
+    if seed.startswith(PREFIX):
+        seed = seed[len(PREFIX):]
+    data = base64.b64decode(seed)
+    if len(data) != 624 * 4:
+        raise ValueError("seed of %d bytes, expect %d" % (len(data), 624 * 4))
+    # random.seed of an int is init_by_array of its little-endian words,
+    # a zero high word would shorten the key
+    if struct.unpack("<624I", data)[-1] == 0:
+        raise ValueError("the last word of the seed is zero")
+    r = random.Random()
+    r.seed(int.from_bytes(data, "little"))
+    for _ in range(num_game * 288):
+        r.getrandbits(32)
+    src = struct.pack("<288I", *[r.getrandbits(32) for _ in range(288)])
+    rnd = []
+    for i in range(0, len(src), 128):
+        rnd += struct.unpack("<16I", hashlib.sha512(src[i:i + 128]).digest())
+    tiles = list(range(136))
+    for i in range(135):
+        j = i + rnd[i] % (136 - i)
+        tiles[i], tiles[j] = tiles[j], tiles[i]
+    return tiles, (rnd[135] % 6, rnd[136] % 6)
+
+
+def main():
+    seed = sys.argv[1]
+    for num_game in map(int, sys.argv[2:]):
+        tiles, dice = yama(seed, num_game)
+        print(num_game, dice[0], dice[1], bytes(tiles).hex())
+
+
+if __name__ == "__main__":
+    main()
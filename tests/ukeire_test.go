@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/hphphp123321/mahjong-go/mahjong"
+)
+
+func TestUkeireInfos(t *testing.T) {
+	// 233m1122334p111s, 1 shanten accepting 1234m123456p
+	hand := mahjong.Tiles{
+		mahjong.Man2T1, mahjong.Man3T1, mahjong.Man3T2, mahjong.Pin1T1, mahjong.Pin1T2, mahjong.Pin2T1, mahjong.Pin2T2,
+		mahjong.Pin3T1, mahjong.Pin3T2, mahjong.Pin4T1, mahjong.Sou1T1, mahjong.Sou1T2, mahjong.Sou1T3,
+	}
+	info := mahjong.GetUkeireInfo(hand, nil, nil)
+	if info.Discard != mahjong.TileDummy || info.Shanten != 1 || info.Ukeire != 30 || len(info.Accepts) != 10 || info.TwoStep == 0 {
+		t.Fatalf("ukeire of the hand: %+v", info)
+	}
+	if info.Accepts[mahjong.Man1] != 4 || info.Accepts[mahjong.Man3] != 2 || info.Accepts[mahjong.Pin1] != 2 {
+		t.Fatalf("accepts of the hand: %v", info.Accepts)
+	}
+
+	infos := mahjong.GetUkeireInfos(append(hand.Copy(), mahjong.Chun1), mahjong.Calls{}, nil)
+	if len(infos) != 14 || infos[0].Discard != mahjong.Chun1 || !reflect.DeepEqual(infos[0].Accepts, info.Accepts) || infos[0].TwoStep != info.TwoStep {
+		t.Fatalf("best discard: %+v", infos[0])
+	}
+	for i := 1; i < len(infos); i++ {
+		prev, cur := infos[i-1], infos[i]
+		if prev.Shanten > cur.Shanten || (prev.Shanten == cur.Shanten && (prev.Ukeire < cur.Ukeire ||
+			(prev.Ukeire == cur.Ukeire && prev.TwoStep < cur.TwoStep))) {
+			t.Fatalf("discard %s ranked before %s", prev.Discard, cur.Discard)
+		}
+	}
+
+	// 123m78m456p234s77z tenpai on 69m, one 6m discarded and one 9m as the dora indicator
+	tenpai := mahjong.Tiles{
+		mahjong.Man1T1, mahjong.Man2T1, mahjong.Man3T1, mahjong.Man7T1, mahjong.Man8T1, mahjong.Pin4T1, mahjong.Pin5T1,
+		mahjong.Pin6T1, mahjong.Sou2T1, mahjong.Sou3T1, mahjong.Sou4T1, mahjong.Chun1, mahjong.Chun2,
+	}
+	state := mahjong.NewBoardState()
+	state.HandTiles = tenpai
+	state.DoraIndicators = mahjong.Tiles{mahjong.Man9T1}
+	state.PlayerStates[mahjong.South].DiscardTiles = mahjong.Tiles{mahjong.Man6T2}
+	info = mahjong.GetUkeireInfo(tenpai, nil, state)
+	waits := mahjong.GetTenpaiSlice(tenpai, mahjong.Calls{})
+	if info.Shanten != 0 || info.Ukeire != 6 || info.TwoStep != 0 || len(info.Accepts) != len(waits) {
+		t.Fatalf("ukeire of the tenpai hand: %+v", info)
+	}
+	for _, class := range waits {
+		if info.Accepts[class] != 3 {
+			t.Fatalf("accepts of the tenpai hand: %v", info.Accepts)
+		}
+	}
+
+	data, err := json.Marshal(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var info2 mahjong.UkeireInfo
+	if err = json.Unmarshal(data, &info2); err != nil || !reflect.DeepEqual(*info, info2) {
+		t.Fatalf("json of the ukeire info: %s, %v", data, err)
+	}
+}
@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"errors"
+	"github.com/hphphp123321/mahjong-go/mahjong"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestUndo(t *testing.T) {
+	var seed = rand.Int63()
+	r := rand.New(rand.NewSource(seed))
+	const depth = 8
+
+	type record struct {
+		posCalls    map[mahjong.Wind]mahjong.Calls
+		boardStates map[mahjong.Wind]*mahjong.BoardState
+		numEvents   int
+	}
+	snapshot := func(game *mahjong.Game, posCalls map[mahjong.Wind]mahjong.Calls) record {
+		rec := record{
+			posCalls:    posCalls,
+			boardStates: make(map[mahjong.Wind]*mahjong.BoardState, 4),
+			numEvents:   len(game.GetAllGlobalEvents()),
+		}
+		for wind := range game.PosPlayer {
+			rec.boardStates[wind] = mahjong.BoardStateCopy(game.GetPosBoardState(wind, posCalls[wind]))
+		}
+		return rec
+	}
+	check := func(game *mahjong.Game, posCalls map[mahjong.Wind]mahjong.Calls, rec record) {
+		if !reflect.DeepEqual(posCalls, rec.posCalls) {
+			t.Fatalf("seed %d: valid calls not restored", seed)
+		}
+		if len(game.GetAllGlobalEvents()) != rec.numEvents {
+			t.Fatalf("seed %d: %d events after undo, expect %d", seed, len(game.GetAllGlobalEvents()), rec.numEvents)
+		}
+		for wind, boardState := range rec.boardStates {
+			if !game.GetPosBoardState(wind, posCalls[wind]).Equal(boardState) {
+				t.Fatalf("seed %d: board state of %s not restored", seed, wind)
+			}
+		}
+	}
+	randomCall := func(posCalls map[mahjong.Wind]mahjong.Calls) map[mahjong.Wind]*mahjong.Call {
+		posCall := make(map[mahjong.Wind]*mahjong.Call, 4)
+		for wind := mahjong.East; wind <= mahjong.North; wind++ {
+			if calls, ok := posCalls[wind]; ok {
+				posCall[wind] = calls[r.Intn(len(calls))]
+			}
+		}
+		return posCall
+	}
+
+	game := mahjong.NewMahjongGame(seed, nil)
+	players := newPlayers(4)
+	posCalls := game.Reset(players, nil)
+	if _, err := game.Undo(); !errors.Is(err, mahjong.ErrNoHistory) {
+		t.Fatalf("expect ErrNoHistory without history depth, got %v", err)
+	}
+	game.SetHistoryDepth(depth)
+
+	var records []record
+	var numHistory = 0
+	var flag = mahjong.EndTypeNone
+	var err error
+	for flag != mahjong.EndTypeGame {
+		records = append(records, snapshot(game, posCalls))
+		posCalls, flag = game.Step(randomCall(posCalls))
+		if numHistory < depth {
+			numHistory++
+		}
+
+		// undo some steps and play again from there
+		if r.Intn(20) == 0 && flag != mahjong.EndTypeGame {
+			for n := 1 + r.Intn(numHistory); n > 0; n-- {
+				if posCalls, err = game.Undo(); err != nil {
+					t.Fatalf("seed %d: %v", seed, err)
+				}
+				check(game, posCalls, records[len(records)-1])
+				records = records[:len(records)-1]
+				numHistory--
+			}
+			for i, player := range players {
+				if game.PosPlayer[player.Wind] != player {
+					t.Fatalf("seed %d: player %d is replaced by undo", seed, i)
+				}
+			}
+		}
+	}
+
+	// the history is limited by the depth
+	for n := 0; n < numHistory; n++ {
+		if posCalls, err = game.Undo(); err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+		check(game, posCalls, records[len(records)-1])
+		records = records[:len(records)-1]
+	}
+	if _, err = game.Undo(); !errors.Is(err, mahjong.ErrNoHistory) {
+		t.Fatalf("seed %d: expect ErrNoHistory beyond the depth, got %v", seed, err)
+	}
+
+	// rewind to the state with the same number of global events
+	rec := snapshot(game, posCalls)
+	flag = mahjong.EndTypeNone
+	for n := 0; n < depth && flag != mahjong.EndTypeGame; n++ {
+		posCalls, flag = game.Step(randomCall(posCalls))
+	}
+	if posCalls, err = game.RewindTo(rec.numEvents); err != nil {
+		t.Fatalf("seed %d: %v", seed, err)
+	}
+	check(game, posCalls, rec)
+	if _, err = game.RewindTo(0); !errors.Is(err, mahjong.ErrNoHistory) {
+		t.Fatalf("seed %d: expect ErrNoHistory beyond the depth, got %v", seed, err)
+	}
+}
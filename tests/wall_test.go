@@ -0,0 +1,209 @@
+package tests
+
+import (
+	"github.com/hphphp123321/go-common"
+	"github.com/hphphp123321/mahjong-go/mahjong"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestWallGenerator(t *testing.T) {
+	var seed = rand.Int63()
+
+	playGame := func(rule *mahjong.Rule, options ...mahjong.GameOption) *mahjong.Game {
+		r := rand.New(rand.NewSource(seed))
+		game := mahjong.NewMahjongGame(seed, rule, options...)
+		posCalls := game.Reset(newPlayers(rule.NumPlayers()), nil)
+		var flag = mahjong.EndTypeNone
+		for flag != mahjong.EndTypeGame {
+			posCall := make(map[mahjong.Wind]*mahjong.Call, 4)
+			for wind := mahjong.East; wind <= mahjong.North; wind++ {
+				if calls, ok := posCalls[wind]; ok {
+					posCall[wind] = calls[r.Intn(len(calls))]
+				}
+			}
+			posCalls, flag = game.Step(posCall)
+		}
+		return game
+	}
+	walls := func(game *mahjong.Game) []*mahjong.EventGlobalInit {
+		var inits []*mahjong.EventGlobalInit
+		for _, event := range game.GetAllGlobalEvents() {
+			if e, ok := event.(*mahjong.EventGlobalInit); ok {
+				inits = append(inits, e)
+			}
+		}
+		return inits
+	}
+	wallTiles := func(isSanma bool) mahjong.Tiles {
+		var tiles mahjong.Tiles
+		for i := 0; i < mahjong.NumTiles; i++ {
+			if isSanma && common.SliceContain(mahjong.SanmaRemovedTileClasses, mahjong.Tile(i).Class()) {
+				continue
+			}
+			tiles = append(tiles, mahjong.Tile(i))
+		}
+		return tiles
+	}
+
+	// math/rand shuffle with the same seed deals the same walls as the default
+	rule := mahjong.GetDefaultRule()
+	game := playGame(rule, mahjong.WithWallGenerator(mahjong.NewRandWallGenerator(seed)))
+	if !reflect.DeepEqual(game.GetAllGlobalEvents(), playGame(rule).GetAllGlobalEvents()) {
+		t.Fatalf("seed %d: walls of the math/rand generator differ from the default", seed)
+	}
+
+	// every round can be reproduced by its metadata
+	for _, rule := range []*mahjong.Rule{mahjong.GetDefaultRule(), mahjong.GetDefaultSanmaRule()} {
+		generator := mahjong.HashWallGenerator{}
+		for _, e := range walls(playGame(rule, mahjong.WithWallGenerator(generator))) {
+			info := mahjong.WallInfo{Seed: seed, NumGame: e.NumGame, WindRound: e.WindRound, NumHonba: e.NumHonba, IsSanma: rule.IsSanma}
+			wall, err := generator.Wall(info, wallTiles(rule.IsSanma))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !common.SliceEqual(wall, e.AllTiles) {
+				t.Fatalf("seed %d: wall of round %d can't be reproduced", seed, e.NumGame)
+			}
+		}
+	}
+	if (mahjong.HashWallGenerator{}).RoundSeed(mahjong.WallInfo{Seed: seed}) == (mahjong.HashWallGenerator{}).RoundSeed(mahjong.WallInfo{Seed: seed, NumGame: 1}) {
+		t.Fatal("rounds share the seed")
+	}
+
+	// fixed walls are dealt in order
+	r := rand.New(rand.NewSource(seed))
+	fixed := &mahjong.FixedWallGenerator{}
+	for i := 0; i < 100; i++ {
+		wall := wallTiles(false)
+		r.Shuffle(len(wall), func(i, j int) { wall[i], wall[j] = wall[j], wall[i] })
+		fixed.Walls = append(fixed.Walls, wall)
+	}
+	for _, e := range walls(playGame(rule, mahjong.WithWallGenerator(fixed))) {
+		if !common.SliceEqual(fixed.Walls[e.NumGame], e.AllTiles) {
+			t.Fatalf("seed %d: wall of round %d is not the fixed wall", seed, e.NumGame)
+		}
+	}
+	tiles := mahjong.NewMahjongTiles(nil)
+	badWall := fixed.Walls[0].Copy()
+	badWall[0] = badWall[1]
+	if err := tiles.ResetWall(badWall); err == nil {
+		t.Fatal("wall with a duplicated tile is accepted")
+	}
+
+	// crypto walls hold every tile once
+	for _, e := range walls(playGame(rule, mahjong.WithWallGenerator(mahjong.CryptoWallGenerator{}))) {
+		if err := tiles.ResetWall(e.AllTiles); err != nil {
+			t.Fatalf("crypto wall of round %d: %v", e.NumGame, err)
+		}
+	}
+}
+
+func TestWallGeneratorClone(t *testing.T) {
+	var seed = rand.Int63()
+	r := rand.New(rand.NewSource(seed))
+	lastWall := func(game *mahjong.Game) mahjong.Tiles {
+		var wall mahjong.Tiles
+		for _, event := range game.GetAllGlobalEvents() {
+			if e, ok := event.(*mahjong.EventGlobalInit); ok {
+				wall = e.AllTiles
+			}
+		}
+		return wall
+	}
+
+	game := mahjong.NewMahjongGame(seed, nil, mahjong.WithWallGenerator(mahjong.NewRandWallGenerator(seed)))
+	posCalls := game.Reset(newPlayers(4), nil)
+	game.SetHistoryDepth(1)
+	var flag = mahjong.EndTypeNone
+	for flag == mahjong.EndTypeNone {
+		posCall := make(map[mahjong.Wind]*mahjong.Call, 4)
+		for wind, calls := range posCalls {
+			posCall[wind] = calls[r.Intn(len(calls))]
+		}
+		posCalls, flag = game.Step(posCall)
+	}
+	if flag == mahjong.EndTypeGame {
+		t.Skipf("seed %d: the game ends in the first round", seed)
+	}
+
+	// the next step deals the wall of the next round
+	nextCall := func() map[mahjong.Wind]*mahjong.Call {
+		posCall := make(map[mahjong.Wind]*mahjong.Call, 4)
+		for wind := range posCalls {
+			posCall[wind] = mahjong.NextCall
+		}
+		return posCall
+	}
+	clone := game.Clone()
+	clone.Step(nextCall())
+	game.Step(nextCall())
+	wall := lastWall(game)
+	if !reflect.DeepEqual(lastWall(clone), wall) {
+		t.Fatalf("seed %d: the clone deals a different wall", seed)
+	}
+
+	// undo across the round boundary deals the same wall again
+	if _, err := game.Undo(); err != nil {
+		t.Fatalf("seed %d: %v", seed, err)
+	}
+	game.Step(nextCall())
+	if !reflect.DeepEqual(lastWall(game), wall) {
+		t.Fatalf("seed %d: the wall differs after undo", seed)
+	}
+}
+
+func TestWallGeneratorError(t *testing.T) {
+	var seed = rand.Int63()
+	r := rand.New(rand.NewSource(seed))
+	fixed := &mahjong.FixedWallGenerator{Walls: []mahjong.Tiles{prepareWall(r, nil)}}
+	game := mahjong.NewMahjongGame(seed, nil, mahjong.WithWallGenerator(fixed))
+	game.SetHistoryDepth(1)
+	posCalls := game.Reset(newPlayers(4), nil)
+	var flag = mahjong.EndTypeNone
+	var err error
+	for flag == mahjong.EndTypeNone {
+		posCall := make(map[mahjong.Wind]*mahjong.Call, 4)
+		for wind, calls := range posCalls {
+			posCall[wind] = calls[r.Intn(len(calls))]
+		}
+		if posCalls, flag, err = game.StepE(posCall); err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+	}
+	if flag == mahjong.EndTypeGame {
+		t.Skipf("seed %d: the game ends in the first round", seed)
+	}
+
+	// the walls run out, the game is not changed
+	nextCall := make(map[mahjong.Wind]*mahjong.Call, 4)
+	for wind := range posCalls {
+		nextCall[wind] = mahjong.NextCall
+	}
+	numEvents := len(game.GetAllGlobalEvents())
+	numGame := game.NumGame
+	if _, _, err = game.StepE(nextCall); err == nil {
+		t.Fatalf("seed %d: no error without a wall", seed)
+	}
+	if len(game.GetAllGlobalEvents()) != numEvents || game.NumGame != numGame {
+		t.Fatalf("seed %d: game changed by the error of the wall generator", seed)
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatalf("seed %d: Step should panic without a wall", seed)
+			}
+		}()
+		game.Step(nextCall)
+	}()
+
+	// the step goes on once the wall is prepared, the undo returns to the end of the first round
+	fixed.Walls = append(fixed.Walls, prepareWall(r, nil))
+	if _, _, err = game.StepE(nextCall); err != nil || game.NumGame != numGame+1 {
+		t.Fatalf("seed %d: second round not dealt: %v", seed, err)
+	}
+	if _, err = game.Undo(); err != nil || game.NumGame != numGame || len(game.GetAllGlobalEvents()) != numEvents {
+		t.Fatalf("seed %d: undo to the end of the first round failed: %v", seed, err)
+	}
+}